@@ -4,6 +4,7 @@ import (
 	"context"
 	"log"
 	"os"
+	"time"
 
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 
@@ -11,6 +12,7 @@ import (
 	"github.com/zxc7563598/fintrack-backend/i18n"
 	"github.com/zxc7563598/fintrack-backend/middleware"
 	"github.com/zxc7563598/fintrack-backend/router"
+	"github.com/zxc7563598/fintrack-backend/service"
 )
 
 // App 结构体
@@ -39,6 +41,14 @@ func (a *App) OnDomReady(ctx context.Context) {
 	i18n.InitI18n()
 	// 初始化 SQLite
 	config.InitDB()
+	// 启动周期记账任务
+	service.StartRecurringBillJob(config.DB, time.Hour)
+	// 启动净资产月度快照任务
+	service.StartNetWorthSnapshotJob(config.DB, time.Hour)
+	// 启动回收站自动清理任务
+	service.StartBillTrashJob(config.DB, config.Cfg.Trash.Retention(), time.Hour)
+	// 启动附件清理任务
+	service.StartAttachmentCleanupJob(service.NewAttachmentStore(config.DB, config.Cfg.Attachment.Dir(), config.Cfg.Attachment.MaxSize()), time.Hour)
 	// 设置私钥文件系统
 	middleware.SetPrivateKeyFS(privateKeyFile)
 	// 启动后端服务器
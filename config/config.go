@@ -6,6 +6,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/zxc7563598/fintrack-backend/utils/helpers"
 	"gopkg.in/yaml.v3"
 )
 
@@ -19,9 +20,59 @@ type JWTConfig struct {
 	RefreshTokenExp time.Duration `yaml:"refresh_token_exp"`
 }
 
+type TrashConfig struct {
+	RetentionDays int `yaml:"retention_days"` // 回收站保留天数，未配置时为30天，小于0时不自动清理
+}
+
+// 默认回收站保留天数
+const DefaultTrashRetentionDays = 30
+
+// Retention 回收站保留时长，返回0表示不自动清理
+func (t TrashConfig) Retention() time.Duration {
+	days := t.RetentionDays
+	if days == 0 {
+		days = DefaultTrashRetentionDays
+	}
+	if days < 0 {
+		return 0
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+type AttachmentConfig struct {
+	Path      string `yaml:"path"`        // 附件保存目录（相对数据目录），未配置时为 data/attachments
+	MaxSizeMB int    `yaml:"max_size_mb"` // 单个附件大小上限（MB），未配置时为10MB
+}
+
+// 默认附件保存目录及单个附件大小上限（MB）
+const (
+	DefaultAttachmentPath      = "data/attachments"
+	DefaultAttachmentMaxSizeMB = 10
+)
+
+// Dir 附件保存目录
+func (a AttachmentConfig) Dir() string {
+	path := a.Path
+	if path == "" {
+		path = DefaultAttachmentPath
+	}
+	return helpers.GetDataPath(path)
+}
+
+// MaxSize 单个附件大小上限（字节）
+func (a AttachmentConfig) MaxSize() int64 {
+	size := a.MaxSizeMB
+	if size <= 0 {
+		size = DefaultAttachmentMaxSizeMB
+	}
+	return int64(size) << 20
+}
+
 type Config struct {
-	Database DatabaseConfig `yaml:"database"`
-	JWT      JWTConfig      `yaml:"jwt"`
+	Database   DatabaseConfig   `yaml:"database"`
+	JWT        JWTConfig        `yaml:"jwt"`
+	Trash      TrashConfig      `yaml:"trash"`
+	Attachment AttachmentConfig `yaml:"attachment"`
 }
 
 var Cfg Config
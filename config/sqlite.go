@@ -34,9 +34,143 @@ func InitDB() {
 		&model.Token{},
 		&model.BillRecord{},
 		&model.UserMailbox{},
+		&model.Tag{},
+		&model.BillRecordTag{},
+		&model.BillSplit{},
+		&model.Merchant{},
+		&model.MerchantAlias{},
+		&model.BillRule{},
+		&model.PaymentMethodAlias{},
+		&model.Subscription{},
+		&model.RecurringBill{},
+		&model.RecurringBillOccurrence{},
+		&model.Budget{},
+		&model.Envelope{},
+		&model.EnvelopeTransfer{},
+		&model.SavingsGoal{},
+		&model.CreditAccount{},
+		&model.NetWorthItem{},
+		&model.NetWorthSnapshot{},
+		&model.InvestmentHolding{},
+		&model.InvestmentTransaction{},
+		&model.InvestmentPrice{},
+		&model.Person{},
+		&model.Loan{},
+		&model.LoanRepayment{},
+		&model.ReimbursementClaim{},
+		&model.ReimbursementItem{},
+		&model.SplitGroup{},
+		&model.SplitGroupMember{},
+		&model.SplitExpense{},
+		&model.SplitShare{},
+		&model.SplitSettlement{},
+		&model.Ledger{},
+		&model.LedgerMember{},
+		&model.LedgerInvitation{},
+		&model.BillRecordHistory{},
+		&model.BillTradeTombstone{},
+		&model.BillMerge{},
+		&model.BillRefund{},
+		&model.AttachmentFile{},
+		&model.BillAttachment{},
 	)
 	if err != nil {
 		log.Fatalf("自动迁移失败: %v", err)
 	}
 	log.Println("✅ 数据表自动迁移完成")
+	// 历史账单补全原始交易方式
+	err = DB.Unscoped().Model(&model.BillRecord{}).
+		Where("raw_payment_method IS NULL OR raw_payment_method = ''").
+		Update("raw_payment_method", gorm.Expr("payment_method")).Error
+	if err != nil {
+		log.Fatalf("数据迁移失败: %v", err)
+	}
+	// 历史交易方式别名、标签、商户、分类规则、预算、信封、储蓄目标、信用账户、资产负债、订阅及周期记账模板归入用户的默认账本（尚无默认账本的在创建默认账本时归入）
+	for _, m := range model.LedgerScopedModels() {
+		stmt := &gorm.Statement{DB: DB}
+		if err = stmt.Parse(m); err != nil {
+			log.Fatalf("数据迁移失败: %v", err)
+		}
+		err = DB.Unscoped().Model(m).
+			Where("ledger_id = 0").
+			Update("ledger_id", gorm.Expr("COALESCE((?), 0)", DB.Model(&model.Ledger{}).
+				Select("id").
+				Where("owner_id = "+stmt.Schema.Table+".user_id AND is_default = ?", true).
+				Limit(1))).Error
+		if err != nil {
+			log.Fatalf("数据迁移失败: %v", err)
+		}
+	}
+	// 信封预算模式由用户迁移到用户的默认账本（尚无默认账本的保留在用户上，待下次启动时迁移）
+	if DB.Migrator().HasColumn(&model.User{}, "envelope_mode") {
+		hasDefault := "users.envelope_mode = ? AND EXISTS (SELECT 1 FROM ledgers WHERE ledgers.owner_id = users.id AND ledgers.is_default = ? AND ledgers.deleted_at IS NULL)"
+		err = DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec("UPDATE ledgers SET envelope_mode = ?, envelope_start = (SELECT users.envelope_start FROM users WHERE users.id = ledgers.owner_id) WHERE is_default = ? AND owner_id IN (SELECT id FROM users WHERE "+hasDefault+")", true, true, true, true).Error; err != nil {
+				return err
+			}
+			return tx.Exec("UPDATE users SET envelope_mode = ? WHERE "+hasDefault, false, true, true).Error
+		})
+		if err != nil {
+			log.Fatalf("数据迁移失败: %v", err)
+		}
+	}
+	// 标签归入默认账本后，其他账本中的账单仍引用该标签时，在账单所在账本复制同名标签并改为关联复制的标签
+	if err = splitTagsByLedger(DB); err != nil {
+		log.Fatalf("数据迁移失败: %v", err)
+	}
+	// 历史账单补全规范化交易状态
+	var statuses []string
+	err = DB.Unscoped().Model(&model.BillRecord{}).
+		Where("trade_status_type = 0").
+		Distinct().
+		Pluck("COALESCE(trade_status, '')", &statuses).Error
+	if err != nil {
+		log.Fatalf("数据迁移失败: %v", err)
+	}
+	for _, status := range statuses {
+		err = DB.Unscoped().Model(&model.BillRecord{}).
+			Where("trade_status_type = 0 AND COALESCE(trade_status, '') = ?", status).
+			Update("trade_status_type", model.TradeStatusTypeFromString(status)).Error
+		if err != nil {
+			log.Fatalf("数据迁移失败: %v", err)
+		}
+	}
+}
+
+// 将被其他账本的账单引用的标签复制到账单所在账本，并将关联改为复制的标签
+func splitTagsByLedger(db *gorm.DB) error {
+	var pairs []struct {
+		TagID    uint
+		LedgerID uint
+	}
+	err := db.Table("bill_record_tags").
+		Select("DISTINCT bill_record_tags.tag_id, bill_records.ledger_id").
+		Joins("JOIN bill_records ON bill_records.id = bill_record_tags.bill_record_id").
+		Joins("JOIN tags ON tags.id = bill_record_tags.tag_id AND tags.deleted_at IS NULL").
+		Where("bill_records.ledger_id <> 0 AND tags.ledger_id <> bill_records.ledger_id").
+		Scan(&pairs).Error
+	if err != nil {
+		return err
+	}
+	for _, p := range pairs {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var tag model.Tag
+			if err := tx.First(&tag, p.TagID).Error; err != nil {
+				return err
+			}
+			target := model.Tag{UserID: tag.UserID, LedgerID: p.LedgerID, Name: tag.Name}
+			if err := tx.Where("ledger_id = ? AND name = ?", p.LedgerID, tag.Name).FirstOrCreate(&target).Error; err != nil {
+				return err
+			}
+			billIDs := tx.Unscoped().Model(&model.BillRecord{}).Select("id").Where("ledger_id = ?", p.LedgerID)
+			if err := tx.Exec("INSERT OR IGNORE INTO bill_record_tags (bill_record_id, tag_id, created_at) SELECT bill_record_id, ?, created_at FROM bill_record_tags WHERE tag_id = ? AND bill_record_id IN (?)", target.ID, tag.ID, billIDs).Error; err != nil {
+				return err
+			}
+			return tx.Where("tag_id = ? AND bill_record_id IN (?)", tag.ID, billIDs).Delete(&model.BillRecordTag{}).Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
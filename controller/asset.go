@@ -9,6 +9,7 @@ import (
 	"github.com/zxc7563598/fintrack-backend/utils/response"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type MonthlyStat struct {
@@ -34,6 +35,21 @@ type BillSummary struct {
 	Last12Months []MonthlyStat `json:"last12_months"`
 }
 
+// 用户可访问账本的合计
+type LedgerTotal struct {
+	LedgerID     uint          `json:"ledger_id"`
+	Name         string        `json:"name"`
+	TotalCount   int64         `json:"total_count"`
+	TotalIncome  helpers.Money `json:"total_income"`
+	TotalExpense helpers.Money `json:"total_expense"`
+}
+
+// 资产概览请求体
+type AssetOverviewRequest struct {
+	IncludeUnsettled bool `json:"include_unsettled"` // 是否计入处理中、已关闭、失败的交易
+}
+
+// 资产概览接口：summary 为当前账本数据，consolidated 为用户所有账本合并后的数据
 func AssetOverviewHandler(c *gin.Context) {
 	// 获取用户ID
 	userIDAny, exists := c.Get("user_id")
@@ -46,6 +62,61 @@ func AssetOverviewHandler(c *gin.Context) {
 		response.Fail(c, 300002)
 		return
 	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(AssetOverviewRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	// 获取用户可访问的账本
+	var ledgers []LedgerTotal
+	if err := config.DB.Model(&model.Ledger{}).
+		Select("ledgers.id AS ledger_id, ledgers.name").
+		Joins("JOIN ledger_members ON ledger_members.ledger_id = ledgers.id").
+		Where("ledger_members.user_id = ?", userID).
+		Order("ledgers.is_default DESC, ledgers.id").
+		Scan(&ledgers).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	ledgerIDs := make([]uint, 0, len(ledgers))
+	for i, l := range ledgers {
+		ledgerIDs = append(ledgerIDs, l.LedgerID)
+		var agg struct {
+			Income, Expense float64
+			Count           int64
+		}
+		if err := overviewBills([]uint{l.LedgerID}, req.IncludeUnsettled).
+			Select("SUM(CASE WHEN income_type = 1 THEN amount ELSE 0 END) as income, SUM(CASE WHEN income_type = 2 THEN amount ELSE 0 END) as expense, COUNT(*) as count").
+			Scan(&agg).Error; err != nil {
+			response.Fail(c, 100001)
+			return
+		}
+		ledgers[i].TotalCount = agg.Count
+		ledgers[i].TotalIncome = helpers.Money(agg.Income)
+		ledgers[i].TotalExpense = helpers.Money(agg.Expense)
+	}
+	// 返回数据
+	response.Ok(c, gin.H{
+		"summary":      billSummary([]uint{ledgerID}, req.IncludeUnsettled),
+		"consolidated": billSummary(ledgerIDs, req.IncludeUnsettled),
+		"ledgers":      ledgers,
+	})
+}
+
+// 概览统计的账单，includeUnsettled 为 false 时不含处理中、已关闭、失败的交易
+func overviewBills(ledgerIDs []uint, includeUnsettled bool) *gorm.DB {
+	db := config.DB.Model(&model.BillRecord{}).Where("ledger_id IN ?", ledgerIDs)
+	if !includeUnsettled {
+		db = db.Where("trade_status_type NOT IN ?", model.UnsettledTradeStatusTypes)
+	}
+	return db
+}
+
+// 汇总指定账本的收支概览
+func billSummary(ledgerIDs []uint, includeUnsettled bool) BillSummary {
 	var summary BillSummary
 	now := time.Now()
 	// 总计收入、总计支出、总笔数（一次聚合查询）
@@ -54,8 +125,7 @@ func AssetOverviewHandler(c *gin.Context) {
 		Count           int64
 	}
 	var agg aggResult
-	config.DB.Model(&model.BillRecord{}).
-		Where("user_id = ?", userID).
+	overviewBills(ledgerIDs, includeUnsettled).
 		Select("SUM(CASE WHEN income_type = 1 THEN amount ELSE 0 END) as income, SUM(CASE WHEN income_type = 2 THEN amount ELSE 0 END) as expense, COUNT(*) as count").
 		Scan(&agg)
 	summary.TotalIncome = helpers.Money(agg.Income)
@@ -63,7 +133,7 @@ func AssetOverviewHandler(c *gin.Context) {
 	summary.TotalCount = agg.Count
 	// 最新一条记录
 	var times []int64
-	err := config.DB.Model(&model.BillRecord{}).Where("user_id = ?", userID).Order("trade_time DESC").Limit(1).Pluck("trade_time", &times).Error
+	err := overviewBills(ledgerIDs, includeUnsettled).Order("trade_time DESC").Limit(1).Pluck("trade_time", &times).Error
 	if err != nil || len(times) == 0 {
 		summary.LastRecord = 0
 	} else {
@@ -74,7 +144,7 @@ func AssetOverviewHandler(c *gin.Context) {
 	monthStart := helpers.StartOfMonth(now)
 	twelveMonthsAgo := monthStart.AddDate(0, -11, 0) // 最近12个月
 	var records []model.BillRecord
-	config.DB.Where("user_id = ? AND trade_time >= ?", userID, twelveMonthsAgo.Unix()).Find(&records)
+	overviewBills(ledgerIDs, includeUnsettled).Where("trade_time >= ?", twelveMonthsAgo.Unix()).Find(&records)
 	// 初始化 Last12Months
 	summary.Last12Months = make([]MonthlyStat, 12)
 	for i := 0; i < 12; i++ {
@@ -140,8 +210,5 @@ func AssetOverviewHandler(c *gin.Context) {
 			}
 		}
 	}
-	// 返回数据
-	response.Ok(c, gin.H{
-		"summary": summary,
-	})
+	return summary
 }
@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"context"
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/cohesion-org/deepseek-go"
@@ -18,6 +20,8 @@ import (
 	"github.com/zxc7563598/fintrack-backend/service"
 	"github.com/zxc7563598/fintrack-backend/service/ai"
 	"github.com/zxc7563598/fintrack-backend/utils/response"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // 获取交易列表请求体
@@ -33,22 +37,14 @@ type GetBillListRequest struct {
 	PaymentMethod      *[]string `json:"payment_method"`       // 账户
 	Counterpartys      *[]string `json:"counterpartys"`        // 交易平台
 	TradeTypes         *[]string `json:"trade_types"`          // 交易分类
+	Tags               *[]uint   `json:"tags"`                 // 标签
+	Merchants          *[]uint   `json:"merchants"`            // 商户
 }
 
 // 获取交易列表接口
 func GetBillListHandler(c *gin.Context) {
-	layout := "2006-01-02"
-	// 获取用户ID
-	userIDAny, exists := c.Get("user_id")
-	if !exists {
-		response.Fail(c, 300001)
-		return
-	}
-	userID, ok := userIDAny.(uint)
-	if !ok {
-		response.Fail(c, 300002)
-		return
-	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
 	// 获取请求参数
 	req, ok := c.MustGet("payload").(GetBillListRequest)
 	if !ok {
@@ -58,8 +54,50 @@ func GetBillListHandler(c *gin.Context) {
 	// 获取账单数据
 	var records []dto.BillListItem
 	var total int64
-	db := config.DB.Model(&model.BillRecord{}).Where("user_id = ?", userID)
+	db := config.DB.Model(&model.BillRecord{}).Where("ledger_id = ?", ledgerID)
 	// 搜索条件
+	db = filterBillList(db, req)
+	// 计算总数
+	if err := db.Count(&total).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	// 排序
+	sortKey := "trade_time"
+	if req.SortKey != nil && *req.SortKey != "" {
+		sortKey = *req.SortKey
+	}
+	sortOrder := "desc"
+	if req.SortOrder != nil && (*req.SortOrder == "asc" || *req.SortOrder == "desc") {
+		sortOrder = *req.SortOrder
+	}
+	db = db.Order(fmt.Sprintf("%s %s", sortKey, sortOrder))
+	// 分页
+	page := 1
+	if req.Page != nil && *req.Page > 0 {
+		page = *req.Page
+	}
+	itemsPerPage := 20
+	if req.ItemsPerPage != nil && *req.ItemsPerPage > 0 {
+		itemsPerPage = *req.ItemsPerPage
+	}
+	offset := (page - 1) * itemsPerPage
+	db = db.Offset(offset).Limit(itemsPerPage)
+	// 查询
+	if err := db.Find(&records).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"total": total,
+		"data":  records,
+	})
+}
+
+// 按账单列表的筛选条件过滤账单
+func filterBillList(db *gorm.DB, req GetBillListRequest) *gorm.DB {
+	layout := "2006-01-02"
 	if req.StartFormattedDate != nil && *req.StartFormattedDate != "" {
 		t, err := time.ParseInLocation(layout, *req.StartFormattedDate, time.Local)
 		if err == nil {
@@ -96,44 +134,18 @@ func GetBillListHandler(c *gin.Context) {
 		db = db.Where("payment_method IN ?", *req.PaymentMethod)
 	}
 	if req.TradeTypes != nil && len(*req.TradeTypes) > 0 {
-		db = db.Where("trade_type IN ?", *req.TradeTypes)
-	}
-	// 计算总数
-	if err := db.Count(&total).Error; err != nil {
-		response.Fail(c, 100001)
-		return
-	}
-	// 排序
-	sortKey := "trade_time"
-	if req.SortKey != nil && *req.SortKey != "" {
-		sortKey = *req.SortKey
-	}
-	sortOrder := "desc"
-	if req.SortOrder != nil && (*req.SortOrder == "asc" || *req.SortOrder == "desc") {
-		sortOrder = *req.SortOrder
-	}
-	db = db.Order(fmt.Sprintf("%s %s", sortKey, sortOrder))
-	// 分页
-	page := 1
-	if req.Page != nil && *req.Page > 0 {
-		page = *req.Page
+		db = db.Where(
+			config.DB.Where("trade_type IN ?", *req.TradeTypes).
+				Or("id IN (?)", config.DB.Model(&model.BillSplit{}).Select("bill_record_id").Where("trade_type IN ?", *req.TradeTypes)),
+		)
 	}
-	itemsPerPage := 20
-	if req.ItemsPerPage != nil && *req.ItemsPerPage > 0 {
-		itemsPerPage = *req.ItemsPerPage
+	if req.Tags != nil && len(*req.Tags) > 0 {
+		db = db.Where("id IN (?)", service.TaggedBillIDs(config.DB, *req.Tags))
 	}
-	offset := (page - 1) * itemsPerPage
-	db = db.Offset(offset).Limit(itemsPerPage)
-	// 查询
-	if err := db.Find(&records).Error; err != nil {
-		response.Fail(c, 100001)
-		return
+	if req.Merchants != nil && len(*req.Merchants) > 0 {
+		db = db.Where("merchant_id IN ?", *req.Merchants)
 	}
-	// 返回成功
-	response.Ok(c, gin.H{
-		"total": total,
-		"data":  records,
-	})
+	return db
 }
 
 // 获取交易信息请求体
@@ -143,17 +155,8 @@ type GetBillInfoRequest struct {
 
 // 获取交易信息接口
 func GetBillInfoHandler(c *gin.Context) {
-	// 获取用户ID
-	userIDAny, exists := c.Get("user_id")
-	if !exists {
-		response.Fail(c, 300001)
-		return
-	}
-	userID, ok := userIDAny.(uint)
-	if !ok {
-		response.Fail(c, 300002)
-		return
-	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
 	// 获取请求参数
 	req, ok := c.MustGet("payload").(GetBillInfoRequest)
 	if !ok {
@@ -163,42 +166,78 @@ func GetBillInfoHandler(c *gin.Context) {
 	var tradeTypes []string
 	if err := config.DB.Model(&model.BillRecord{}).
 		Distinct("trade_type").
-		Where("user_id = ?", userID).
+		Where("ledger_id = ?", ledgerID).
 		Pluck("trade_type", &tradeTypes).Error; err != nil {
 		response.Fail(c, 100001)
 		return
 	}
+	// 已规范化到商户的交易对方通过商户列表筛选，这里只返回未匹配的原始交易对方
 	var counterpartys []string
 	if err := config.DB.Model(&model.BillRecord{}).
 		Distinct("counterparty").
-		Where("user_id = ?", userID).
+		Where("ledger_id = ? AND merchant_id = 0", ledgerID).
 		Pluck("counterparty", &counterpartys).Error; err != nil {
 		response.Fail(c, 100001)
 		return
 	}
+	var merchants []dto.MerchantOptionItem
+	if err := config.DB.Model(&model.Merchant{}).
+		Select("id, name").
+		Where("ledger_id = ?", ledgerID).
+		Scan(&merchants).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
 	var paymentMethod []string
 	if err := config.DB.Model(&model.BillRecord{}).
 		Distinct("payment_method").
-		Where("user_id = ?", userID).
+		Where("ledger_id = ?", ledgerID).
 		Pluck("payment_method", &paymentMethod).Error; err != nil {
 		response.Fail(c, 100001)
 		return
 	}
+	var tags []dto.TagOptionItem
+	if err := config.DB.Model(&model.Tag{}).
+		Select("id, name").
+		Where("ledger_id = ?", ledgerID).
+		Scan(&tags).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
 	// 获取数据
 	var bill dto.BillInfoItem
+	tagIDs := []uint{}
+	splits := []dto.BillSplitItem{}
 	if req.ID > 0 {
-		result := config.DB.Model(&model.BillRecord{}).Where("id = ? and user_id = ?", req.ID, userID).First(&bill)
+		result := config.DB.Model(&model.BillRecord{}).Where("id = ? and ledger_id = ?", req.ID, ledgerID).First(&bill)
 		if result.Error != nil {
 			response.Fail(c, 100001)
 			return
 		}
+		if err := config.DB.Model(&model.BillRecordTag{}).
+			Where("bill_record_id = ?", bill.ID).
+			Pluck("tag_id", &tagIDs).Error; err != nil {
+			response.Fail(c, 100001)
+			return
+		}
+		if err := config.DB.Model(&model.BillSplit{}).
+			Where("bill_record_id = ?", bill.ID).
+			Order("id").
+			Find(&splits).Error; err != nil {
+			response.Fail(c, 100001)
+			return
+		}
 	}
 	// 返回数据
 	response.Ok(c, gin.H{
 		"trade_types":    tradeTypes,
 		"counterpartys":  counterpartys,
+		"merchants":      merchants,
 		"payment_method": paymentMethod,
+		"tags":           tags,
 		"data":           bill,
+		"tag_ids":        tagIDs,
+		"splits":         splits,
 	})
 }
 
@@ -230,6 +269,8 @@ func StoreBillRecordHandler(c *gin.Context) {
 		response.Fail(c, 300002)
 		return
 	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
 	// 获取请求参数
 	req, ok := c.MustGet("payload").(StoreBillRecordRequest)
 	if !ok {
@@ -241,23 +282,74 @@ func StoreBillRecordHandler(c *gin.Context) {
 		response.Fail(c, 100012)
 		return
 	}
+	// 商户规范化
+	normalizer, err := service.NewMerchantNormalizer(config.DB, ledgerID)
+	if err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	// 交易方式别名
+	resolver, err := service.NewPaymentMethodResolver(config.DB, ledgerID)
+	if err != nil {
+		response.Fail(c, 100001)
+		return
+	}
 	bill := model.BillRecord{
-		UserID:        userID,
-		Platform:      req.Platform,
-		IncomeType:    req.IncomeType,
-		TradeType:     req.TradeType,
-		ProductName:   req.ProductName,
-		Counterparty:  req.Counterparty,
-		PaymentMethod: req.PaymentMethod,
-		Amount:        req.Amount,
-		TradeTime:     t.Unix(),
-		Remark:        req.Remark,
+		UserID:           userID,
+		LedgerID:         ledgerID,
+		Platform:         req.Platform,
+		IncomeType:       req.IncomeType,
+		TradeType:        req.TradeType,
+		ProductName:      req.ProductName,
+		Counterparty:     req.Counterparty,
+		MerchantID:       normalizer.Match(req.Counterparty),
+		PaymentMethod:    resolver.Resolve(req.PaymentMethod),
+		RawPaymentMethod: req.PaymentMethod,
+		Amount:           req.Amount,
+		TradeTime:        t.Unix(),
+		Remark:           req.Remark,
 	}
 	if req.ID > 0 {
+		// 已拆分的账单，拆分金额合计需与修改后的金额一致
+		var splits []model.BillSplit
+		if err := config.DB.Where("bill_record_id = ?", req.ID).Find(&splits).Error; err != nil {
+			response.Fail(c, 100001)
+			return
+		}
+		if errors.Is(service.CheckBillSplits(splits, req.Amount), service.ErrBillSplitsMismatch) {
+			response.Fail(c, 100028)
+			return
+		}
+		befores, err := service.SnapshotBillRecords(config.DB.Where("ledger_id = ? AND deleted_at IS NULL", ledgerID), []uint{req.ID})
+		if err != nil {
+			response.Fail(c, 100001)
+			return
+		}
+		if len(befores) == 0 {
+			response.Fail(c, 100051)
+			return
+		}
+		// 账户未修改时保留原有的归类结果与原始账户名称（请求中的是归类后的名称）
+		omits := []string{"user_id"}
+		if req.PaymentMethod == befores[req.ID].PaymentMethod {
+			omits = append(omits, "payment_method", "raw_payment_method")
+		}
 		// 修改
-		err := config.DB.Model(&model.BillRecord{}).
-			Where("id = ? AND user_id = ?", req.ID, userID).
-			Updates(bill).Error
+		err = config.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&model.BillRecord{}).
+				Where("id = ? AND ledger_id = ?", req.ID, ledgerID).
+				Omit(omits...).
+				Updates(bill).Error; err != nil {
+				return err
+			}
+			// 商户可能变为未匹配，零值需单独更新
+			if err := tx.Model(&model.BillRecord{}).
+				Where("id = ? AND ledger_id = ?", req.ID, ledgerID).
+				Update("merchant_id", bill.MerchantID).Error; err != nil {
+				return err
+			}
+			return service.RecordBillChanges(tx, userID, model.BillSourceManual, model.BillActionUpdate, befores)
+		})
 		if err != nil {
 			response.Fail(c, 100013)
 			return
@@ -267,7 +359,14 @@ func StoreBillRecordHandler(c *gin.Context) {
 		bill.TradeNo = uuid.NewString()
 		bill.MerchantOrderNo = uuid.NewString()
 		bill.TradeStatus = "交易成功"
-		if err := config.DB.Create(&bill).Error; err != nil {
+		bill.TradeStatusType = uint8(model.TradeStatusSuccess)
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&bill).Error; err != nil {
+				return err
+			}
+			return service.RecordBillCreated(tx, userID, model.BillSourceManual, []model.BillRecord{bill})
+		})
+		if err != nil {
 			response.Fail(c, 100013)
 			return
 		}
@@ -294,13 +393,25 @@ func DeleteBillRecordHandler(c *gin.Context) {
 		response.Fail(c, 300002)
 		return
 	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
 	// 获取请求参数
 	req, ok := c.MustGet("payload").(DeleteBillRecordRequest)
 	if !ok {
 		response.Fail(c, 100010)
 		return
 	}
-	if err := config.DB.Where("id = ? and user_id = ?", req.ID, userID).Delete(&model.BillRecord{}).Error; err != nil {
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		befores, err := service.SnapshotBillRecords(tx.Where("ledger_id = ? AND deleted_at IS NULL", ledgerID), []uint{req.ID})
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("id = ? and ledger_id = ?", req.ID, ledgerID).Delete(&model.BillRecord{}).Error; err != nil {
+			return err
+		}
+		return service.RecordBillChanges(tx, userID, model.BillSourceManual, model.BillActionDelete, befores)
+	})
+	if err != nil {
 		response.Fail(c, 100014)
 		return
 	}
@@ -308,14 +419,14 @@ func DeleteBillRecordHandler(c *gin.Context) {
 	response.Ok(c, gin.H{})
 }
 
-// 获取账单日历请求体
-type GetBillCalendarRequest struct {
-	StartAt string `json:"start_at" binding:"required"`
-	EndAt   string `json:"end_at" binding:"required"`
+// 移动账单到其他账本请求体
+type MoveBillRecordsRequest struct {
+	IDs            []uint `json:"ids" binding:"required"`              // 账单ID列表
+	TargetLedgerID uint   `json:"target_ledger_id" binding:"required"` // 目标账本ID
 }
 
-// 获取账单日历接口
-func GetBillCalendarHandler(c *gin.Context) {
+// 移动账单到其他账本接口，需要在目标账本具备编辑权限
+func MoveBillRecordsHandler(c *gin.Context) {
 	// 获取用户ID
 	userIDAny, exists := c.Get("user_id")
 	if !exists {
@@ -327,6 +438,193 @@ func GetBillCalendarHandler(c *gin.Context) {
 		response.Fail(c, 300002)
 		return
 	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(MoveBillRecordsRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	if len(req.IDs) == 0 || req.TargetLedgerID == ledgerID {
+		response.Fail(c, 300013)
+		return
+	}
+	role, err := service.LedgerRoleOf(config.DB, req.TargetLedgerID, userID)
+	if err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	if role == 0 {
+		response.Fail(c, 300014)
+		return
+	}
+	if !role.Allows(model.LedgerEditor) {
+		response.Fail(c, 300015)
+		return
+	}
+	// 商户按账本区分，移动后按目标账本的商户规则重新规范化
+	normalizer, err := service.NewMerchantNormalizer(config.DB, req.TargetLedgerID)
+	if err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	// 移动数据
+	var count int64
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		befores, err := service.SnapshotBillRecords(tx.Where("ledger_id = ? AND deleted_at IS NULL", ledgerID), req.IDs)
+		if err != nil {
+			return err
+		}
+		result := tx.Model(&model.BillRecord{}).
+			Where("id IN ? AND ledger_id = ?", req.IDs, ledgerID).
+			Update("ledger_id", req.TargetLedgerID)
+		if result.Error != nil {
+			return result.Error
+		}
+		count = result.RowsAffected
+		moved := make([]uint, 0, len(befores))
+		merchants := map[uint][]uint{}
+		for id, b := range befores {
+			moved = append(moved, id)
+			merchantID := normalizer.Match(b.Counterparty)
+			merchants[merchantID] = append(merchants[merchantID], id)
+		}
+		for merchantID, ids := range merchants {
+			if err := tx.Model(&model.BillRecord{}).
+				Where("id IN ?", ids).
+				Update("merchant_id", merchantID).Error; err != nil {
+				return err
+			}
+		}
+		// 退款关联随账单移动，退款与原消费不再属于同一账本时取消关联
+		if err := tx.Where("ledger_id = ? AND (refund_bill_record_id IN ?) <> (original_bill_record_id IN ?)", ledgerID, moved, moved).
+			Delete(&model.BillRefund{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.BillRefund{}).
+			Where("ledger_id = ? AND refund_bill_record_id IN ?", ledgerID, moved).
+			Update("ledger_id", req.TargetLedgerID).Error; err != nil {
+			return err
+		}
+		// 附件、合并记录随账单移动
+		for _, m := range []any{&model.BillAttachment{}, &model.BillMerge{}} {
+			if err := tx.Model(m).
+				Where("ledger_id = ? AND bill_record_id IN ?", ledgerID, moved).
+				Update("ledger_id", req.TargetLedgerID).Error; err != nil {
+				return err
+			}
+		}
+		// 交易单号在原账本写入墓碑，避免再次导入原账本时重复导入已移走的交易
+		tombstones := []model.BillTradeTombstone{}
+		for _, b := range befores {
+			if b.TradeNo != "" {
+				tombstones = append(tombstones, model.BillTradeTombstone{LedgerID: ledgerID, TradeNo: b.TradeNo})
+			}
+		}
+		if len(tombstones) > 0 {
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(&tombstones, 100).Error; err != nil {
+				return err
+			}
+		}
+		return service.RecordBillChanges(tx, userID, model.BillSourceManual, model.BillActionUpdate, befores)
+	})
+	if err != nil {
+		response.Fail(c, 100023)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"count": count,
+	})
+}
+
+// 存储账单拆分明细请求体
+type StoreBillSplitsRequest struct {
+	ID     uint                `json:"id" binding:"required"` // 账单ID
+	Splits []dto.BillSplitItem `json:"splits"`                // 拆分明细，为空时取消拆分
+}
+
+// 存储账单拆分明细接口
+func StoreBillSplitsHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(StoreBillSplitsRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	// 获取账单
+	var bill model.BillRecord
+	if err := config.DB.Where("id = ? AND ledger_id = ?", req.ID, ledgerID).First(&bill).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	splits := make([]model.BillSplit, 0, len(req.Splits))
+	for _, item := range req.Splits {
+		splits = append(splits, model.BillSplit{
+			UserID:       userID,
+			BillRecordID: bill.ID,
+			TradeType:    strings.TrimSpace(item.TradeType),
+			Amount:       item.Amount,
+			Remark:       item.Remark,
+		})
+	}
+	// 校验拆分明细：至少两行，每行需有分类且金额大于0，合计等于账单金额
+	switch err := service.CheckBillSplits(splits, bill.Amount); {
+	case errors.Is(err, service.ErrInvalidBillSplits):
+		response.Fail(c, 100029)
+		return
+	case errors.Is(err, service.ErrBillSplitsMismatch):
+		response.Fail(c, 100028)
+		return
+	}
+	// 整体替换拆分明细
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		befores, err := service.SnapshotBillSplits(tx, []uint{bill.ID})
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("bill_record_id = ?", bill.ID).Delete(&model.BillSplit{}).Error; err != nil {
+			return err
+		}
+		if len(splits) > 0 {
+			if err := tx.Create(&splits).Error; err != nil {
+				return err
+			}
+		}
+		return service.RecordBillSplitChanges(tx, userID, model.BillSourceManual, befores)
+	})
+	if err != nil {
+		response.Fail(c, 100023)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{})
+}
+
+// 获取账单日历请求体
+type GetBillCalendarRequest struct {
+	StartAt string `json:"start_at" binding:"required"`
+	EndAt   string `json:"end_at" binding:"required"`
+}
+
+// 获取账单日历接口
+func GetBillCalendarHandler(c *gin.Context) {
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
 	// 获取请求参数
 	req, ok := c.MustGet("payload").(GetBillCalendarRequest)
 	if !ok {
@@ -350,7 +648,7 @@ func GetBillCalendarHandler(c *gin.Context) {
 
 	// 查询数据库
 	var records []model.BillRecord
-	if err := config.DB.Where("user_id = ? AND trade_time BETWEEN ? AND ?", userID, startUnix, endUnix).
+	if err := config.DB.Where("ledger_id = ? AND trade_time BETWEEN ? AND ?", ledgerID, startUnix, endUnix).
 		Find(&records).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -397,22 +695,15 @@ type ExportBillRequest struct {
 	PaymentMethod      *[]string `json:"payment_method"`       // 账户
 	Counterpartys      *[]string `json:"counterpartys"`        // 交易平台
 	TradeTypes         *[]string `json:"trade_types"`          // 交易分类
+	Tags               *[]uint   `json:"tags"`                 // 标签
+	Merchants          *[]uint   `json:"merchants"`            // 商户
 }
 
 // 账单导出接口
 func ExportBillHandler(c *gin.Context) {
 	layout := "2006-01-02"
-	// 获取用户ID
-	userIDAny, exists := c.Get("user_id")
-	if !exists {
-		response.Fail(c, 300001)
-		return
-	}
-	userID, ok := userIDAny.(uint)
-	if !ok {
-		response.Fail(c, 300002)
-		return
-	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
 	// 获取请求参数
 	req, ok := c.MustGet("payload").(ExportBillRequest)
 	if !ok {
@@ -421,7 +712,7 @@ func ExportBillHandler(c *gin.Context) {
 	}
 	// 获取账单数据
 	var records []dto.BillExportItem
-	db := config.DB.Model(&model.BillRecord{}).Where("user_id = ?", userID)
+	db := config.DB.Model(&model.BillRecord{}).Where("ledger_id = ?", ledgerID)
 	// 搜索条件
 	if req.StartFormattedDate != nil && *req.StartFormattedDate != "" {
 		t, err := time.ParseInLocation(layout, *req.StartFormattedDate, time.Local)
@@ -449,7 +740,16 @@ func ExportBillHandler(c *gin.Context) {
 		db = db.Where("payment_method IN ?", *req.PaymentMethod)
 	}
 	if req.TradeTypes != nil && len(*req.TradeTypes) > 0 {
-		db = db.Where("trade_type IN ?", *req.TradeTypes)
+		db = db.Where(
+			config.DB.Where("trade_type IN ?", *req.TradeTypes).
+				Or("id IN (?)", config.DB.Model(&model.BillSplit{}).Select("bill_record_id").Where("trade_type IN ?", *req.TradeTypes)),
+		)
+	}
+	if req.Tags != nil && len(*req.Tags) > 0 {
+		db = db.Where("id IN (?)", service.TaggedBillIDs(config.DB, *req.Tags))
+	}
+	if req.Merchants != nil && len(*req.Merchants) > 0 {
+		db = db.Where("merchant_id IN ?", *req.Merchants)
 	}
 	// 排序
 	sortKey := "trade_time"
@@ -511,6 +811,8 @@ func AnalysisBillHandler(c *gin.Context) {
 		response.Fail(c, 300002)
 		return
 	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
 	// 获取请求参数
 	req, ok := c.MustGet("payload").(AnalysisBillRequest)
 	if !ok {
@@ -519,7 +821,7 @@ func AnalysisBillHandler(c *gin.Context) {
 	}
 	// 获取账单数据
 	var records []dto.BillExportItem
-	db := config.DB.Model(&model.BillRecord{}).Where("user_id = ?", userID)
+	db := config.DB.Model(&model.BillRecord{}).Where("ledger_id = ?", ledgerID)
 	// 搜索条件
 	if req.StartFormattedDate != nil && *req.StartFormattedDate != "" {
 		t, err := time.ParseInLocation(layout, *req.StartFormattedDate, time.Local)
@@ -0,0 +1,247 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zxc7563598/fintrack-backend/config"
+	"github.com/zxc7563598/fintrack-backend/model"
+	"github.com/zxc7563598/fintrack-backend/service"
+	"github.com/zxc7563598/fintrack-backend/utils/response"
+)
+
+// 附件上传时表单字段、分隔符等额外允许的请求体大小
+const attachmentFormOverhead = 1 << 20
+
+// 账单附件存储
+func attachmentStore() *service.AttachmentStore {
+	return service.NewAttachmentStore(config.DB, config.Cfg.Attachment.Dir(), config.Cfg.Attachment.MaxSize())
+}
+
+// 账单附件
+type BillAttachmentItem struct {
+	ID           uint      `json:"id"`
+	BillRecordID uint      `json:"bill_record_id"`
+	UserID       uint      `json:"user_id"`
+	FileName     string    `json:"file_name"`
+	MimeType     string    `json:"mime_type"`
+	Size         int64     `json:"size"`
+	HasThumbnail bool      `json:"has_thumbnail"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// 账单附件上传接口，表单字段 file 为文件，bill_record_id 为账单ID
+func UploadBillAttachmentHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 限制请求体大小，避免超大文件在校验前被整体读入内存或临时文件
+	store := attachmentStore()
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, store.MaxSize()+attachmentFormOverhead)
+	if _, err := c.MultipartForm(); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			response.Fail(c, 100080)
+			return
+		}
+		response.Fail(c, 100008)
+		return
+	}
+	// 获取请求参数
+	billRecordID, err := strconv.ParseUint(c.PostForm("bill_record_id"), 10, 64)
+	if err != nil || billRecordID == 0 {
+		response.Fail(c, 300013)
+		return
+	}
+	var bill model.BillRecord
+	if err := config.DB.Select("id").Where("id = ? AND ledger_id = ?", billRecordID, ledgerID).First(&bill).Error; err != nil {
+		response.Fail(c, 100051)
+		return
+	}
+	// 获取上传的文件
+	file, err := c.FormFile("file")
+	if err != nil {
+		response.Fail(c, 100008)
+		return
+	}
+	if file.Size > store.MaxSize() {
+		response.Fail(c, 100080)
+		return
+	}
+	f, err := file.Open()
+	if err != nil {
+		response.Fail(c, 100008)
+		return
+	}
+	defer f.Close()
+	// 保存文件并创建附件记录
+	attachment := model.BillAttachment{
+		LedgerID:     ledgerID,
+		UserID:       userID,
+		BillRecordID: bill.ID,
+		FileName:     file.Filename,
+	}
+	stored, err := store.Attach(f, &attachment)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrAttachmentTooLarge):
+			response.Fail(c, 100080)
+		case errors.Is(err, service.ErrAttachmentEmpty):
+			response.Fail(c, 100008)
+		default:
+			response.Fail(c, 100007)
+		}
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"attachment": billAttachmentItem(attachment, stored),
+	})
+}
+
+// 获取账单附件列表请求体
+type GetBillAttachmentListRequest struct {
+	BillRecordID uint `json:"bill_record_id" binding:"required"` // 账单ID
+}
+
+// 获取账单附件列表接口
+func GetBillAttachmentListHandler(c *gin.Context) {
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(GetBillAttachmentListRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	// 获取数据
+	var attachments []model.BillAttachment
+	if err := config.DB.Where("ledger_id = ? AND bill_record_id = ?", ledgerID, req.BillRecordID).Order("id").Find(&attachments).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	fileIDs := make([]uint, 0, len(attachments))
+	for _, a := range attachments {
+		fileIDs = append(fileIDs, a.AttachmentFileID)
+	}
+	files := map[uint]model.AttachmentFile{}
+	if len(fileIDs) > 0 {
+		var rows []model.AttachmentFile
+		if err := config.DB.Where("id IN ?", uniqueUints(fileIDs)).Find(&rows).Error; err != nil {
+			response.Fail(c, 100001)
+			return
+		}
+		for _, f := range rows {
+			files[f.ID] = f
+		}
+	}
+	list := make([]BillAttachmentItem, 0, len(attachments))
+	for _, a := range attachments {
+		list = append(list, billAttachmentItem(a, files[a.AttachmentFileID]))
+	}
+	// 返回信息
+	response.Ok(c, gin.H{
+		"list": list,
+	})
+}
+
+func billAttachmentItem(a model.BillAttachment, f model.AttachmentFile) BillAttachmentItem {
+	return BillAttachmentItem{
+		ID:           a.ID,
+		BillRecordID: a.BillRecordID,
+		UserID:       a.UserID,
+		FileName:     a.FileName,
+		MimeType:     f.MimeType,
+		Size:         f.Size,
+		HasThumbnail: f.HasThumbnail,
+		CreatedAt:    a.CreatedAt,
+	}
+}
+
+// 下载账单附件请求体
+type DownloadBillAttachmentRequest struct {
+	ID        uint `json:"id" binding:"required"` // 附件ID
+	Thumbnail bool `json:"thumbnail"`             // 是否下载缩略图
+}
+
+// 下载账单附件接口
+func DownloadBillAttachmentHandler(c *gin.Context) {
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(DownloadBillAttachmentRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	// 获取数据
+	var attachment model.BillAttachment
+	if err := config.DB.Where("id = ? AND ledger_id = ?", req.ID, ledgerID).First(&attachment).Error; err != nil {
+		response.Fail(c, 100079)
+		return
+	}
+	var file model.AttachmentFile
+	if err := config.DB.First(&file, attachment.AttachmentFileID).Error; err != nil {
+		response.Fail(c, 100079)
+		return
+	}
+	store := attachmentStore()
+	// 返回文件
+	if req.Thumbnail {
+		if !file.HasThumbnail {
+			response.Fail(c, 100079)
+			return
+		}
+		c.Header("Content-Type", "image/jpeg")
+		c.File(store.ThumbnailPath(file))
+		return
+	}
+	c.Header("Content-Type", file.MimeType)
+	c.FileAttachment(store.Path(file), attachment.FileName)
+}
+
+// 删除账单附件请求体
+type DeleteBillAttachmentRequest struct {
+	ID uint `json:"id" binding:"required"` // 附件ID
+}
+
+// 删除账单附件接口，文件不再被其他附件引用时一并删除
+func DeleteBillAttachmentHandler(c *gin.Context) {
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(DeleteBillAttachmentRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	var attachment model.BillAttachment
+	if err := config.DB.Where("id = ? AND ledger_id = ?", req.ID, ledgerID).First(&attachment).Error; err != nil {
+		response.Fail(c, 100079)
+		return
+	}
+	// 删除数据
+	if err := config.DB.Delete(&attachment).Error; err != nil {
+		response.Fail(c, 100014)
+		return
+	}
+	if err := attachmentStore().Release([]uint{attachment.AttachmentFileID}); err != nil {
+		response.Fail(c, 100014)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{})
+}
@@ -0,0 +1,267 @@
+package controller
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zxc7563598/fintrack-backend/config"
+	"github.com/zxc7563598/fintrack-backend/model"
+	"github.com/zxc7563598/fintrack-backend/service"
+	"github.com/zxc7563598/fintrack-backend/utils/response"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 批量操作的账单范围：指定账单ID或使用账单列表的筛选条件，二选一
+// 筛选条件为空时需指定 all 确认操作当前账本的全部账单
+type BillBulkScope struct {
+	IDs    []uint              `json:"ids"`     // 账单ID列表
+	Filter *GetBillListRequest `json:"filter"`  // 账单列表筛选条件，分页与排序参数不生效
+	All    bool                `json:"all"`     // 是否操作当前账本全部账单（不指定ID且筛选条件为空时必须指定）
+	DryRun bool                `json:"dry_run"` // 仅返回受影响的数量，不执行
+}
+
+// 批量操作每批处理的账单数量，避免 IN 查询超出 SQLite 绑定变量数量上限
+const bulkBillChunkSize = 500
+
+// 批量修改账单请求体
+type BulkUpdateBillRecordsRequest struct {
+	BillBulkScope
+	TradeType     *string `json:"trade_type"`     // 设置分类
+	PaymentMethod *string `json:"payment_method"` // 设置账户
+	IncomeType    *uint8  `json:"income_type"`    // 设置收支类型
+	AddTagIDs     []uint  `json:"add_tag_ids"`    // 添加标签
+	RemoveTagIDs  []uint  `json:"remove_tag_ids"` // 移除标签
+	RemarkAppend  string  `json:"remark_append"`  // 追加到备注末尾的内容
+}
+
+// 批量修改账单接口
+func BulkUpdateBillRecordsHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(BulkUpdateBillRecordsRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	// 构建变更内容
+	updates := map[string]any{}
+	if req.TradeType != nil {
+		tradeType := strings.TrimSpace(*req.TradeType)
+		if tradeType == "" {
+			response.Fail(c, 300013)
+			return
+		}
+		updates["trade_type"] = tradeType
+	}
+	if req.PaymentMethod != nil {
+		paymentMethod := strings.TrimSpace(*req.PaymentMethod)
+		if paymentMethod == "" {
+			response.Fail(c, 300013)
+			return
+		}
+		resolver, err := service.NewPaymentMethodResolver(config.DB, ledgerID)
+		if err != nil {
+			response.Fail(c, 100001)
+			return
+		}
+		updates["payment_method"] = resolver.Resolve(paymentMethod)
+		updates["raw_payment_method"] = paymentMethod
+	}
+	if req.IncomeType != nil {
+		if *req.IncomeType < uint8(model.IncomeTypeIncome) || *req.IncomeType > uint8(model.IncomeTypeNone) {
+			response.Fail(c, 300013)
+			return
+		}
+		updates["income_type"] = *req.IncomeType
+	}
+	if remark := strings.TrimSpace(req.RemarkAppend); remark != "" {
+		updates["remark"] = gorm.Expr("CASE WHEN remark IS NULL OR remark = '' THEN ? ELSE remark || ' ' || ? END", remark, remark)
+	}
+	if len(updates) == 0 && len(req.AddTagIDs) == 0 && len(req.RemoveTagIDs) == 0 {
+		response.Fail(c, 300013)
+		return
+	}
+	// 标签需属于当前账本
+	tagIDs := uniqueUints(append(append([]uint{}, req.AddTagIDs...), req.RemoveTagIDs...))
+	if len(tagIDs) > 0 {
+		var count int64
+		if err := config.DB.Model(&model.Tag{}).Where("ledger_id = ? AND id IN ?", ledgerID, tagIDs).Count(&count).Error; err != nil {
+			response.Fail(c, 100001)
+			return
+		}
+		if int(count) != len(tagIDs) {
+			response.Fail(c, 100025)
+			return
+		}
+	}
+	// 获取账单
+	ids, code := bulkBillIDs(ledgerID, req.BillBulkScope)
+	if code != 0 {
+		response.Fail(c, code)
+		return
+	}
+	if req.DryRun || len(ids) == 0 {
+		response.Ok(c, gin.H{
+			"count": len(ids),
+		})
+		return
+	}
+	// 执行变更（分批处理）
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		for chunk := range slices.Chunk(ids, bulkBillChunkSize) {
+			if len(updates) > 0 {
+				if _, err := service.UpdateBillRecords(tx, userID, model.BillSourceManual, updates, "id IN ?", chunk); err != nil {
+					return err
+				}
+			}
+			tagBefores, err := service.SnapshotBillTags(tx, chunk)
+			if err != nil {
+				return err
+			}
+			if len(req.AddTagIDs) > 0 {
+				links := make([]model.BillRecordTag, 0, len(chunk)*len(req.AddTagIDs))
+				for _, id := range chunk {
+					for _, tagID := range req.AddTagIDs {
+						links = append(links, model.BillRecordTag{BillRecordID: id, TagID: tagID})
+					}
+				}
+				if err := tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(&links, 100).Error; err != nil {
+					return err
+				}
+			}
+			if len(req.RemoveTagIDs) > 0 {
+				if err := tx.Where("bill_record_id IN ? AND tag_id IN ?", chunk, req.RemoveTagIDs).Delete(&model.BillRecordTag{}).Error; err != nil {
+					return err
+				}
+			}
+			if err := service.RecordBillTagChanges(tx, userID, model.BillSourceManual, tagBefores); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		response.Fail(c, 100023)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"count": len(ids),
+	})
+}
+
+// 批量删除账单接口，删除的账单进入回收站
+func BulkDeleteBillRecordsHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(BillBulkScope)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	// 获取账单
+	ids, code := bulkBillIDs(ledgerID, req)
+	if code != 0 {
+		response.Fail(c, code)
+		return
+	}
+	if req.DryRun || len(ids) == 0 {
+		response.Ok(c, gin.H{
+			"count": len(ids),
+		})
+		return
+	}
+	// 删除数据（分批处理）
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		for chunk := range slices.Chunk(ids, bulkBillChunkSize) {
+			befores, err := service.SnapshotBillRecords(tx, chunk)
+			if err != nil {
+				return err
+			}
+			if err := tx.Where("id IN ?", chunk).Delete(&model.BillRecord{}).Error; err != nil {
+				return err
+			}
+			if err := service.RecordBillChanges(tx, userID, model.BillSourceManual, model.BillActionDelete, befores); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		response.Fail(c, 100014)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"count": len(ids),
+	})
+}
+
+// 获取批量操作范围内当前账本的账单ID，失败时返回错误码
+func bulkBillIDs(ledgerID uint, scope BillBulkScope) ([]uint, int) {
+	if len(scope.IDs) > 0 && (scope.Filter != nil || scope.All) {
+		return nil, 300013
+	}
+	if len(scope.IDs) == 0 && !scope.All && (scope.Filter == nil || !hasBillListFilter(*scope.Filter)) {
+		return nil, 100027
+	}
+	ids := []uint{}
+	if len(scope.IDs) > 0 {
+		// 指定ID时分批筛选出属于当前账本的账单
+		for chunk := range slices.Chunk(uniqueUints(scope.IDs), bulkBillChunkSize) {
+			var found []uint
+			if err := config.DB.Model(&model.BillRecord{}).Where("ledger_id = ? AND id IN ?", ledgerID, chunk).Pluck("id", &found).Error; err != nil {
+				return nil, 100001
+			}
+			ids = append(ids, found...)
+		}
+		return ids, 0
+	}
+	db := config.DB.Model(&model.BillRecord{}).Where("ledger_id = ?", ledgerID)
+	if scope.Filter != nil {
+		db = filterBillList(db, *scope.Filter)
+	}
+	if err := db.Pluck("id", &ids).Error; err != nil {
+		return nil, 100001
+	}
+	return ids, 0
+}
+
+// 判断账单列表请求是否包含任一筛选条件（分页与排序参数除外）
+func hasBillListFilter(req GetBillListRequest) bool {
+	return (req.StartFormattedDate != nil && *req.StartFormattedDate != "") ||
+		(req.EndFormattedDate != nil && *req.EndFormattedDate != "") ||
+		(req.Search != nil && *req.Search != "") ||
+		req.IncomeType != nil ||
+		(req.Counterpartys != nil && len(*req.Counterpartys) > 0) ||
+		(req.PaymentMethod != nil && len(*req.PaymentMethod) > 0) ||
+		(req.TradeTypes != nil && len(*req.TradeTypes) > 0) ||
+		(req.Tags != nil && len(*req.Tags) > 0) ||
+		(req.Merchants != nil && len(*req.Merchants) > 0)
+}
@@ -0,0 +1,363 @@
+package controller
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zxc7563598/fintrack-backend/config"
+	"github.com/zxc7563598/fintrack-backend/model"
+	"github.com/zxc7563598/fintrack-backend/service"
+	"github.com/zxc7563598/fintrack-backend/utils/helpers"
+	"github.com/zxc7563598/fintrack-backend/utils/response"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 疑似重复的两笔账单
+type DuplicatePairItem struct {
+	A     uint    `json:"a"`
+	B     uint    `json:"b"`
+	Score float64 `json:"score"`
+}
+
+// 疑似重复的账单
+type DuplicateBillItem struct {
+	ID            uint          `json:"id"`
+	Platform      uint8         `json:"platform"`
+	TradeNo       string        `json:"trade_no"`
+	IncomeType    uint8         `json:"income_type"`
+	TradeType     string        `json:"trade_type"`
+	ProductName   string        `json:"product_name"`
+	Counterparty  string        `json:"counterparty"`
+	PaymentMethod string        `json:"payment_method"`
+	Amount        helpers.Money `json:"amount"`
+	TradeTime     int64         `json:"trade_time"`
+	Remark        string        `json:"remark"`
+}
+
+// 疑似重复的账单分组
+type DuplicateGroupItem struct {
+	Score float64             `json:"score"`
+	Bills []DuplicateBillItem `json:"bills"`
+	Pairs []DuplicatePairItem `json:"pairs"`
+}
+
+// 查找疑似重复账单请求体
+type FindDuplicateBillsRequest struct {
+	StartFormattedDate *string  `json:"start_formatted_date"` // 开始日期
+	EndFormattedDate   *string  `json:"end_formatted_date"`   // 结束日期
+	WindowHours        *int     `json:"window_hours"`         // 交易时间最大间隔（小时），默认48
+	MinScore           *float64 `json:"min_score"`            // 最低得分（0-100），默认60
+}
+
+// 查找疑似重复账单接口
+func FindDuplicateBillsHandler(c *gin.Context) {
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(FindDuplicateBillsRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	opts := service.DuplicateOptions{Window: 48 * time.Hour, MinScore: 60}
+	if req.WindowHours != nil {
+		if *req.WindowHours <= 0 {
+			response.Fail(c, 300013)
+			return
+		}
+		opts.Window = time.Duration(*req.WindowHours) * time.Hour
+	}
+	if req.MinScore != nil {
+		if *req.MinScore < 0 || *req.MinScore > 100 {
+			response.Fail(c, 300013)
+			return
+		}
+		opts.MinScore = *req.MinScore
+	}
+	// 获取数据
+	var bills []model.BillRecord
+	db := config.DB.Where("ledger_id = ?", ledgerID)
+	db = filterBillList(db, GetBillListRequest{StartFormattedDate: req.StartFormattedDate, EndFormattedDate: req.EndFormattedDate})
+	if err := db.Find(&bills).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	groups := service.FindDuplicateBills(bills, opts)
+	// 组装分组内的账单信息
+	byID := map[uint]model.BillRecord{}
+	for _, b := range bills {
+		byID[b.ID] = b
+	}
+	list := make([]DuplicateGroupItem, 0, len(groups))
+	for _, g := range groups {
+		item := DuplicateGroupItem{
+			Score: g.Score,
+			Bills: make([]DuplicateBillItem, 0, len(g.BillIDs)),
+			Pairs: make([]DuplicatePairItem, 0, len(g.Pairs)),
+		}
+		for _, id := range g.BillIDs {
+			b := byID[id]
+			item.Bills = append(item.Bills, DuplicateBillItem{
+				ID:            b.ID,
+				Platform:      b.Platform,
+				TradeNo:       b.TradeNo,
+				IncomeType:    b.IncomeType,
+				TradeType:     b.TradeType,
+				ProductName:   b.ProductName,
+				Counterparty:  b.Counterparty,
+				PaymentMethod: b.PaymentMethod,
+				Amount:        helpers.Money(b.Amount),
+				TradeTime:     b.TradeTime,
+				Remark:        b.Remark,
+			})
+		}
+		for _, p := range g.Pairs {
+			item.Pairs = append(item.Pairs, DuplicatePairItem{A: p.A, B: p.B, Score: p.Score})
+		}
+		list = append(list, item)
+	}
+	// 返回信息
+	response.Ok(c, gin.H{
+		"list": list,
+	})
+}
+
+// 合并重复账单请求体
+type MergeBillRecordsRequest struct {
+	IDs       []uint `json:"ids" binding:"required"` // 需要合并的账单ID，至少两笔
+	PrimaryID uint   `json:"primary_id"`             // 保留的账单ID，为0时自动选择（优先导入的账单）
+}
+
+// 合并重复账单接口：保留一笔账单并补全其字段，其余账单的标签、拆分、报销及其他关联转移到保留的账单后移入回收站
+func MergeBillRecordsHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(MergeBillRecordsRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	ids := uniqueUints(req.IDs)
+	if len(ids) < 2 {
+		response.Fail(c, 300013)
+		return
+	}
+	var bills []model.BillRecord
+	if err := config.DB.Where("ledger_id = ? AND id IN ?", ledgerID, ids).Order("id").Find(&bills).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	if len(bills) != len(ids) {
+		response.Fail(c, 100051)
+		return
+	}
+	// 仅收支类型与金额一致的账单可以合并
+	for _, b := range bills[1:] {
+		if b.IncomeType != bills[0].IncomeType || !helpers.AmountEqual(b.Amount, bills[0].Amount) {
+			response.Fail(c, 100076)
+			return
+		}
+	}
+	// 确定保留的账单
+	primaryIndex := service.PickMergePrimary(bills)
+	if req.PrimaryID > 0 {
+		primaryIndex = -1
+		for i, b := range bills {
+			if b.ID == req.PrimaryID {
+				primaryIndex = i
+			}
+		}
+		if primaryIndex < 0 {
+			response.Fail(c, 300013)
+			return
+		}
+	}
+	primary := bills[primaryIndex]
+	others := make([]model.BillRecord, 0, len(bills)-1)
+	otherIDs := make([]uint, 0, len(bills)-1)
+	for i, b := range bills {
+		if i != primaryIndex {
+			others = append(others, b)
+			otherIDs = append(otherIDs, b.ID)
+		}
+	}
+	merged := service.MergeBillFields(primary, others)
+	// 执行合并
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		befores, err := service.SnapshotBillRecords(tx, ids)
+		if err != nil {
+			return err
+		}
+		tagBefores, err := service.SnapshotBillTags(tx, []uint{primary.ID})
+		if err != nil {
+			return err
+		}
+		splitBefores, err := service.SnapshotBillSplits(tx, []uint{primary.ID})
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&model.BillRecord{}).Where("id = ?", primary.ID).Updates(map[string]any{
+			"product_name":       merged.ProductName,
+			"counterparty":       merged.Counterparty,
+			"merchant_id":        merged.MerchantID,
+			"trade_type":         merged.TradeType,
+			"payment_method":     merged.PaymentMethod,
+			"raw_payment_method": merged.RawPaymentMethod,
+			"merchant_order_no":  merged.MerchantOrderNo,
+			"trade_status":       merged.TradeStatus,
+			"trade_status_type":  model.TradeStatusTypeFromString(merged.TradeStatus),
+			"remark":             merged.Remark,
+		}).Error; err != nil {
+			return err
+		}
+		// 标签取并集
+		var tagIDs []uint
+		if err := tx.Model(&model.BillRecordTag{}).Distinct("tag_id").Where("bill_record_id IN ?", otherIDs).Pluck("tag_id", &tagIDs).Error; err != nil {
+			return err
+		}
+		if len(tagIDs) > 0 {
+			links := make([]model.BillRecordTag, 0, len(tagIDs))
+			for _, tagID := range tagIDs {
+				links = append(links, model.BillRecordTag{BillRecordID: primary.ID, TagID: tagID})
+			}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&links).Error; err != nil {
+				return err
+			}
+		}
+		// 保留的账单没有拆分或报销时，沿用被合并账单的
+		for _, m := range []any{&model.BillSplit{}, &model.ReimbursementItem{}} {
+			var count int64
+			if err := tx.Model(m).Where("bill_record_id = ?", primary.ID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				continue
+			}
+			var sourceIDs []uint
+			if err := tx.Model(m).Where("bill_record_id IN ?", otherIDs).Order("bill_record_id").Limit(1).Pluck("bill_record_id", &sourceIDs).Error; err != nil {
+				return err
+			}
+			if len(sourceIDs) > 0 {
+				if err := tx.Model(m).Where("bill_record_id = ?", sourceIDs[0]).Update("bill_record_id", primary.ID).Error; err != nil {
+					return err
+				}
+			}
+		}
+		// 被合并账单不再属于报销单
+		if err := tx.Where("bill_record_id IN ?", otherIDs).Delete(&model.ReimbursementItem{}).Error; err != nil {
+			return err
+		}
+		if err := service.RelinkBillRecords(tx, otherIDs, primary.ID); err != nil {
+			return err
+		}
+		// 记录合并关系并将被合并账单移入回收站
+		merges := make([]model.BillMerge, 0, len(others))
+		for _, o := range others {
+			snapshot, err := json.Marshal(service.BillFieldValues(o))
+			if err != nil {
+				return err
+			}
+			merges = append(merges, model.BillMerge{
+				LedgerID:           ledgerID,
+				UserID:             userID,
+				BillRecordID:       primary.ID,
+				MergedBillRecordID: o.ID,
+				Snapshot:           string(snapshot),
+			})
+		}
+		if err := tx.Create(&merges).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("id IN ?", otherIDs).Delete(&model.BillRecord{}).Error; err != nil {
+			return err
+		}
+		// 记录变更历史
+		deletedBefores := map[uint]model.BillRecord{}
+		for _, id := range otherIDs {
+			deletedBefores[id] = befores[id]
+		}
+		if err := service.RecordBillChanges(tx, userID, model.BillSourceManual, model.BillActionDelete, deletedBefores); err != nil {
+			return err
+		}
+		if err := service.RecordBillChanges(tx, userID, model.BillSourceManual, model.BillActionUpdate, map[uint]model.BillRecord{primary.ID: befores[primary.ID]}); err != nil {
+			return err
+		}
+		if err := service.RecordBillTagChanges(tx, userID, model.BillSourceManual, tagBefores); err != nil {
+			return err
+		}
+		return service.RecordBillSplitChanges(tx, userID, model.BillSourceManual, splitBefores)
+	})
+	if err != nil {
+		response.Fail(c, 100023)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"id":         primary.ID,
+		"merged_ids": otherIDs,
+	})
+}
+
+// 账单合并来源
+type BillMergeItem struct {
+	ID                 uint           `json:"id"`
+	MergedBillRecordID uint           `json:"merged_bill_record_id"`
+	UserID             uint           `json:"user_id"`
+	Snapshot           map[string]any `json:"snapshot"`
+	CreatedAt          time.Time      `json:"created_at"`
+}
+
+// 获取账单合并来源请求体
+type GetBillMergeListRequest struct {
+	ID uint `json:"id" binding:"required"` // 合并后保留的账单ID
+}
+
+// 获取账单合并来源接口
+func GetBillMergeListHandler(c *gin.Context) {
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(GetBillMergeListRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	// 获取数据
+	var merges []model.BillMerge
+	if err := config.DB.Where("ledger_id = ? AND bill_record_id = ?", ledgerID, req.ID).Order("id").Find(&merges).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	list := make([]BillMergeItem, 0, len(merges))
+	for _, m := range merges {
+		item := BillMergeItem{
+			ID:                 m.ID,
+			MergedBillRecordID: m.MergedBillRecordID,
+			UserID:             m.UserID,
+			CreatedAt:          m.CreatedAt,
+		}
+		if err := json.Unmarshal([]byte(m.Snapshot), &item.Snapshot); err != nil {
+			response.Fail(c, 100001)
+			return
+		}
+		list = append(list, item)
+	}
+	// 返回信息
+	response.Ok(c, gin.H{
+		"list": list,
+	})
+}
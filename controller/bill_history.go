@@ -0,0 +1,160 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zxc7563598/fintrack-backend/config"
+	"github.com/zxc7563598/fintrack-backend/model"
+	"github.com/zxc7563598/fintrack-backend/service"
+	"github.com/zxc7563598/fintrack-backend/utils/response"
+	"gorm.io/gorm"
+)
+
+// 账单变更历史
+type BillHistoryItem struct {
+	ID           uint                    `json:"id"`
+	BillRecordID uint                    `json:"bill_record_id"`
+	Action       uint8                   `json:"action"`
+	Source       uint8                   `json:"source"`
+	UserID       uint                    `json:"user_id"`
+	UserName     string                  `json:"user_name"`
+	RawChanges   string                  `json:"-" gorm:"column:changes"`
+	Changes      []model.BillFieldChange `json:"changes" gorm:"-"`
+	CreatedAt    time.Time               `json:"created_at"`
+}
+
+// 获取账单变更历史请求体
+type GetBillHistoryRequest struct {
+	ID uint `json:"id" binding:"required"` // 账单ID
+}
+
+// 获取账单变更历史接口，已删除的账单也可查询
+func GetBillHistoryHandler(c *gin.Context) {
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(GetBillHistoryRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	var count int64
+	if err := config.DB.Unscoped().Model(&model.BillRecord{}).Where("id = ? AND ledger_id = ?", req.ID, ledgerID).Count(&count).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	if count == 0 {
+		response.Fail(c, 100051)
+		return
+	}
+	// 获取数据
+	list := []BillHistoryItem{}
+	if err := config.DB.Model(&model.BillRecordHistory{}).
+		Select("bill_record_histories.id, bill_record_histories.bill_record_id, bill_record_histories.action, bill_record_histories.source, bill_record_histories.user_id, users.name AS user_name, bill_record_histories.changes, bill_record_histories.created_at").
+		Joins("LEFT JOIN users ON users.id = bill_record_histories.user_id").
+		Where("bill_record_histories.bill_record_id = ?", req.ID).
+		Order("bill_record_histories.id DESC").
+		Scan(&list).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	for i := range list {
+		if err := json.Unmarshal([]byte(list[i].RawChanges), &list[i].Changes); err != nil {
+			response.Fail(c, 100001)
+			return
+		}
+	}
+	// 返回信息
+	response.Ok(c, gin.H{
+		"list": list,
+	})
+}
+
+// 回滚账单请求体
+type RevertBillRecordRequest struct {
+	ID        uint `json:"id" binding:"required"`         // 账单ID
+	HistoryID uint `json:"history_id" binding:"required"` // 回滚到该条历史记录后的版本
+}
+
+// 回滚账单到历史版本接口，已删除的账单回滚后恢复
+func RevertBillRecordHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(RevertBillRecordRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	var bill model.BillRecord
+	if err := config.DB.Unscoped().Where("id = ? AND ledger_id = ?", req.ID, ledgerID).First(&bill).Error; err != nil {
+		response.Fail(c, 100051)
+		return
+	}
+	var history model.BillRecordHistory
+	if err := config.DB.Where("id = ? AND bill_record_id = ?", req.HistoryID, req.ID).First(&history).Error; err != nil {
+		response.Fail(c, 100073)
+		return
+	}
+	if history.Action == uint8(model.BillActionDelete) {
+		response.Fail(c, 100074)
+		return
+	}
+	updates, err := service.BillRevertValues(history.Snapshot)
+	if err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	// 已拆分的账单，拆分金额合计需与回滚后的金额一致
+	if amount, ok := updates["amount"].(float64); ok {
+		var splits []model.BillSplit
+		if err := config.DB.Where("bill_record_id = ?", req.ID).Find(&splits).Error; err != nil {
+			response.Fail(c, 100001)
+			return
+		}
+		if errors.Is(service.CheckBillSplits(splits, amount), service.ErrBillSplitsMismatch) {
+			response.Fail(c, 100028)
+			return
+		}
+	}
+	// 交易对方有变化时按当前账本的商户别名重新匹配商户
+	if counterparty, ok := updates["counterparty"].(string); ok && counterparty != bill.Counterparty {
+		normalizer, err := service.NewMerchantNormalizer(config.DB, ledgerID)
+		if err != nil {
+			response.Fail(c, 100001)
+			return
+		}
+		updates["merchant_id"] = normalizer.Match(counterparty)
+	}
+	// 执行回滚
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		befores := map[uint]model.BillRecord{bill.ID: bill}
+		if bill.DeletedAt.Valid {
+			updates["deleted_at"] = nil
+		}
+		if err := tx.Unscoped().Model(&model.BillRecord{}).Where("id = ?", bill.ID).Updates(updates).Error; err != nil {
+			return err
+		}
+		return service.RecordBillChanges(tx, userID, model.BillSourceManual, model.BillActionRevert, befores)
+	})
+	if err != nil {
+		response.Fail(c, 100023)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{})
+}
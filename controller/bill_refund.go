@@ -0,0 +1,264 @@
+package controller
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zxc7563598/fintrack-backend/config"
+	"github.com/zxc7563598/fintrack-backend/model"
+	"github.com/zxc7563598/fintrack-backend/service"
+	"github.com/zxc7563598/fintrack-backend/utils/helpers"
+	"github.com/zxc7563598/fintrack-backend/utils/response"
+	"gorm.io/gorm"
+)
+
+// 退款报表请求体
+type GetRefundReportRequest struct {
+	StartFormattedDate *string `json:"start_formatted_date"` // 开始日期（按退款时间）
+	EndFormattedDate   *string `json:"end_formatted_date"`   // 结束日期（按退款时间）
+}
+
+// 退款报表中的账单信息
+type RefundBillItem struct {
+	ID           uint          `json:"id"`
+	TradeType    string        `json:"trade_type"`
+	ProductName  string        `json:"product_name"`
+	Counterparty string        `json:"counterparty"`
+	Amount       helpers.Money `json:"amount"`
+	TradeTime    int64         `json:"trade_time"`
+}
+
+// 已关联的退款
+type RefundItem struct {
+	ID        uint           `json:"id"`
+	Amount    helpers.Money  `json:"amount"`
+	MatchType uint8          `json:"match_type"`
+	Refund    RefundBillItem `json:"refund"`
+	Original  RefundBillItem `json:"original"`
+}
+
+// 按原消费分类汇总的退款
+type RefundCategoryItem struct {
+	TradeType string        `json:"trade_type"`
+	Count     int           `json:"count"`
+	Amount    helpers.Money `json:"amount"`
+}
+
+// 退款报表接口：已关联的退款及其原消费、按原消费分类的退款汇总、尚未关联的退款
+func GetRefundReportHandler(c *gin.Context) {
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(GetRefundReportRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	filter := GetBillListRequest{StartFormattedDate: req.StartFormattedDate, EndFormattedDate: req.EndFormattedDate}
+	// 获取已关联的退款
+	var refundBills []model.BillRecord
+	linked := config.DB.Model(&model.BillRefund{}).Select("refund_bill_record_id").Where("ledger_id = ?", ledgerID)
+	if err := filterBillList(config.DB.Where("ledger_id = ? AND id IN (?)", ledgerID, linked), filter).Find(&refundBills).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	refundByID := map[uint]model.BillRecord{}
+	refundIDs := make([]uint, 0, len(refundBills))
+	for _, b := range refundBills {
+		refundByID[b.ID] = b
+		refundIDs = append(refundIDs, b.ID)
+	}
+	var links []model.BillRefund
+	if len(refundIDs) > 0 {
+		if err := config.DB.Where("refund_bill_record_id IN ?", refundIDs).Find(&links).Error; err != nil {
+			response.Fail(c, 100001)
+			return
+		}
+	}
+	originalIDs := make([]uint, 0, len(links))
+	for _, l := range links {
+		originalIDs = append(originalIDs, l.OriginalBillRecordID)
+	}
+	originalByID := map[uint]model.BillRecord{}
+	if len(originalIDs) > 0 {
+		var originals []model.BillRecord
+		if err := config.DB.Unscoped().Where("id IN ?", originalIDs).Find(&originals).Error; err != nil {
+			response.Fail(c, 100001)
+			return
+		}
+		for _, b := range originals {
+			originalByID[b.ID] = b
+		}
+	}
+	// 组装明细与分类汇总
+	list := make([]RefundItem, 0, len(links))
+	categories := map[string]*RefundCategoryItem{}
+	var total float64
+	for _, l := range links {
+		r, o := refundByID[l.RefundBillRecordID], originalByID[l.OriginalBillRecordID]
+		list = append(list, RefundItem{
+			ID:        l.ID,
+			Amount:    helpers.Money(l.Amount),
+			MatchType: l.MatchType,
+			Refund:    refundBillItem(r),
+			Original:  refundBillItem(o),
+		})
+		total += l.Amount
+		category, ok := categories[o.TradeType]
+		if !ok {
+			category = &RefundCategoryItem{TradeType: o.TradeType}
+			categories[o.TradeType] = category
+		}
+		category.Count++
+		category.Amount += helpers.Money(l.Amount)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Refund.TradeTime > list[j].Refund.TradeTime
+	})
+	byCategory := make([]RefundCategoryItem, 0, len(categories))
+	for _, category := range categories {
+		byCategory = append(byCategory, *category)
+	}
+	sort.Slice(byCategory, func(i, j int) bool {
+		return byCategory[i].Amount > byCategory[j].Amount
+	})
+	// 获取尚未关联的退款
+	var candidates []model.BillRecord
+	if err := filterBillList(config.DB.Where("ledger_id = ? AND income_type <> ? AND id NOT IN (?)", ledgerID, model.IncomeTypeExpense, linked), filter).
+		Where("trade_status LIKE ? OR trade_type LIKE ? OR product_name LIKE ?", "%退款%", "%退款%", "%退款%").
+		Order("trade_time DESC").
+		Find(&candidates).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	unmatched := []RefundBillItem{}
+	for _, b := range candidates {
+		if service.IsRefundBill(b) {
+			unmatched = append(unmatched, refundBillItem(b))
+		}
+	}
+	// 返回信息
+	response.Ok(c, gin.H{
+		"total":       helpers.Money(total),
+		"list":        list,
+		"by_category": byCategory,
+		"unmatched":   unmatched,
+	})
+}
+
+func refundBillItem(b model.BillRecord) RefundBillItem {
+	return RefundBillItem{
+		ID:           b.ID,
+		TradeType:    b.TradeType,
+		ProductName:  b.ProductName,
+		Counterparty: b.Counterparty,
+		Amount:       helpers.Money(b.Amount),
+		TradeTime:    b.TradeTime,
+	}
+}
+
+// 自动关联退款接口
+func MatchRefundsHandler(c *gin.Context) {
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 执行匹配
+	count, err := service.MatchRefunds(config.DB, ledgerID)
+	if err != nil {
+		response.Fail(c, 100023)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"count": count,
+	})
+}
+
+// 手动关联退款请求体
+type LinkRefundRequest struct {
+	RefundID   uint `json:"refund_id" binding:"required"`   // 退款账单ID
+	OriginalID uint `json:"original_id" binding:"required"` // 原始消费账单ID
+}
+
+// 手动关联退款接口，退款已关联时改为关联到新的原始消费
+func LinkRefundHandler(c *gin.Context) {
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(LinkRefundRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	var refund, original model.BillRecord
+	if err := config.DB.Where("ledger_id = ? AND id = ?", ledgerID, req.RefundID).First(&refund).Error; err != nil {
+		response.Fail(c, 100051)
+		return
+	}
+	if err := config.DB.Where("ledger_id = ? AND id = ?", ledgerID, req.OriginalID).First(&original).Error; err != nil {
+		response.Fail(c, 100051)
+		return
+	}
+	// 退款需为非支出账单，原始消费需为支出账单
+	if refund.IncomeType == uint8(model.IncomeTypeExpense) || original.IncomeType != uint8(model.IncomeTypeExpense) {
+		response.Fail(c, 100077)
+		return
+	}
+	// 累计退款不能超过原消费金额
+	var refunded float64
+	if err := config.DB.Model(&model.BillRefund{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("original_bill_record_id = ? AND refund_bill_record_id <> ?", original.ID, refund.ID).
+		Scan(&refunded).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	if refunded+refund.Amount > original.Amount && !helpers.AmountEqual(refunded+refund.Amount, original.Amount) {
+		response.Fail(c, 100078)
+		return
+	}
+	// 保存关联
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var link model.BillRefund
+		err := tx.Where("refund_bill_record_id = ?", refund.ID).First(&link).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		link.LedgerID = ledgerID
+		link.RefundBillRecordID = refund.ID
+		link.OriginalBillRecordID = original.ID
+		link.Amount = refund.Amount
+		link.MatchType = uint8(model.RefundMatchManual)
+		return tx.Save(&link).Error
+	})
+	if err != nil {
+		response.Fail(c, 100023)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{})
+}
+
+// 取消关联退款请求体
+type UnlinkRefundRequest struct {
+	RefundID uint `json:"refund_id" binding:"required"` // 退款账单ID
+}
+
+// 取消关联退款接口
+func UnlinkRefundHandler(c *gin.Context) {
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(UnlinkRefundRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	// 删除数据
+	if err := config.DB.Where("ledger_id = ? AND refund_bill_record_id = ?", ledgerID, req.RefundID).Delete(&model.BillRefund{}).Error; err != nil {
+		response.Fail(c, 100014)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{})
+}
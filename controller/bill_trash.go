@@ -0,0 +1,191 @@
+package controller
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zxc7563598/fintrack-backend/config"
+	"github.com/zxc7563598/fintrack-backend/model"
+	"github.com/zxc7563598/fintrack-backend/service"
+	"github.com/zxc7563598/fintrack-backend/utils/helpers"
+	"github.com/zxc7563598/fintrack-backend/utils/response"
+	"gorm.io/gorm"
+)
+
+// 回收站账单
+type BillTrashItem struct {
+	ID           uint          `json:"id"`
+	IncomeType   uint8         `json:"income_type"`
+	TradeType    string        `json:"trade_type"`
+	ProductName  string        `json:"product_name"`
+	Counterparty string        `json:"counterparty"`
+	Amount       helpers.Money `json:"amount"`
+	TradeTime    int64         `json:"trade_time"`
+	DeletedAt    time.Time     `json:"deleted_at"`
+	PurgeAt      *time.Time    `json:"purge_at" gorm:"-"` // 自动彻底删除的时间，不自动清理时为空
+}
+
+// 获取回收站列表请求体
+type GetBillTrashListRequest struct {
+	Page         *int `json:"page"`           // 页码
+	ItemsPerPage *int `json:"items_per_page"` // 每页条数
+}
+
+// 获取回收站列表接口
+func GetBillTrashListHandler(c *gin.Context) {
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(GetBillTrashListRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	db := config.DB.Unscoped().Model(&model.BillRecord{}).Where("ledger_id = ? AND deleted_at IS NOT NULL", ledgerID)
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	// 分页
+	page := 1
+	if req.Page != nil && *req.Page > 0 {
+		page = *req.Page
+	}
+	itemsPerPage := 20
+	if req.ItemsPerPage != nil && *req.ItemsPerPage > 0 {
+		itemsPerPage = *req.ItemsPerPage
+	}
+	// 获取数据
+	list := []BillTrashItem{}
+	if err := db.Select("id, income_type, trade_type, product_name, counterparty, amount, trade_time, deleted_at").
+		Order("deleted_at DESC").
+		Offset((page - 1) * itemsPerPage).
+		Limit(itemsPerPage).
+		Scan(&list).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	retention := config.Cfg.Trash.Retention()
+	if retention > 0 {
+		for i := range list {
+			purgeAt := list[i].DeletedAt.Add(retention)
+			list[i].PurgeAt = &purgeAt
+		}
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"total": total,
+		"data":  list,
+	})
+}
+
+// 回收站操作请求体
+type BillTrashRequest struct {
+	IDs []uint `json:"ids"` // 账单ID列表
+	All bool   `json:"all"` // 是否操作回收站内全部账单
+}
+
+// 从回收站恢复账单接口
+func RestoreBillRecordsHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(BillTrashRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	ids, code := trashedBillIDs(ledgerID, req)
+	if code != 0 {
+		response.Fail(c, code)
+		return
+	}
+	// 恢复数据
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		befores, err := service.SnapshotBillRecords(tx, ids)
+		if err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&model.BillRecord{}).Where("id IN ?", ids).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		return service.RecordBillChanges(tx, userID, model.BillSourceManual, model.BillActionRestore, befores)
+	})
+	if err != nil {
+		response.Fail(c, 100023)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"count": len(ids),
+	})
+}
+
+// 彻底删除回收站账单接口，交易单号保留用于导入去重
+func PurgeBillRecordsHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(BillTrashRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	ids, code := trashedBillIDs(ledgerID, req)
+	if code != 0 {
+		response.Fail(c, code)
+		return
+	}
+	// 删除数据
+	count, err := service.PurgeBillRecords(config.DB, userID, model.BillSourceManual, ids)
+	if err != nil {
+		response.Fail(c, 100014)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"count": count,
+	})
+}
+
+// 获取账本回收站中需要操作的账单ID，失败时返回错误码
+func trashedBillIDs(ledgerID uint, req BillTrashRequest) ([]uint, int) {
+	if !req.All && len(req.IDs) == 0 {
+		return nil, 300013
+	}
+	db := config.DB.Unscoped().Model(&model.BillRecord{}).Where("ledger_id = ? AND deleted_at IS NOT NULL", ledgerID)
+	if !req.All {
+		db = db.Where("id IN ?", req.IDs)
+	}
+	var ids []uint
+	if err := db.Pluck("id", &ids).Error; err != nil {
+		return nil, 100001
+	}
+	if len(ids) == 0 {
+		return nil, 100075
+	}
+	return ids, 0
+}
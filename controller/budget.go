@@ -0,0 +1,349 @@
+package controller
+
+import (
+	"math"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zxc7563598/fintrack-backend/config"
+	"github.com/zxc7563598/fintrack-backend/model"
+	"github.com/zxc7563598/fintrack-backend/service"
+	"github.com/zxc7563598/fintrack-backend/utils/helpers"
+	"github.com/zxc7563598/fintrack-backend/utils/response"
+)
+
+// 获取预算列表请求体
+type GetBudgetListRequest struct {
+	Month *string `json:"month"` // 月份（2006-01），为空时返回全部设置
+}
+
+// 获取预算列表接口
+func GetBudgetListHandler(c *gin.Context) {
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(GetBudgetListRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	// 获取数据
+	var list []model.Budget
+	db := config.DB.Where("ledger_id = ?", ledgerID)
+	if req.Month != nil && *req.Month != "" {
+		db = db.Where("month = ?", *req.Month)
+	}
+	if err := db.Order("month DESC, trade_type").Find(&list).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	// 返回信息
+	response.Ok(c, gin.H{
+		"list": list,
+	})
+}
+
+// 存储预算请求体
+type StoreBudgetRequest struct {
+	ID        uint    `json:"id"`                        // ID，修改透传，添加为0
+	TradeType string  `json:"trade_type"`                // 交易类型（分类），为空表示总预算
+	Month     string  `json:"month" binding:"required"`  // 生效月份（2006-01）
+	Amount    float64 `json:"amount" binding:"required"` // 预算金额
+	Rollover  bool    `json:"rollover"`                  // 未用完的预算是否结转至下月
+}
+
+// 存储预算接口，同一分类同一月份只保留一条设置
+func StoreBudgetHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(StoreBudgetRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	if _, err := time.Parse(service.BudgetMonthLayout, req.Month); err != nil {
+		response.Fail(c, 100012)
+		return
+	}
+	if req.Amount <= 0 {
+		response.Fail(c, 300013)
+		return
+	}
+	// 查找已有设置：指定ID时按ID查找，否则按分类与月份查找
+	var exist model.Budget
+	if req.ID > 0 {
+		if err := config.DB.Where("id = ? AND ledger_id = ?", req.ID, ledgerID).First(&exist).Error; err != nil {
+			response.Fail(c, 100039)
+			return
+		}
+	} else if err := config.DB.Where("ledger_id = ? AND trade_type = ? AND month = ?", ledgerID, req.TradeType, req.Month).Limit(1).Find(&exist).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	budget := model.Budget{
+		ID:        exist.ID,
+		UserID:    userID,
+		LedgerID:  ledgerID,
+		TradeType: req.TradeType,
+		Month:     req.Month,
+		Amount:    req.Amount,
+		Rollover:  req.Rollover,
+		CreatedAt: exist.CreatedAt,
+	}
+	if err := config.DB.Save(&budget).Error; err != nil {
+		response.Fail(c, 100013)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"id": budget.ID,
+	})
+}
+
+// 删除预算请求体
+type DeleteBudgetRequest struct {
+	ID uint `json:"id" binding:"required"` // ID
+}
+
+// 删除预算接口
+func DeleteBudgetHandler(c *gin.Context) {
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(DeleteBudgetRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	// 删除数据
+	if err := config.DB.Where("id = ? and ledger_id = ?", req.ID, ledgerID).Delete(&model.Budget{}).Error; err != nil {
+		response.Fail(c, 100014)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{})
+}
+
+type BudgetProgress struct {
+	TradeType string        `json:"trade_type"`
+	Month     string        `json:"month"`
+	Budget    helpers.Money `json:"budget"`
+	Rollover  helpers.Money `json:"rollover"`
+	Available helpers.Money `json:"available"`
+	Actual    helpers.Money `json:"actual"`
+	Projected helpers.Money `json:"projected"`
+	Remaining helpers.Money `json:"remaining"`
+	Percent   float64       `json:"percent"`
+	Exceeded  bool          `json:"exceeded"`
+}
+
+// 预算进度请求体
+type BudgetProgressRequest struct {
+	Month *string `json:"month"` // 月份（2006-01），为空时为本月
+}
+
+// 预算进度接口：预算、实际支出及按日均支出预测的月末支出
+func BudgetProgressHandler(c *gin.Context) {
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(BudgetProgressRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	now := time.Now()
+	monthStart := helpers.StartOfMonth(now)
+	if req.Month != nil && *req.Month != "" {
+		t, err := time.ParseInLocation(service.BudgetMonthLayout, *req.Month, time.Local)
+		if err != nil {
+			response.Fail(c, 100012)
+			return
+		}
+		monthStart = t
+	}
+	month := monthStart.Format(service.BudgetMonthLayout)
+	timelines, code := budgetTimelines(ledgerID, month)
+	if code != 0 {
+		response.Fail(c, code)
+		return
+	}
+	// 取目标月份的执行情况
+	list := []BudgetProgress{}
+	for _, tl := range timelines {
+		p := tl.periods[len(tl.periods)-1]
+		projected := service.ProjectMonthEnd(p.Actual, monthStart, now)
+		item := BudgetProgress{
+			TradeType: tl.tradeType,
+			Month:     p.Month,
+			Budget:    helpers.Money(p.Base),
+			Rollover:  helpers.Money(p.Rollover),
+			Available: helpers.Money(p.Available),
+			Actual:    helpers.Money(p.Actual),
+			Projected: helpers.Money(projected),
+			Remaining: helpers.Money(p.Available - p.Actual),
+			Exceeded:  p.Actual > p.Available,
+		}
+		if p.Available > 0 {
+			item.Percent = math.Round(p.Actual/p.Available*10000) / 100
+		}
+		list = append(list, item)
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"month": month,
+		"list":  list,
+	})
+}
+
+type BudgetHistory struct {
+	TradeType     string          `json:"trade_type"`
+	Months        []string        `json:"months"`
+	Available     []helpers.Money `json:"available"`
+	Actual        []helpers.Money `json:"actual"`
+	Adhered       []bool          `json:"adhered"`
+	AdheredMonths int             `json:"adhered_months"`
+	AdherenceRate float64         `json:"adherence_rate"`
+}
+
+// 预算历史请求体
+type BudgetHistoryRequest struct {
+	Months int `json:"months"` // 统计最近多少个已结束的月份，默认6
+}
+
+// 预算历史接口：以往各月预算执行情况及达成率
+func BudgetHistoryHandler(c *gin.Context) {
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(BudgetHistoryRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	count := 6
+	if req.Months > 0 && req.Months <= 60 {
+		count = req.Months
+	}
+	lastMonth := helpers.StartOfMonth(time.Now()).AddDate(0, -1, 0).Format(service.BudgetMonthLayout)
+	timelines, code := budgetTimelines(ledgerID, lastMonth)
+	if code != 0 {
+		response.Fail(c, code)
+		return
+	}
+	list := []BudgetHistory{}
+	for _, tl := range timelines {
+		periods := tl.periods
+		if len(periods) > count {
+			periods = periods[len(periods)-count:]
+		}
+		item := BudgetHistory{
+			TradeType: tl.tradeType,
+			Months:    []string{},
+			Available: []helpers.Money{},
+			Actual:    []helpers.Money{},
+			Adhered:   []bool{},
+		}
+		for _, p := range periods {
+			adhered := p.Actual <= p.Available
+			item.Months = append(item.Months, p.Month)
+			item.Available = append(item.Available, helpers.Money(p.Available))
+			item.Actual = append(item.Actual, helpers.Money(p.Actual))
+			item.Adhered = append(item.Adhered, adhered)
+			if adhered {
+				item.AdheredMonths++
+			}
+		}
+		if len(periods) > 0 {
+			item.AdherenceRate = math.Round(float64(item.AdheredMonths)/float64(len(periods))*10000) / 100
+		}
+		list = append(list, item)
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"list": list,
+	})
+}
+
+// 单个分类的预算逐月执行情况
+type budgetTimeline struct {
+	tradeType string
+	periods   []service.BudgetPeriod
+}
+
+// 计算账本所有预算截至 until 月份的逐月执行情况，失败时返回错误码
+func budgetTimelines(ledgerID uint, until string) ([]budgetTimeline, int) {
+	var budgets []model.Budget
+	if err := config.DB.Where("ledger_id = ? AND month <= ?", ledgerID, until).Order("trade_type, month").Find(&budgets).Error; err != nil {
+		return nil, 100001
+	}
+	if len(budgets) == 0 {
+		return []budgetTimeline{}, 0
+	}
+	// 按分类分组，并确定最早的预算月份
+	groups := map[string][]model.Budget{}
+	tradeTypes := []string{}
+	earliest := budgets[0].Month
+	for _, b := range budgets {
+		if _, ok := groups[b.TradeType]; !ok {
+			tradeTypes = append(tradeTypes, b.TradeType)
+		}
+		groups[b.TradeType] = append(groups[b.TradeType], b)
+		if b.Month < earliest {
+			earliest = b.Month
+		}
+	}
+	// 汇总期间内每月各分类的支出（包含拆分明细）
+	start, _ := time.ParseInLocation(service.BudgetMonthLayout, earliest, time.Local)
+	end, _ := time.ParseInLocation(service.BudgetMonthLayout, until, time.Local)
+	type monthExpense struct {
+		TradeType string
+		Month     string
+		Expense   float64
+	}
+	var results []monthExpense
+	err := billLines(false).
+		Select(`
+		trade_type,
+		strftime('%Y-%m', trade_time, 'unixepoch', 'localtime') AS month,
+		COALESCE(SUM(amount),0) AS expense
+	`).
+		Where("ledger_id = ? AND income_type = ?", ledgerID, model.IncomeTypeExpense).
+		Where("trade_time >= ? AND trade_time < ?", start.Unix(), end.AddDate(0, 1, 0).Unix()).
+		Group("trade_type, month").
+		Scan(&results).Error
+	if err != nil {
+		return nil, 100001
+	}
+	// 总预算（分类为空）统计全部支出
+	actual := map[string]map[string]float64{"": {}}
+	for _, r := range results {
+		if actual[r.TradeType] == nil {
+			actual[r.TradeType] = map[string]float64{}
+		}
+		actual[r.TradeType][r.Month] += r.Expense
+		if r.TradeType != "" {
+			actual[""][r.Month] += r.Expense
+		}
+	}
+	timelines := make([]budgetTimeline, 0, len(tradeTypes))
+	for _, tradeType := range tradeTypes {
+		timelines = append(timelines, budgetTimeline{
+			tradeType: tradeType,
+			periods:   service.BudgetTimeline(groups[tradeType], actual[tradeType], until),
+		})
+	}
+	return timelines, 0
+}
@@ -0,0 +1,352 @@
+package controller
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zxc7563598/fintrack-backend/config"
+	"github.com/zxc7563598/fintrack-backend/model"
+	"github.com/zxc7563598/fintrack-backend/service"
+	"github.com/zxc7563598/fintrack-backend/utils/helpers"
+	"github.com/zxc7563598/fintrack-backend/utils/response"
+)
+
+// 获取信用账户列表接口
+func GetCreditAccountListHandler(c *gin.Context) {
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取数据
+	var list []model.CreditAccount
+	if err := config.DB.Where("ledger_id = ?", ledgerID).Order("id").Find(&list).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	// 返回信息
+	response.Ok(c, gin.H{
+		"list": list,
+	})
+}
+
+// 存储信用账户请求体
+type StoreCreditAccountRequest struct {
+	ID               uint    `json:"id"`                                // ID，修改透传，添加为0
+	PaymentMethod    string  `json:"payment_method" binding:"required"` // 对应的交易方式（账户）
+	Name             string  `json:"name" binding:"required"`           // 账户名称
+	CreditLimit      float64 `json:"credit_limit"`                      // 信用额度
+	BillingDay       int     `json:"billing_day" binding:"required"`    // 账单日
+	DueDay           int     `json:"due_day" binding:"required"`        // 还款日
+	RepaymentKeyword string  `json:"repayment_keyword"`                 // 还款记录识别关键字，为空时使用账户名称
+}
+
+// 存储信用账户接口
+func StoreCreditAccountHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(StoreCreditAccountRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" || req.PaymentMethod == "" || req.CreditLimit < 0 {
+		response.Fail(c, 300013)
+		return
+	}
+	if req.BillingDay < 1 || req.BillingDay > 31 || req.DueDay < 1 || req.DueDay > 31 {
+		response.Fail(c, 100047)
+		return
+	}
+	account := model.CreditAccount{
+		UserID:           userID,
+		LedgerID:         ledgerID,
+		PaymentMethod:    req.PaymentMethod,
+		Name:             name,
+		CreditLimit:      req.CreditLimit,
+		BillingDay:       req.BillingDay,
+		DueDay:           req.DueDay,
+		RepaymentKeyword: strings.TrimSpace(req.RepaymentKeyword),
+	}
+	if req.ID > 0 {
+		// 修改
+		var exist model.CreditAccount
+		if err := config.DB.Where("id = ? AND ledger_id = ?", req.ID, ledgerID).First(&exist).Error; err != nil {
+			response.Fail(c, 100046)
+			return
+		}
+		account.ID = exist.ID
+		account.CreatedAt = exist.CreatedAt
+		if err := config.DB.Save(&account).Error; err != nil {
+			response.Fail(c, 100013)
+			return
+		}
+	} else {
+		// 新增
+		if err := config.DB.Create(&account).Error; err != nil {
+			response.Fail(c, 100013)
+			return
+		}
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"id": account.ID,
+	})
+}
+
+// 删除信用账户请求体
+type DeleteCreditAccountRequest struct {
+	ID uint `json:"id" binding:"required"` // ID
+}
+
+// 删除信用账户接口
+func DeleteCreditAccountHandler(c *gin.Context) {
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(DeleteCreditAccountRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	// 删除数据
+	if err := config.DB.Where("id = ? and ledger_id = ?", req.ID, ledgerID).Delete(&model.CreditAccount{}).Error; err != nil {
+		response.Fail(c, 100014)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{})
+}
+
+type CreditStatementItem struct {
+	StartAt     int64         `json:"start_at"`
+	StatementAt int64         `json:"statement_at"`
+	DueAt       int64         `json:"due_at"`
+	Charges     helpers.Money `json:"charges"`
+	Refunds     helpers.Money `json:"refunds"`
+	Amount      helpers.Money `json:"amount"`
+	Repaid      helpers.Money `json:"repaid"`
+	Outstanding helpers.Money `json:"outstanding"`
+	Overdue     bool          `json:"overdue"`
+}
+
+// 信用账户账单请求体
+type CreditStatementRequest struct {
+	ID    uint `json:"id" binding:"required"` // 信用账户ID
+	Count int  `json:"count"`                 // 返回最近多少期已出账单，默认12
+}
+
+// 信用账户账单接口：按账单周期汇总消费、还款及未还金额
+func CreditStatementHandler(c *gin.Context) {
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(CreditStatementRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	count := 12
+	if req.Count > 0 && req.Count <= 120 {
+		count = req.Count
+	}
+	var account model.CreditAccount
+	if err := config.DB.Where("id = ? AND ledger_id = ?", req.ID, ledgerID).First(&account).Error; err != nil {
+		response.Fail(c, 100046)
+		return
+	}
+	now := time.Now()
+	statements, current, credit, err := creditStatements(ledgerID, account, now)
+	if err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	// 最近的账单在前
+	var outstanding float64
+	list := []CreditStatementItem{}
+	for i := len(statements) - 1; i >= 0; i-- {
+		s := statements[i]
+		outstanding += s.Outstanding
+		if len(list) < count {
+			list = append(list, creditStatementItem(s, now))
+		}
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"account":     account,
+		"list":        list,
+		"unbilled":    creditStatementItem(current, now),
+		"outstanding": helpers.Money(outstanding),
+		"overpaid":    helpers.Money(credit),
+		"available":   helpers.Money(account.CreditLimit - outstanding - current.Amount + credit),
+	})
+}
+
+type CreditDueItem struct {
+	AccountID   uint          `json:"account_id"`
+	Name        string        `json:"name"`
+	StatementAt int64         `json:"statement_at"`
+	DueAt       int64         `json:"due_at"`
+	Amount      helpers.Money `json:"amount"`
+	Outstanding helpers.Money `json:"outstanding"`
+	Overdue     bool          `json:"overdue"`
+}
+
+// 待还款接口：所有信用账户已出账单中尚未还清的金额，按还款日排序
+func CreditUpcomingDueHandler(c *gin.Context) {
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	var accounts []model.CreditAccount
+	if err := config.DB.Where("ledger_id = ?", ledgerID).Order("id").Find(&accounts).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	now := time.Now()
+	var total float64
+	list := []CreditDueItem{}
+	for _, account := range accounts {
+		statements, _, _, err := creditStatements(ledgerID, account, now)
+		if err != nil {
+			response.Fail(c, 100001)
+			return
+		}
+		for _, s := range statements {
+			if s.Outstanding <= 0.005 {
+				continue
+			}
+			total += s.Outstanding
+			list = append(list, CreditDueItem{
+				AccountID:   account.ID,
+				Name:        account.Name,
+				StatementAt: s.StatementAt,
+				DueAt:       s.DueAt,
+				Amount:      helpers.Money(s.Amount),
+				Outstanding: helpers.Money(s.Outstanding),
+				Overdue:     s.DueAt < now.Unix(),
+			})
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].DueAt < list[j].DueAt
+	})
+	// 返回成功
+	response.Ok(c, gin.H{
+		"total": helpers.Money(total),
+		"list":  list,
+	})
+}
+
+type InstallmentItem struct {
+	Counterparty    string        `json:"counterparty"`
+	ProductName     string        `json:"product_name"`
+	PaymentMethod   string        `json:"payment_method"`
+	PeriodAmount    helpers.Money `json:"period_amount"`
+	CurrentPeriod   int           `json:"current_period"`
+	TotalPeriods    int           `json:"total_periods"`
+	RemainingPeriod int           `json:"remaining_period"`
+	RemainingAmount helpers.Money `json:"remaining_amount"`
+	LastTradeAt     int64         `json:"last_trade_at"`
+	NextTradeAt     int64         `json:"next_trade_at"`
+}
+
+// 分期识别请求体
+type CreditInstallmentRequest struct {
+	ID *uint `json:"id"` // 信用账户ID，为空时识别全部账单
+}
+
+// 分期识别接口：从商品名称中识别分期付款及剩余期数
+func CreditInstallmentHandler(c *gin.Context) {
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(CreditInstallmentRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	db := config.DB.Where("ledger_id = ? AND (product_name LIKE ? OR product_name LIKE ? OR product_name LIKE ?)", ledgerID, "%期%", "%/%", "%／%")
+	if req.ID != nil {
+		var account model.CreditAccount
+		if err := config.DB.Where("id = ? AND ledger_id = ?", *req.ID, ledgerID).First(&account).Error; err != nil {
+			response.Fail(c, 100046)
+			return
+		}
+		db = db.Where("payment_method = ?", account.PaymentMethod)
+	}
+	var records []model.BillRecord
+	if err := db.Find(&records).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	var remaining float64
+	list := []InstallmentItem{}
+	for _, inst := range service.DetectInstallments(records) {
+		remaining += inst.RemainingAmount
+		list = append(list, InstallmentItem{
+			Counterparty:    inst.Counterparty,
+			ProductName:     inst.ProductName,
+			PaymentMethod:   inst.PaymentMethod,
+			PeriodAmount:    helpers.Money(inst.PeriodAmount),
+			CurrentPeriod:   inst.CurrentPeriod,
+			TotalPeriods:    inst.TotalPeriods,
+			RemainingPeriod: inst.RemainingPeriod,
+			RemainingAmount: helpers.Money(inst.RemainingAmount),
+			LastTradeAt:     inst.LastTradeAt,
+			NextTradeAt:     inst.NextTradeAt,
+		})
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"remaining_amount": helpers.Money(remaining),
+		"list":             list,
+	})
+}
+
+// 计算信用账户的账单，返回已出账单、当前未出账单及多还金额
+func creditStatements(ledgerID uint, account model.CreditAccount, now time.Time) ([]service.CreditStatement, service.CreditStatement, float64, error) {
+	var records []model.BillRecord
+	if err := config.DB.Where("ledger_id = ? AND payment_method = ? AND income_type IN ?", ledgerID, account.PaymentMethod, []int{int(model.IncomeTypeIncome), int(model.IncomeTypeExpense)}).
+		Find(&records).Error; err != nil {
+		return nil, service.CreditStatement{}, 0, err
+	}
+	// 还款记录：不计收支且包含“还款”的账单，再按关键字筛选
+	var candidates []model.BillRecord
+	if err := config.DB.Where("ledger_id = ? AND income_type = ? AND (product_name LIKE ? OR counterparty LIKE ?)", ledgerID, model.IncomeTypeNone, "%还款%", "%还款%").
+		Find(&candidates).Error; err != nil {
+		return nil, service.CreditStatement{}, 0, err
+	}
+	var repayments []model.BillRecord
+	for _, r := range candidates {
+		if service.IsCreditRepayment(account, r) {
+			repayments = append(repayments, r)
+		}
+	}
+	statements, current, credit := service.BuildCreditStatements(account, records, repayments, now)
+	return statements, current, credit, nil
+}
+
+func creditStatementItem(s service.CreditStatement, now time.Time) CreditStatementItem {
+	return CreditStatementItem{
+		StartAt:     s.StartAt,
+		StatementAt: s.StatementAt,
+		DueAt:       s.DueAt,
+		Charges:     helpers.Money(s.Charges),
+		Refunds:     helpers.Money(s.Refunds),
+		Amount:      helpers.Money(s.Amount),
+		Repaid:      helpers.Money(s.Repaid),
+		Outstanding: helpers.Money(s.Outstanding),
+		Overdue:     s.Outstanding > 0.005 && s.DueAt < now.Unix(),
+	}
+}
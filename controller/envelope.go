@@ -0,0 +1,455 @@
+package controller
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zxc7563598/fintrack-backend/config"
+	"github.com/zxc7563598/fintrack-backend/model"
+	"github.com/zxc7563598/fintrack-backend/service"
+	"github.com/zxc7563598/fintrack-backend/utils/helpers"
+	"github.com/zxc7563598/fintrack-backend/utils/response"
+)
+
+// 获取账本的信封预算模式接口
+func GetEnvelopeModeHandler(c *gin.Context) {
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取数据
+	var ledger model.Ledger
+	if err := config.DB.Select("id", "envelope_mode", "envelope_start").First(&ledger, ledgerID).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"enabled":     ledger.EnvelopeMode,
+		"start_month": ledger.EnvelopeStart,
+	})
+}
+
+// 存储信封预算模式请求体
+type StoreEnvelopeModeRequest struct {
+	Enabled    bool   `json:"enabled"`     // 是否开启
+	StartMonth string `json:"start_month"` // 开始月份（2006-01），为空时为本月，此后的收入计入待分配
+}
+
+// 存储账本的信封预算模式接口，对账本所有成员生效
+func StoreEnvelopeModeHandler(c *gin.Context) {
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(StoreEnvelopeModeRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	if req.StartMonth == "" {
+		req.StartMonth = time.Now().Format(service.BudgetMonthLayout)
+	}
+	if _, err := time.Parse(service.BudgetMonthLayout, req.StartMonth); err != nil {
+		response.Fail(c, 100012)
+		return
+	}
+	// 存储数据
+	updates := map[string]any{
+		"envelope_mode":  req.Enabled,
+		"envelope_start": req.StartMonth,
+	}
+	err := config.DB.Model(&model.Ledger{}).
+		Where("id = ?", ledgerID).
+		Updates(updates).Error
+	if err != nil {
+		response.Fail(c, 100013)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{})
+}
+
+// 获取信封列表接口
+func GetEnvelopeListHandler(c *gin.Context) {
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取数据
+	var list []model.Envelope
+	if err := config.DB.Where("ledger_id = ?", ledgerID).Order("sort, id").Find(&list).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	// 返回信息
+	response.Ok(c, gin.H{
+		"list": list,
+	})
+}
+
+// 存储信封请求体
+type StoreEnvelopeRequest struct {
+	ID         uint     `json:"id"`                      // ID，修改透传，添加为0
+	Name       string   `json:"name" binding:"required"` // 信封名称
+	TradeTypes []string `json:"trade_types"`             // 对应的交易类型（分类）
+	Sort       int      `json:"sort"`                    // 排序
+}
+
+// 存储信封接口，同一分类只能归属一个信封
+func StoreEnvelopeHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(StoreEnvelopeRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		response.Fail(c, 300013)
+		return
+	}
+	// 校验分类未被其他信封使用
+	var others []model.Envelope
+	if err := config.DB.Where("ledger_id = ? AND id <> ?", ledgerID, req.ID).Find(&others).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	used := map[string]bool{}
+	for _, e := range others {
+		for _, tradeType := range e.TradeTypes {
+			used[tradeType] = true
+		}
+	}
+	tradeTypes := []string{}
+	seen := map[string]bool{}
+	for _, tradeType := range req.TradeTypes {
+		tradeType = strings.TrimSpace(tradeType)
+		if tradeType == "" || seen[tradeType] {
+			continue
+		}
+		if used[tradeType] {
+			response.Fail(c, 100043)
+			return
+		}
+		seen[tradeType] = true
+		tradeTypes = append(tradeTypes, tradeType)
+	}
+	envelope := model.Envelope{
+		UserID:     userID,
+		LedgerID:   ledgerID,
+		Name:       name,
+		TradeTypes: tradeTypes,
+		Sort:       req.Sort,
+	}
+	if req.ID > 0 {
+		// 修改
+		var exist model.Envelope
+		if err := config.DB.Where("id = ? AND ledger_id = ?", req.ID, ledgerID).First(&exist).Error; err != nil {
+			response.Fail(c, 100041)
+			return
+		}
+		envelope.ID = exist.ID
+		envelope.CreatedAt = exist.CreatedAt
+		if err := config.DB.Save(&envelope).Error; err != nil {
+			response.Fail(c, 100013)
+			return
+		}
+	} else {
+		// 新增
+		if err := config.DB.Create(&envelope).Error; err != nil {
+			response.Fail(c, 100013)
+			return
+		}
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"id": envelope.ID,
+	})
+}
+
+// 删除信封请求体
+type DeleteEnvelopeRequest struct {
+	ID uint `json:"id" binding:"required"` // ID
+}
+
+// 删除信封接口，信封余额退回待分配
+func DeleteEnvelopeHandler(c *gin.Context) {
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(DeleteEnvelopeRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	// 删除数据
+	if err := config.DB.Where("id = ? and ledger_id = ?", req.ID, ledgerID).Delete(&model.Envelope{}).Error; err != nil {
+		response.Fail(c, 100014)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{})
+}
+
+// 信封资金划拨请求体
+type AssignEnvelopeRequest struct {
+	Month          string  `json:"month" binding:"required"`  // 所属月份（2006-01）
+	FromEnvelopeID uint    `json:"from_envelope_id"`          // 转出信封ID，0为待分配
+	ToEnvelopeID   uint    `json:"to_envelope_id"`            // 转入信封ID，0为待分配
+	Amount         float64 `json:"amount" binding:"required"` // 金额
+	Remark         string  `json:"remark"`                    // 备注
+}
+
+// 信封资金划拨接口：从待分配分配至信封、在信封之间移动或退回待分配
+func AssignEnvelopeHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(AssignEnvelopeRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	ledger, code := envelopeLedger(ledgerID)
+	if code != 0 {
+		response.Fail(c, code)
+		return
+	}
+	if _, err := time.Parse(service.BudgetMonthLayout, req.Month); err != nil {
+		response.Fail(c, 100012)
+		return
+	}
+	if req.Amount <= 0 || req.FromEnvelopeID == req.ToEnvelopeID || req.Month < ledger.EnvelopeStart {
+		response.Fail(c, 100042)
+		return
+	}
+	// 校验信封归属
+	ids := []uint{}
+	for _, id := range []uint{req.FromEnvelopeID, req.ToEnvelopeID} {
+		if id > 0 {
+			ids = append(ids, id)
+		}
+	}
+	var count int64
+	if err := config.DB.Model(&model.Envelope{}).Where("ledger_id = ? AND id IN ?", ledgerID, ids).Count(&count).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	if count != int64(len(ids)) {
+		response.Fail(c, 100041)
+		return
+	}
+	// 存储数据
+	transfer := model.EnvelopeTransfer{
+		UserID:         userID,
+		LedgerID:       ledgerID,
+		Month:          req.Month,
+		FromEnvelopeID: req.FromEnvelopeID,
+		ToEnvelopeID:   req.ToEnvelopeID,
+		Amount:         req.Amount,
+		Remark:         req.Remark,
+	}
+	if err := config.DB.Create(&transfer).Error; err != nil {
+		response.Fail(c, 100013)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"id": transfer.ID,
+	})
+}
+
+// 撤销信封资金划拨请求体
+type DeleteEnvelopeTransferRequest struct {
+	ID uint `json:"id" binding:"required"` // ID
+}
+
+// 撤销信封资金划拨接口
+func DeleteEnvelopeTransferHandler(c *gin.Context) {
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(DeleteEnvelopeTransferRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	// 删除数据
+	if err := config.DB.Where("id = ? and ledger_id = ?", req.ID, ledgerID).Delete(&model.EnvelopeTransfer{}).Error; err != nil {
+		response.Fail(c, 100014)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{})
+}
+
+type EnvelopeReport struct {
+	ID         uint          `json:"id"`
+	Name       string        `json:"name"`
+	TradeTypes []string      `json:"trade_types"`
+	Carried    helpers.Money `json:"carried"`
+	Assigned   helpers.Money `json:"assigned"`
+	Spent      helpers.Money `json:"spent"`
+	Balance    helpers.Money `json:"balance"`
+	Overspent  bool          `json:"overspent"`
+}
+
+// 信封预算月报请求体
+type EnvelopeReportRequest struct {
+	Month *string `json:"month"` // 月份（2006-01），为空时为本月
+}
+
+// 信封预算月报接口：待分配余额、各信封余额、超支情况及当月划拨记录
+func EnvelopeReportHandler(c *gin.Context) {
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(EnvelopeReportRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	ledger, code := envelopeLedger(ledgerID)
+	if code != 0 {
+		response.Fail(c, code)
+		return
+	}
+	monthStart := helpers.StartOfMonth(time.Now())
+	if req.Month != nil && *req.Month != "" {
+		t, err := time.ParseInLocation(service.BudgetMonthLayout, *req.Month, time.Local)
+		if err != nil {
+			response.Fail(c, 100012)
+			return
+		}
+		monthStart = t
+	}
+	month := monthStart.Format(service.BudgetMonthLayout)
+	start, err := time.ParseInLocation(service.BudgetMonthLayout, ledger.EnvelopeStart, time.Local)
+	if err != nil {
+		start = monthStart
+	}
+	// 获取信封与划拨记录
+	var envelopes []model.Envelope
+	if err := config.DB.Where("ledger_id = ?", ledgerID).Order("sort, id").Find(&envelopes).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	var transfers []model.EnvelopeTransfer
+	if err := config.DB.Where("ledger_id = ? AND month >= ? AND month <= ?", ledgerID, ledger.EnvelopeStart, month).Order("id").Find(&transfers).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	// 汇总开始月份至当月月末的收支（包含拆分明细），区分当月与之前
+	type flowRow struct {
+		TradeType  string
+		IncomeType uint8
+		Current    int
+		Amount     float64
+	}
+	var rows []flowRow
+	err = billLines(false).
+		Select(`
+		trade_type,
+		income_type,
+		CASE WHEN trade_time < ? THEN 0 ELSE 1 END AS current,
+		COALESCE(SUM(amount),0) AS amount
+	`, monthStart.Unix()).
+		Where("ledger_id = ? AND income_type IN ?", ledgerID, []int{int(model.IncomeTypeIncome), int(model.IncomeTypeExpense)}).
+		Where("trade_time >= ? AND trade_time < ?", start.Unix(), monthStart.AddDate(0, 1, 0).Unix()).
+		Group("trade_type, income_type, current").
+		Scan(&rows).Error
+	if err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	var income service.EnvelopeFlow
+	expenses := map[string]service.EnvelopeFlow{}
+	for _, r := range rows {
+		if r.IncomeType == uint8(model.IncomeTypeIncome) {
+			if r.Current == 1 {
+				income.Current += r.Amount
+			} else {
+				income.Before += r.Amount
+			}
+			continue
+		}
+		flow := expenses[r.TradeType]
+		if r.Current == 1 {
+			flow.Current += r.Amount
+		} else {
+			flow.Before += r.Amount
+		}
+		expenses[r.TradeType] = flow
+	}
+	if month < ledger.EnvelopeStart {
+		// 开始月份之前不计入
+		income = service.EnvelopeFlow{}
+		expenses = map[string]service.EnvelopeFlow{}
+	}
+	summary := service.BuildEnvelopeSummary(envelopes, transfers, month, income, expenses)
+	// 构建返回数据
+	list := make([]EnvelopeReport, 0, len(summary.Envelopes))
+	for _, b := range summary.Envelopes {
+		list = append(list, EnvelopeReport{
+			ID:         b.Envelope.ID,
+			Name:       b.Envelope.Name,
+			TradeTypes: b.Envelope.TradeTypes,
+			Carried:    helpers.Money(b.Carried),
+			Assigned:   helpers.Money(b.Assigned),
+			Spent:      helpers.Money(b.Spent),
+			Balance:    helpers.Money(b.Balance),
+			Overspent:  b.Balance < 0,
+		})
+	}
+	moves := []model.EnvelopeTransfer{}
+	for _, t := range transfers {
+		if t.Month == month {
+			moves = append(moves, t)
+		}
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"month":           month,
+		"to_assign":       helpers.Money(summary.ToAssign),
+		"income":          helpers.Money(summary.Income.Current),
+		"assigned":        helpers.Money(summary.Assigned.Current),
+		"uncovered":       helpers.Money(summary.Uncovered.Current),
+		"uncovered_types": summary.Unassigned,
+		"overspent":       helpers.Money(summary.Overspent),
+		"envelopes":       list,
+		"transfers":       moves,
+	})
+}
+
+// 获取已开启信封预算模式的账本，失败时返回错误码
+func envelopeLedger(ledgerID uint) (model.Ledger, int) {
+	var ledger model.Ledger
+	if err := config.DB.Select("id", "envelope_mode", "envelope_start").First(&ledger, ledgerID).Error; err != nil {
+		return ledger, 100001
+	}
+	if !ledger.EnvelopeMode {
+		return ledger, 100040
+	}
+	return ledger, 0
+}
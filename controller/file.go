@@ -3,6 +3,7 @@ package controller
 import (
 	"fmt"
 	"io"
+	"log"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -14,10 +15,12 @@ import (
 	"github.com/zxc7563598/fintrack-backend/config"
 	"github.com/zxc7563598/fintrack-backend/dto"
 	"github.com/zxc7563598/fintrack-backend/model"
+	"github.com/zxc7563598/fintrack-backend/service"
 	"github.com/zxc7563598/fintrack-backend/utils/helpers"
 	"github.com/zxc7563598/fintrack-backend/utils/response"
 	"golang.org/x/text/encoding/simplifiedchinese"
 	"golang.org/x/text/transform"
+	"gorm.io/gorm/clause"
 )
 
 // 支付宝账单CSV文件上传接口
@@ -374,6 +377,8 @@ func StoreAlipayCSVInfoHandler(c *gin.Context) {
 		response.Fail(c, 300002)
 		return
 	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
 	// 获取请求参数
 	req, ok := c.MustGet("payload").(StoreAlipayCSVInfoRequest)
 	if !ok {
@@ -390,6 +395,24 @@ func StoreAlipayCSVInfoHandler(c *gin.Context) {
 		response.Fail(c, 100010)
 		return
 	}
+	// 商户规范化
+	normalizer, err := service.NewMerchantNormalizer(config.DB, ledgerID)
+	if err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	// 交易方式别名
+	resolver, err := service.NewPaymentMethodResolver(config.DB, ledgerID)
+	if err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	// 自动分类规则
+	engine, err := service.NewBillRuleEngine(config.DB, ledgerID)
+	if err != nil {
+		response.Fail(c, 100001)
+		return
+	}
 	// 开启事务
 	tx := config.DB.Begin()
 	defer func() {
@@ -398,6 +421,7 @@ func StoreAlipayCSVInfoHandler(c *gin.Context) {
 			response.Fail(c, 100011)
 		}
 	}()
+	created := []model.BillRecord{}
 	for i := 23; i < len(records); i++ {
 		row := records[i]
 		if len(row) < 12 {
@@ -407,9 +431,14 @@ func StoreAlipayCSVInfoHandler(c *gin.Context) {
 		for i := range row {
 			row[i] = strings.TrimSpace(row[i])
 		}
-		// 判断是否已存在
-		var exist model.BillRecord
-		if err := tx.Where("trade_no = ?", row[9]).First(&exist).Error; err == nil {
+		// 判断是否已存在，回收站中及已彻底删除的交易同样跳过
+		exist, err := service.BillTradeNoExists(tx, ledgerID, row[9])
+		if err != nil {
+			tx.Rollback()
+			response.Fail(c, 100001)
+			return
+		}
+		if exist {
 			continue
 		}
 		// 解析时间
@@ -430,30 +459,58 @@ func StoreAlipayCSVInfoHandler(c *gin.Context) {
 			paymentMethod = row[7]
 		}
 		bill := model.BillRecord{
-			UserID:          userID,
-			TradeNo:         row[9],
-			MerchantOrderNo: row[10],
-			Platform:        uint8(model.PlatformAlipay),
-			IncomeType:      model.IncomeTypeFromString(row[5]),
-			TradeType:       row[1],
-			ProductName:     row[4],
-			Counterparty:    row[2],
-			PaymentMethod:   paymentMethod,
-			Amount:          amount,
-			TradeStatus:     row[8],
-			TradeTime:       t.Unix(),
-			Remark:          row[11],
+			UserID:           userID,
+			LedgerID:         ledgerID,
+			TradeNo:          row[9],
+			MerchantOrderNo:  row[10],
+			Platform:         uint8(model.PlatformAlipay),
+			IncomeType:       model.IncomeTypeFromString(row[5]),
+			TradeType:        row[1],
+			ProductName:      row[4],
+			Counterparty:     row[2],
+			MerchantID:       normalizer.Match(row[2]),
+			PaymentMethod:    resolver.Resolve(paymentMethod),
+			RawPaymentMethod: paymentMethod,
+			Amount:           amount,
+			TradeStatus:      row[8],
+			TradeStatusType:  model.TradeStatusTypeFromString(row[8]),
+			TradeTime:        t.Unix(),
+			Remark:           row[11],
+		}
+		// 执行自动分类规则
+		result := engine.Apply(&bill)
+		if result.Ignore {
+			continue
 		}
 		if err := tx.Create(&bill).Error; err != nil {
 			tx.Rollback()
 			response.Fail(c, 100006)
 			return
 		}
+		created = append(created, bill)
+		if len(result.TagIDs) > 0 {
+			links := make([]model.BillRecordTag, 0, len(result.TagIDs))
+			for _, tagID := range result.TagIDs {
+				links = append(links, model.BillRecordTag{BillRecordID: bill.ID, TagID: tagID})
+			}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&links).Error; err != nil {
+				tx.Rollback()
+				response.Fail(c, 100006)
+				return
+			}
+		}
+	}
+	if err := service.RecordBillCreated(tx, userID, model.BillSourceImport, created); err != nil {
+		tx.Rollback()
+		response.Fail(c, 100006)
+		return
 	}
 	if err := tx.Commit().Error; err != nil {
 		response.Fail(c, 100007)
 		return
 	}
+	// 匹配新导入的报销收入与退款，匹配失败不影响导入结果
+	matchImportedBills(userID, ledgerID)
 	// 返回数据
 	response.Ok(c, gin.H{})
 }
@@ -477,6 +534,8 @@ func StoreWechatXLSXInfoHandler(c *gin.Context) {
 		response.Fail(c, 300002)
 		return
 	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
 	// 获取请求参数
 	req, ok := c.MustGet("payload").(StoreWechatXLSXInfoRequest)
 	if !ok {
@@ -493,6 +552,24 @@ func StoreWechatXLSXInfoHandler(c *gin.Context) {
 		response.Fail(c, 100010)
 		return
 	}
+	// 商户规范化
+	normalizer, err := service.NewMerchantNormalizer(config.DB, ledgerID)
+	if err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	// 交易方式别名
+	resolver, err := service.NewPaymentMethodResolver(config.DB, ledgerID)
+	if err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	// 自动分类规则
+	engine, err := service.NewBillRuleEngine(config.DB, ledgerID)
+	if err != nil {
+		response.Fail(c, 100001)
+		return
+	}
 	// 开启事务
 	tx := config.DB.Begin()
 	defer func() {
@@ -501,6 +578,7 @@ func StoreWechatXLSXInfoHandler(c *gin.Context) {
 			response.Fail(c, 100011)
 		}
 	}()
+	created := []model.BillRecord{}
 	for i := 17; i < len(records); i++ {
 		row := records[i]
 		if len(row) < 11 {
@@ -510,9 +588,14 @@ func StoreWechatXLSXInfoHandler(c *gin.Context) {
 		for i := range row {
 			row[i] = strings.TrimSpace(row[i])
 		}
-		// 判断是否已存在
-		var exist model.BillRecord
-		if err := tx.Where("trade_no = ?", row[8]).First(&exist).Error; err == nil {
+		// 判断是否已存在，回收站中及已彻底删除的交易同样跳过
+		exist, err := service.BillTradeNoExists(tx, ledgerID, row[8])
+		if err != nil {
+			tx.Rollback()
+			response.Fail(c, 100001)
+			return
+		}
+		if exist {
 			continue
 		}
 		// 解析时间
@@ -533,30 +616,58 @@ func StoreWechatXLSXInfoHandler(c *gin.Context) {
 			paymentMethod = row[6]
 		}
 		bill := model.BillRecord{
-			UserID:          userID,
-			TradeNo:         row[8],
-			MerchantOrderNo: row[9],
-			Platform:        uint8(model.PlatformWechat),
-			IncomeType:      model.IncomeTypeFromString(row[4]),
-			TradeType:       row[1],
-			ProductName:     row[3],
-			Counterparty:    row[2],
-			PaymentMethod:   paymentMethod,
-			Amount:          amount,
-			TradeStatus:     row[7],
-			TradeTime:       t.Unix(),
-			Remark:          row[10],
+			UserID:           userID,
+			LedgerID:         ledgerID,
+			TradeNo:          row[8],
+			MerchantOrderNo:  row[9],
+			Platform:         uint8(model.PlatformWechat),
+			IncomeType:       model.IncomeTypeFromString(row[4]),
+			TradeType:        row[1],
+			ProductName:      row[3],
+			Counterparty:     row[2],
+			MerchantID:       normalizer.Match(row[2]),
+			PaymentMethod:    resolver.Resolve(paymentMethod),
+			RawPaymentMethod: paymentMethod,
+			Amount:           amount,
+			TradeStatus:      row[7],
+			TradeStatusType:  model.TradeStatusTypeFromString(row[7]),
+			TradeTime:        t.Unix(),
+			Remark:           row[10],
+		}
+		// 执行自动分类规则
+		result := engine.Apply(&bill)
+		if result.Ignore {
+			continue
 		}
 		if err := tx.Create(&bill).Error; err != nil {
 			tx.Rollback()
 			response.Fail(c, 100006)
 			return
 		}
+		created = append(created, bill)
+		if len(result.TagIDs) > 0 {
+			links := make([]model.BillRecordTag, 0, len(result.TagIDs))
+			for _, tagID := range result.TagIDs {
+				links = append(links, model.BillRecordTag{BillRecordID: bill.ID, TagID: tagID})
+			}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&links).Error; err != nil {
+				tx.Rollback()
+				response.Fail(c, 100006)
+				return
+			}
+		}
+	}
+	if err := service.RecordBillCreated(tx, userID, model.BillSourceImport, created); err != nil {
+		tx.Rollback()
+		response.Fail(c, 100006)
+		return
 	}
 	if err := tx.Commit().Error; err != nil {
 		response.Fail(c, 100007)
 		return
 	}
+	// 匹配新导入的报销收入与退款，匹配失败不影响导入结果
+	matchImportedBills(userID, ledgerID)
 	// 返回数据
 	response.Ok(c, gin.H{})
 }
@@ -596,3 +707,13 @@ func GetAlipayBillMailHandler(c *gin.Context) {
 	// 待定···
 	// 未来实现
 }
+
+// 为导入的账单匹配报销收入与退款，失败时仅记录日志
+func matchImportedBills(userID uint, ledgerID uint) {
+	if _, err := service.MatchReimbursements(config.DB, userID, ledgerID); err != nil {
+		log.Printf("账本 %d 报销匹配失败: %v", ledgerID, err)
+	}
+	if _, err := service.MatchRefunds(config.DB, ledgerID); err != nil {
+		log.Printf("账本 %d 退款匹配失败: %v", ledgerID, err)
+	}
+}
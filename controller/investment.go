@@ -0,0 +1,600 @@
+package controller
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zxc7563598/fintrack-backend/config"
+	"github.com/zxc7563598/fintrack-backend/model"
+	"github.com/zxc7563598/fintrack-backend/service"
+	"github.com/zxc7563598/fintrack-backend/utils/helpers"
+	"github.com/zxc7563598/fintrack-backend/utils/response"
+	"gorm.io/gorm"
+)
+
+type InvestmentValuation struct {
+	ID             uint          `json:"id"`
+	Code           string        `json:"code"`
+	Name           string        `json:"name"`
+	Kind           uint8         `json:"kind"`
+	Units          float64       `json:"units"`
+	CostBasis      helpers.Money `json:"cost_basis"`
+	Price          float64       `json:"price"`
+	PriceDate      string        `json:"price_date"`
+	MarketValue    helpers.Money `json:"market_value"`
+	UnrealizedGain helpers.Money `json:"unrealized_gain"`
+	RealizedGain   helpers.Money `json:"realized_gain"`
+	TotalGain      helpers.Money `json:"total_gain"`
+	Invested       helpers.Money `json:"invested"`
+	ReturnRate     float64       `json:"return_rate"`
+}
+
+// 获取投资持仓接口：市值、浮动盈亏、已实现收益及收益率
+func GetInvestmentListHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取数据
+	var holdings []model.InvestmentHolding
+	if err := config.DB.Where("user_id = ?", userID).Order("kind, id").Find(&holdings).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	var totalValue, totalCost, totalUnrealized, totalRealized, totalInvested float64
+	list := make([]InvestmentValuation, 0, len(holdings))
+	for _, h := range holdings {
+		price, date, err := latestInvestmentPrice(h)
+		if err != nil {
+			response.Fail(c, 100001)
+			return
+		}
+		value := h.Units * price
+		unrealized := value - h.CostBasis
+		item := InvestmentValuation{
+			ID:             h.ID,
+			Code:           h.Code,
+			Name:           h.Name,
+			Kind:           h.Kind,
+			Units:          h.Units,
+			CostBasis:      helpers.Money(h.CostBasis),
+			Price:          price,
+			PriceDate:      date,
+			MarketValue:    helpers.Money(value),
+			UnrealizedGain: helpers.Money(unrealized),
+			RealizedGain:   helpers.Money(h.RealizedGain),
+			TotalGain:      helpers.Money(unrealized + h.RealizedGain),
+			Invested:       helpers.Money(h.Invested),
+		}
+		if h.Invested > 0 {
+			item.ReturnRate = math.Round((unrealized+h.RealizedGain)/h.Invested*10000) / 100
+		}
+		totalValue += value
+		totalCost += h.CostBasis
+		totalUnrealized += unrealized
+		totalRealized += h.RealizedGain
+		totalInvested += h.Invested
+		list = append(list, item)
+	}
+	returnRate := 0.0
+	if totalInvested > 0 {
+		returnRate = math.Round((totalUnrealized+totalRealized)/totalInvested*10000) / 100
+	}
+	// 返回信息
+	response.Ok(c, gin.H{
+		"market_value":    helpers.Money(totalValue),
+		"cost_basis":      helpers.Money(totalCost),
+		"unrealized_gain": helpers.Money(totalUnrealized),
+		"realized_gain":   helpers.Money(totalRealized),
+		"invested":        helpers.Money(totalInvested),
+		"return_rate":     returnRate,
+		"list":            list,
+	})
+}
+
+// 存储投资持仓请求体
+type StoreInvestmentRequest struct {
+	ID   uint   `json:"id"`                      // ID，修改透传，添加为0
+	Code string `json:"code"`                    // 代码
+	Name string `json:"name" binding:"required"` // 名称
+	Kind uint8  `json:"kind" binding:"required"` // 品种（1基金、2货币基金（余额宝）、3股票、4其他）
+}
+
+// 存储投资持仓接口
+func StoreInvestmentHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(StoreInvestmentRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" || req.Kind < uint8(model.InvestmentKindFund) || req.Kind > uint8(model.InvestmentKindOther) {
+		response.Fail(c, 300013)
+		return
+	}
+	if req.ID > 0 {
+		// 修改（份额与成本由交易记录计算，不在此修改）
+		result := config.DB.Model(&model.InvestmentHolding{}).
+			Where("id = ? AND user_id = ?", req.ID, userID).
+			Updates(map[string]any{
+				"code": strings.TrimSpace(req.Code),
+				"name": name,
+				"kind": req.Kind,
+			})
+		if result.Error != nil {
+			response.Fail(c, 100013)
+			return
+		}
+		if result.RowsAffected == 0 {
+			response.Fail(c, 100049)
+			return
+		}
+		response.Ok(c, gin.H{
+			"id": req.ID,
+		})
+		return
+	}
+	// 新增
+	holding := model.InvestmentHolding{
+		UserID: userID,
+		Code:   strings.TrimSpace(req.Code),
+		Name:   name,
+		Kind:   req.Kind,
+	}
+	if err := config.DB.Create(&holding).Error; err != nil {
+		response.Fail(c, 100013)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"id": holding.ID,
+	})
+}
+
+// 删除投资持仓请求体
+type DeleteInvestmentRequest struct {
+	ID uint `json:"id" binding:"required"` // ID
+}
+
+// 删除投资持仓接口，同时删除其交易记录和净值历史（关联的账单保留）
+func DeleteInvestmentHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(DeleteInvestmentRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	// 删除数据
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND user_id = ?", req.ID, userID).Delete(&model.InvestmentHolding{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		if err := tx.Where("holding_id = ?", req.ID).Delete(&model.InvestmentTransaction{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("holding_id = ?", req.ID).Delete(&model.InvestmentPrice{}).Error
+	})
+	if err != nil {
+		response.Fail(c, 100014)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{})
+}
+
+// 获取投资交易请求体
+type GetInvestmentTransactionRequest struct {
+	HoldingID uint `json:"holding_id" binding:"required"` // 持仓ID
+}
+
+// 获取投资交易列表接口
+func GetInvestmentTransactionListHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(GetInvestmentTransactionRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	// 获取数据
+	var list []model.InvestmentTransaction
+	if err := config.DB.Where("user_id = ? AND holding_id = ?", userID, req.HoldingID).Order("trade_at DESC, id DESC").Find(&list).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	// 返回信息
+	response.Ok(c, gin.H{
+		"list": list,
+	})
+}
+
+// 存储投资交易请求体
+type StoreInvestmentTransactionRequest struct {
+	ID           uint    `json:"id"`                            // ID，修改透传，添加为0
+	HoldingID    uint    `json:"holding_id" binding:"required"` // 持仓ID
+	Type         uint8   `json:"type" binding:"required"`       // 类型（1买入、2卖出、3现金分红、4收益再投资）
+	TradeTime    string  `json:"trade_time"`                    // 交易时间，关联账单时为空则使用账单时间
+	Units        float64 `json:"units"`                         // 份额，为空时按金额 / 净值计算
+	Price        float64 `json:"price"`                         // 成交净值，货币基金默认为1
+	Amount       float64 `json:"amount"`                        // 成交金额，关联账单时为空则使用账单金额
+	Fee          float64 `json:"fee"`                           // 手续费
+	BillRecordID uint    `json:"bill_record_id"`                // 关联账单ID
+	Remark       string  `json:"remark"`                        // 备注
+}
+
+// 存储投资交易接口，保存后重新计算持仓
+func StoreInvestmentTransactionHandler(c *gin.Context) {
+	layout := "2006-01-02 15:04:05"
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(StoreInvestmentTransactionRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	var holding model.InvestmentHolding
+	if err := config.DB.Where("id = ? AND user_id = ?", req.HoldingID, userID).First(&holding).Error; err != nil {
+		response.Fail(c, 100049)
+		return
+	}
+	txn := model.InvestmentTransaction{
+		UserID:       userID,
+		HoldingID:    holding.ID,
+		Type:         req.Type,
+		Units:        req.Units,
+		Price:        req.Price,
+		Amount:       req.Amount,
+		Fee:          req.Fee,
+		BillRecordID: req.BillRecordID,
+		Remark:       req.Remark,
+	}
+	// 关联账单需属于当前账本，补全金额和时间
+	ledgerID := c.GetUint("ledger_id")
+	if code := checkOwnedBillRecord(ledgerID, req.BillRecordID); code != 0 {
+		response.Fail(c, code)
+		return
+	}
+	if req.BillRecordID > 0 {
+		var bill model.BillRecord
+		if err := config.DB.Where("id = ? AND ledger_id = ?", req.BillRecordID, ledgerID).First(&bill).Error; err != nil {
+			response.Fail(c, 100051)
+			return
+		}
+		if txn.Amount == 0 {
+			txn.Amount = bill.Amount
+		}
+		txn.TradeAt = bill.TradeTime
+	}
+	if req.TradeTime != "" {
+		t, err := time.ParseInLocation(layout, req.TradeTime, time.Local)
+		if err != nil {
+			response.Fail(c, 100012)
+			return
+		}
+		txn.TradeAt = t.Unix()
+	}
+	if txn.TradeAt == 0 {
+		txn.TradeAt = time.Now().Unix()
+	}
+	// 补全份额、净值与金额
+	if txn.Price == 0 && holding.Kind == uint8(model.InvestmentKindMoneyMarket) {
+		txn.Price = 1
+	}
+	switch model.InvestmentTransactionType(txn.Type) {
+	case model.InvestmentBuy, model.InvestmentSell, model.InvestmentReinvest:
+		if txn.Units == 0 && txn.Price > 0 {
+			txn.Units = math.Round(txn.Amount/txn.Price*10000) / 10000
+		}
+		if txn.Amount == 0 {
+			txn.Amount = math.Round(txn.Units*txn.Price*100) / 100
+		}
+		if txn.Units <= 0 || txn.Amount <= 0 {
+			response.Fail(c, 100050)
+			return
+		}
+		if txn.Price == 0 {
+			txn.Price = txn.Amount / txn.Units
+		}
+	case model.InvestmentDividend:
+		txn.Units = 0
+		if txn.Amount <= 0 {
+			response.Fail(c, 100050)
+			return
+		}
+	default:
+		response.Fail(c, 100050)
+		return
+	}
+	if txn.Fee < 0 {
+		response.Fail(c, 100050)
+		return
+	}
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if req.ID > 0 {
+			var exist model.InvestmentTransaction
+			if err := tx.Where("id = ? AND user_id = ? AND holding_id = ?", req.ID, userID, holding.ID).First(&exist).Error; err != nil {
+				return err
+			}
+			txn.ID = exist.ID
+			txn.CreatedAt = exist.CreatedAt
+		}
+		if err := tx.Save(&txn).Error; err != nil {
+			return err
+		}
+		return service.RecalculateInvestmentHolding(tx, holding.ID)
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrInsufficientUnits) {
+			response.Fail(c, 100050)
+			return
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.Fail(c, 100052)
+			return
+		}
+		response.Fail(c, 100023)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"id": txn.ID,
+	})
+}
+
+// 删除投资交易请求体
+type DeleteInvestmentTransactionRequest struct {
+	ID uint `json:"id" binding:"required"` // ID
+}
+
+// 删除投资交易接口，删除后重新计算持仓
+func DeleteInvestmentTransactionHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(DeleteInvestmentTransactionRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	var txn model.InvestmentTransaction
+	if err := config.DB.Where("id = ? AND user_id = ?", req.ID, userID).First(&txn).Error; err != nil {
+		response.Fail(c, 100052)
+		return
+	}
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&txn).Error; err != nil {
+			return err
+		}
+		return service.RecalculateInvestmentHolding(tx, txn.HoldingID)
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrInsufficientUnits) {
+			response.Fail(c, 100050)
+			return
+		}
+		response.Fail(c, 100014)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{})
+}
+
+// 获取净值历史请求体
+type GetInvestmentPriceRequest struct {
+	HoldingID uint `json:"holding_id" binding:"required"` // 持仓ID
+}
+
+// 获取净值历史接口
+func GetInvestmentPriceListHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(GetInvestmentPriceRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	// 获取数据
+	var list []model.InvestmentPrice
+	if err := config.DB.Where("user_id = ? AND holding_id = ?", userID, req.HoldingID).Order("date DESC").Find(&list).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	// 返回信息
+	response.Ok(c, gin.H{
+		"list": list,
+	})
+}
+
+// 存储净值请求体
+type StoreInvestmentPriceRequest struct {
+	HoldingID uint    `json:"holding_id" binding:"required"` // 持仓ID
+	Date      string  `json:"date" binding:"required"`       // 日期（2006-01-02）
+	Price     float64 `json:"price" binding:"required"`      // 净值（价格）
+}
+
+// 存储净值接口，同一日期重复录入时覆盖
+func StoreInvestmentPriceHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(StoreInvestmentPriceRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	if _, err := time.Parse("2006-01-02", req.Date); err != nil {
+		response.Fail(c, 100012)
+		return
+	}
+	if req.Price <= 0 {
+		response.Fail(c, 300013)
+		return
+	}
+	var count int64
+	if err := config.DB.Model(&model.InvestmentHolding{}).Where("id = ? AND user_id = ?", req.HoldingID, userID).Count(&count).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	if count == 0 {
+		response.Fail(c, 100049)
+		return
+	}
+	// 存储数据
+	var price model.InvestmentPrice
+	if err := config.DB.Where("holding_id = ? AND date = ?", req.HoldingID, req.Date).Limit(1).Find(&price).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	price.UserID = userID
+	price.HoldingID = req.HoldingID
+	price.Date = req.Date
+	price.Price = req.Price
+	if err := config.DB.Save(&price).Error; err != nil {
+		response.Fail(c, 100013)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"id": price.ID,
+	})
+}
+
+// 删除净值请求体
+type DeleteInvestmentPriceRequest struct {
+	ID uint `json:"id" binding:"required"` // ID
+}
+
+// 删除净值接口
+func DeleteInvestmentPriceHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(DeleteInvestmentPriceRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	// 删除数据
+	if err := config.DB.Where("id = ? and user_id = ?", req.ID, userID).Delete(&model.InvestmentPrice{}).Error; err != nil {
+		response.Fail(c, 100014)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{})
+}
+
+// 获取持仓的最新净值及日期：优先使用录入的净值，没有时使用最近一次交易的成交净值，货币基金默认为1
+func latestInvestmentPrice(h model.InvestmentHolding) (float64, string, error) {
+	var prices []model.InvestmentPrice
+	if err := config.DB.Where("holding_id = ?", h.ID).Order("date DESC").Limit(1).Find(&prices).Error; err != nil {
+		return 0, "", err
+	}
+	var txns []model.InvestmentTransaction
+	if err := config.DB.Where("holding_id = ? AND price > 0", h.ID).Order("trade_at DESC, id DESC").Limit(1).Find(&txns).Error; err != nil {
+		return 0, "", err
+	}
+	// 取录入净值与成交净值中日期较新的一个
+	if len(prices) > 0 {
+		if len(txns) == 0 || time.Unix(txns[0].TradeAt, 0).Format("2006-01-02") <= prices[0].Date {
+			return prices[0].Price, prices[0].Date, nil
+		}
+	}
+	if len(txns) > 0 {
+		return txns[0].Price, time.Unix(txns[0].TradeAt, 0).Format("2006-01-02"), nil
+	}
+	if h.Kind == uint8(model.InvestmentKindMoneyMarket) {
+		return 1, "", nil
+	}
+	return 0, "", nil
+}
@@ -0,0 +1,586 @@
+package controller
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zxc7563598/fintrack-backend/config"
+	"github.com/zxc7563598/fintrack-backend/model"
+	"github.com/zxc7563598/fintrack-backend/service"
+	"github.com/zxc7563598/fintrack-backend/utils/response"
+	"gorm.io/gorm"
+)
+
+type LedgerItem struct {
+	ID          uint   `json:"id"`
+	Name        string `json:"name"`
+	Remark      string `json:"remark"`
+	OwnerID     uint   `json:"owner_id"`
+	IsDefault   bool   `json:"is_default"`
+	Role        uint8  `json:"role"`
+	MemberCount int64  `json:"member_count"`
+}
+
+// 获取账本列表接口：返回用户所属的全部账本及其角色
+func GetLedgerListHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 确保默认账本存在
+	if _, err := service.DefaultLedger(config.DB, userID); err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	// 获取数据
+	list := []LedgerItem{}
+	if err := config.DB.Model(&model.Ledger{}).
+		Select("ledgers.id, ledgers.name, ledgers.remark, ledgers.owner_id, ledgers.is_default, ledger_members.role, (SELECT COUNT(*) FROM ledger_members m WHERE m.ledger_id = ledgers.id) AS member_count").
+		Joins("JOIN ledger_members ON ledger_members.ledger_id = ledgers.id AND ledger_members.user_id = ?", userID).
+		Order("ledgers.is_default DESC, ledgers.id").
+		Scan(&list).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	// 返回信息
+	response.Ok(c, gin.H{
+		"list": list,
+	})
+}
+
+// 存储账本请求体
+type StoreLedgerRequest struct {
+	ID     uint   `json:"id"`                      // ID，修改透传，添加为0
+	Name   string `json:"name" binding:"required"` // 账本名称
+	Remark string `json:"remark"`                  // 备注
+}
+
+// 存储账本接口，新建的账本所有者为当前用户，仅所有者可修改
+func StoreLedgerHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(StoreLedgerRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		response.Fail(c, 300013)
+		return
+	}
+	ledger := model.Ledger{
+		OwnerID: userID,
+		Name:    name,
+		Remark:  req.Remark,
+	}
+	if req.ID > 0 {
+		// 修改
+		if code := checkLedgerOwner(req.ID, userID); code != 0 {
+			response.Fail(c, code)
+			return
+		}
+		if err := config.DB.Model(&model.Ledger{}).Where("id = ?", req.ID).Updates(map[string]any{
+			"name":   ledger.Name,
+			"remark": ledger.Remark,
+		}).Error; err != nil {
+			response.Fail(c, 100013)
+			return
+		}
+		ledger.ID = req.ID
+	} else {
+		// 新增
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&ledger).Error; err != nil {
+				return err
+			}
+			return tx.Create(&model.LedgerMember{LedgerID: ledger.ID, UserID: userID, Role: uint8(model.LedgerOwner)}).Error
+		})
+		if err != nil {
+			response.Fail(c, 100013)
+			return
+		}
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"id": ledger.ID,
+	})
+}
+
+// 账本请求体
+type LedgerRequest struct {
+	LedgerID uint `json:"ledger_id" binding:"required"` // 账本ID
+}
+
+// 删除账本接口，仅所有者可删除，默认账本及仍有账单的账本不能删除
+func DeleteLedgerHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(LedgerRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	if code := checkLedgerOwner(req.LedgerID, userID); code != 0 {
+		response.Fail(c, code)
+		return
+	}
+	var ledger model.Ledger
+	if err := config.DB.Where("id = ?", req.LedgerID).First(&ledger).Error; err != nil {
+		response.Fail(c, 100066)
+		return
+	}
+	if ledger.IsDefault {
+		response.Fail(c, 100068)
+		return
+	}
+	var count int64
+	if err := config.DB.Model(&model.BillRecord{}).Where("ledger_id = ?", ledger.ID).Count(&count).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	if count > 0 {
+		response.Fail(c, 100069)
+		return
+	}
+	// 删除数据
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&ledger).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("ledger_id = ?", ledger.ID).Delete(&model.LedgerMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("ledger_id = ?", ledger.ID).Delete(&model.LedgerInvitation{}).Error
+	})
+	if err != nil {
+		response.Fail(c, 100014)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{})
+}
+
+type LedgerMemberItem struct {
+	UserID uint   `json:"user_id"`
+	Name   string `json:"name"`
+	Email  string `json:"email"`
+	Role   uint8  `json:"role"`
+}
+
+// 获取账本成员接口：成员列表及待接受的邀请
+func GetLedgerMemberListHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(LedgerRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	role, err := service.LedgerRoleOf(config.DB, req.LedgerID, userID)
+	if err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	if role == 0 {
+		response.Fail(c, 100066)
+		return
+	}
+	// 获取数据
+	members := []LedgerMemberItem{}
+	if err := config.DB.Model(&model.LedgerMember{}).
+		Select("ledger_members.user_id, users.name, users.email, ledger_members.role").
+		Joins("JOIN users ON users.id = ledger_members.user_id").
+		Where("ledger_members.ledger_id = ?", req.LedgerID).
+		Order("ledger_members.role, ledger_members.id").
+		Scan(&members).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	invitations := []model.LedgerInvitation{}
+	if err := config.DB.Where("ledger_id = ? AND status = ?", req.LedgerID, model.InvitationPending).Order("id").Find(&invitations).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	// 返回信息
+	response.Ok(c, gin.H{
+		"members":     members,
+		"invitations": invitations,
+	})
+}
+
+// 修改成员角色请求体
+type UpdateLedgerMemberRequest struct {
+	LedgerID uint  `json:"ledger_id" binding:"required"` // 账本ID
+	UserID   uint  `json:"user_id" binding:"required"`   // 成员用户ID
+	Role     uint8 `json:"role" binding:"required"`      // 角色（2编辑者、3查看者）
+}
+
+// 修改成员角色接口，仅所有者可操作
+func UpdateLedgerMemberHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(UpdateLedgerMemberRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	if req.Role != uint8(model.LedgerEditor) && req.Role != uint8(model.LedgerViewer) {
+		response.Fail(c, 300013)
+		return
+	}
+	if code := checkLedgerOwner(req.LedgerID, userID); code != 0 {
+		response.Fail(c, code)
+		return
+	}
+	if req.UserID == userID {
+		response.Fail(c, 100072)
+		return
+	}
+	result := config.DB.Model(&model.LedgerMember{}).
+		Where("ledger_id = ? AND user_id = ?", req.LedgerID, req.UserID).
+		Update("role", req.Role)
+	if result.Error != nil {
+		response.Fail(c, 100023)
+		return
+	}
+	if result.RowsAffected == 0 {
+		response.Fail(c, 100072)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{})
+}
+
+// 移除成员请求体
+type RemoveLedgerMemberRequest struct {
+	LedgerID uint `json:"ledger_id" binding:"required"` // 账本ID
+	UserID   uint `json:"user_id" binding:"required"`   // 成员用户ID，为本人时表示退出账本
+}
+
+// 移除成员接口：所有者可移除其他成员，成员可自行退出，所有者不能退出
+func RemoveLedgerMemberHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(RemoveLedgerMemberRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	role, err := service.LedgerRoleOf(config.DB, req.LedgerID, userID)
+	if err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	if role == 0 {
+		response.Fail(c, 100066)
+		return
+	}
+	if req.UserID == userID && role == model.LedgerOwner {
+		response.Fail(c, 100072)
+		return
+	}
+	if req.UserID != userID && role != model.LedgerOwner {
+		response.Fail(c, 100067)
+		return
+	}
+	// 删除数据
+	if err := config.DB.Where("ledger_id = ? AND user_id = ?", req.LedgerID, req.UserID).Delete(&model.LedgerMember{}).Error; err != nil {
+		response.Fail(c, 100014)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{})
+}
+
+// 邀请成员请求体
+type InviteLedgerMemberRequest struct {
+	LedgerID uint   `json:"ledger_id" binding:"required"` // 账本ID
+	Email    string `json:"email" binding:"required"`     // 被邀请人邮箱
+	Role     uint8  `json:"role" binding:"required"`      // 加入后的角色（2编辑者、3查看者）
+}
+
+// 邀请成员接口，仅所有者可邀请，同一邮箱重复邀请时更新角色
+func InviteLedgerMemberHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(InviteLedgerMemberRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	if email == "" || (req.Role != uint8(model.LedgerEditor) && req.Role != uint8(model.LedgerViewer)) {
+		response.Fail(c, 300013)
+		return
+	}
+	if code := checkLedgerOwner(req.LedgerID, userID); code != 0 {
+		response.Fail(c, code)
+		return
+	}
+	// 已是成员的用户不需要邀请
+	var count int64
+	if err := config.DB.Model(&model.LedgerMember{}).
+		Joins("JOIN users ON users.id = ledger_members.user_id").
+		Where("ledger_members.ledger_id = ? AND LOWER(users.email) = ?", req.LedgerID, email).
+		Count(&count).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	if count > 0 {
+		response.Fail(c, 100071)
+		return
+	}
+	var invitation model.LedgerInvitation
+	if err := config.DB.Where(map[string]any{"ledger_id": req.LedgerID, "email": email, "status": model.InvitationPending}).
+		Assign(map[string]any{"role": req.Role, "invited_by": userID}).
+		FirstOrCreate(&invitation).Error; err != nil {
+		response.Fail(c, 100013)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"id": invitation.ID,
+	})
+}
+
+// 撤销邀请请求体
+type RevokeLedgerInvitationRequest struct {
+	ID uint `json:"id" binding:"required"` // 邀请ID
+}
+
+// 撤销邀请接口，仅所有者可操作
+func RevokeLedgerInvitationHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(RevokeLedgerInvitationRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	var invitation model.LedgerInvitation
+	if err := config.DB.Where("id = ? AND status = ?", req.ID, model.InvitationPending).First(&invitation).Error; err != nil {
+		response.Fail(c, 100070)
+		return
+	}
+	if code := checkLedgerOwner(invitation.LedgerID, userID); code != 0 {
+		response.Fail(c, code)
+		return
+	}
+	// 删除数据
+	if err := config.DB.Delete(&invitation).Error; err != nil {
+		response.Fail(c, 100014)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{})
+}
+
+type LedgerInvitationItem struct {
+	ID          uint   `json:"id"`
+	LedgerID    uint   `json:"ledger_id"`
+	LedgerName  string `json:"ledger_name"`
+	Role        uint8  `json:"role"`
+	InviterName string `json:"inviter_name"`
+}
+
+// 获取收到的邀请接口：发送到当前用户邮箱的待接受邀请
+func GetMyLedgerInvitationListHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	var user model.User
+	if err := config.DB.Where("id = ?", userID).First(&user).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	// 获取数据
+	list := []LedgerInvitationItem{}
+	if err := config.DB.Model(&model.LedgerInvitation{}).
+		Select("ledger_invitations.id, ledger_invitations.ledger_id, ledgers.name AS ledger_name, ledger_invitations.role, users.name AS inviter_name").
+		Joins("JOIN ledgers ON ledgers.id = ledger_invitations.ledger_id AND ledgers.deleted_at IS NULL").
+		Joins("LEFT JOIN users ON users.id = ledger_invitations.invited_by").
+		Where("ledger_invitations.email = ? AND ledger_invitations.status = ?", strings.ToLower(user.Email), model.InvitationPending).
+		Order("ledger_invitations.id").
+		Scan(&list).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	// 返回信息
+	response.Ok(c, gin.H{
+		"list": list,
+	})
+}
+
+// 处理邀请请求体
+type RespondLedgerInvitationRequest struct {
+	ID     uint `json:"id" binding:"required"` // 邀请ID
+	Accept bool `json:"accept"`                // 是否接受
+}
+
+// 处理邀请接口：接受后以邀请中的角色加入账本
+func RespondLedgerInvitationHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(RespondLedgerInvitationRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	var user model.User
+	if err := config.DB.Where("id = ?", userID).First(&user).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	var invitation model.LedgerInvitation
+	if err := config.DB.Where("id = ? AND email = ? AND status = ?", req.ID, strings.ToLower(user.Email), model.InvitationPending).First(&invitation).Error; err != nil {
+		response.Fail(c, 100070)
+		return
+	}
+	// 存储数据
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		status := model.InvitationDeclined
+		if req.Accept {
+			status = model.InvitationAccepted
+			member := model.LedgerMember{LedgerID: invitation.LedgerID, UserID: userID, Role: invitation.Role}
+			if err := tx.Where(map[string]any{"ledger_id": invitation.LedgerID, "user_id": userID}).FirstOrCreate(&member).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&invitation).Update("status", status).Error
+	})
+	if err != nil {
+		response.Fail(c, 100023)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"ledger_id": invitation.LedgerID,
+	})
+}
+
+// 校验用户是账本所有者，失败时返回错误码
+func checkLedgerOwner(ledgerID uint, userID uint) int {
+	role, err := service.LedgerRoleOf(config.DB, ledgerID, userID)
+	if err != nil {
+		return 100001
+	}
+	if role == 0 {
+		return 100066
+	}
+	if role != model.LedgerOwner {
+		return 100067
+	}
+	return 0
+}
+
+// 账本备份接口：返回包含账本信息、账单及其关联数据、商户、别名、规则、变更历史和附件文件的 ZIP
+func BackupLedgerHandler(c *gin.Context) {
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 生成备份
+	var buf bytes.Buffer
+	if err := service.WriteLedgerBackup(config.DB, attachmentStore(), ledgerID, &buf); err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	// 返回字节流
+	c.Data(http.StatusOK, "application/zip", buf.Bytes())
+}
@@ -0,0 +1,540 @@
+package controller
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zxc7563598/fintrack-backend/config"
+	"github.com/zxc7563598/fintrack-backend/model"
+	"github.com/zxc7563598/fintrack-backend/service"
+	"github.com/zxc7563598/fintrack-backend/utils/helpers"
+	"github.com/zxc7563598/fintrack-backend/utils/response"
+	"gorm.io/gorm"
+)
+
+type LoanItem struct {
+	ID           uint          `json:"id"`
+	PersonID     uint          `json:"person_id"`
+	PersonName   string        `json:"person_name"`
+	Direction    uint8         `json:"direction"`
+	Amount       helpers.Money `json:"amount"`
+	Repaid       helpers.Money `json:"repaid"`
+	Outstanding  helpers.Money `json:"outstanding"`
+	LoanedAt     int64         `json:"loaned_at"`
+	DueAt        int64         `json:"due_at"`
+	Overdue      bool          `json:"overdue"`
+	BillRecordID uint          `json:"bill_record_id"`
+	Remark       string        `json:"remark"`
+}
+
+// 获取借贷记录请求体
+type GetLoanListRequest struct {
+	PersonID    *uint `json:"person_id"`   // 联系人ID，为空时返回全部
+	Outstanding bool  `json:"outstanding"` // 仅返回未还清的记录
+	Overdue     bool  `json:"overdue"`     // 仅返回已逾期的记录
+}
+
+// 获取借贷记录接口
+func GetLoanListHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(GetLoanListRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	items, _, err := loanItems(userID, req.PersonID)
+	if err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	list := []LoanItem{}
+	for _, item := range items {
+		if (req.Outstanding || req.Overdue) && item.Outstanding <= 0 {
+			continue
+		}
+		if req.Overdue && !item.Overdue {
+			continue
+		}
+		list = append(list, item)
+	}
+	// 返回信息
+	response.Ok(c, gin.H{
+		"list": list,
+	})
+}
+
+// 存储借贷记录请求体
+type StoreLoanRequest struct {
+	ID           uint    `json:"id"`                             // ID，修改透传，添加为0
+	PersonID     uint    `json:"person_id" binding:"required"`   // 联系人ID
+	Direction    uint8   `json:"direction" binding:"required"`   // 方向（1借出、2借入）
+	Amount       float64 `json:"amount" binding:"required"`      // 借贷金额
+	LoanedDate   string  `json:"loaned_date" binding:"required"` // 借贷日期
+	DueDate      string  `json:"due_date"`                       // 约定还款日期，为空时未约定
+	BillRecordID uint    `json:"bill_record_id"`                 // 关联的转账账单ID
+	Remark       string  `json:"remark"`                         // 备注
+}
+
+// 存储借贷记录接口
+func StoreLoanHandler(c *gin.Context) {
+	layout := "2006-01-02"
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(StoreLoanRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	if req.Amount <= 0 || (req.Direction != uint8(model.LoanLent) && req.Direction != uint8(model.LoanBorrowed)) {
+		response.Fail(c, 300013)
+		return
+	}
+	loanedAt, err := time.ParseInLocation(layout, req.LoanedDate, time.Local)
+	if err != nil {
+		response.Fail(c, 100012)
+		return
+	}
+	var dueAt int64
+	if req.DueDate != "" {
+		t, err := time.ParseInLocation(layout, req.DueDate, time.Local)
+		if err != nil {
+			response.Fail(c, 100012)
+			return
+		}
+		dueAt = t.Add(23*time.Hour + 59*time.Minute + 59*time.Second).Unix()
+	}
+	var count int64
+	if err := config.DB.Model(&model.Person{}).Where("id = ? AND user_id = ?", req.PersonID, userID).Count(&count).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	if count == 0 {
+		response.Fail(c, 100053)
+		return
+	}
+	if code := checkOwnedBillRecord(ledgerID, req.BillRecordID); code != 0 {
+		response.Fail(c, code)
+		return
+	}
+	loan := model.Loan{
+		UserID:       userID,
+		PersonID:     req.PersonID,
+		Direction:    req.Direction,
+		Amount:       req.Amount,
+		LoanedAt:     loanedAt.Unix(),
+		DueAt:        dueAt,
+		BillRecordID: req.BillRecordID,
+		Remark:       req.Remark,
+	}
+	if req.ID > 0 {
+		// 修改：金额不能小于已还金额
+		var exist model.Loan
+		if err := config.DB.Where("id = ? AND user_id = ?", req.ID, userID).First(&exist).Error; err != nil {
+			response.Fail(c, 100055)
+			return
+		}
+		var repaid float64
+		if err := config.DB.Model(&model.LoanRepayment{}).Select("COALESCE(SUM(amount),0)").Where("loan_id = ?", exist.ID).Scan(&repaid).Error; err != nil {
+			response.Fail(c, 100001)
+			return
+		}
+		if req.Amount < repaid-0.005 {
+			response.Fail(c, 100056)
+			return
+		}
+		loan.ID = exist.ID
+		loan.CreatedAt = exist.CreatedAt
+		if err := config.DB.Save(&loan).Error; err != nil {
+			response.Fail(c, 100013)
+			return
+		}
+	} else {
+		// 新增
+		if err := config.DB.Create(&loan).Error; err != nil {
+			response.Fail(c, 100013)
+			return
+		}
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"id": loan.ID,
+	})
+}
+
+// 删除借贷记录请求体
+type DeleteLoanRequest struct {
+	ID uint `json:"id" binding:"required"` // ID
+}
+
+// 删除借贷记录接口，同时删除其还款记录（关联的账单保留）
+func DeleteLoanHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(DeleteLoanRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	// 删除数据
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND user_id = ?", req.ID, userID).Delete(&model.Loan{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return tx.Where("loan_id = ?", req.ID).Delete(&model.LoanRepayment{}).Error
+	})
+	if err != nil {
+		response.Fail(c, 100014)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{})
+}
+
+// 获取还款记录请求体
+type GetLoanRepaymentRequest struct {
+	LoanID uint `json:"loan_id" binding:"required"` // 借贷记录ID
+}
+
+// 获取还款记录接口
+func GetLoanRepaymentListHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(GetLoanRepaymentRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	// 获取数据
+	var list []model.LoanRepayment
+	if err := config.DB.Where("user_id = ? AND loan_id = ?", userID, req.LoanID).Order("repaid_at").Find(&list).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	// 返回信息
+	response.Ok(c, gin.H{
+		"list": list,
+	})
+}
+
+// 存储还款记录请求体
+type StoreLoanRepaymentRequest struct {
+	LoanID       uint    `json:"loan_id" binding:"required"` // 借贷记录ID
+	Amount       float64 `json:"amount"`                     // 还款金额，关联账单时为空则使用账单金额
+	RepaidDate   string  `json:"repaid_date"`                // 还款日期，关联账单时为空则使用账单时间
+	BillRecordID uint    `json:"bill_record_id"`             // 关联的还款账单ID
+	Remark       string  `json:"remark"`                     // 备注
+}
+
+// 存储还款记录接口，还款金额不能超过未还金额
+func StoreLoanRepaymentHandler(c *gin.Context) {
+	layout := "2006-01-02"
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(StoreLoanRepaymentRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	var loan model.Loan
+	if err := config.DB.Where("id = ? AND user_id = ?", req.LoanID, userID).First(&loan).Error; err != nil {
+		response.Fail(c, 100055)
+		return
+	}
+	repayment := model.LoanRepayment{
+		UserID:       userID,
+		LoanID:       loan.ID,
+		Amount:       req.Amount,
+		RepaidAt:     time.Now().Unix(),
+		BillRecordID: req.BillRecordID,
+		Remark:       req.Remark,
+	}
+	// 关联账单，补全金额和时间
+	if req.BillRecordID > 0 {
+		var bill model.BillRecord
+		if err := config.DB.Where("id = ? AND ledger_id = ?", req.BillRecordID, ledgerID).First(&bill).Error; err != nil {
+			response.Fail(c, 100051)
+			return
+		}
+		if repayment.Amount == 0 {
+			repayment.Amount = bill.Amount
+		}
+		repayment.RepaidAt = bill.TradeTime
+	}
+	if req.RepaidDate != "" {
+		t, err := time.ParseInLocation(layout, req.RepaidDate, time.Local)
+		if err != nil {
+			response.Fail(c, 100012)
+			return
+		}
+		repayment.RepaidAt = t.Unix()
+	}
+	outstanding, err := service.AddLoanRepayment(config.DB, loan, &repayment)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrLoanOverpaid):
+			response.Fail(c, 100056)
+		case errors.Is(err, service.ErrLoanBillLinked):
+			response.Fail(c, 100081)
+		default:
+			response.Fail(c, 100013)
+		}
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"id":          repayment.ID,
+		"outstanding": helpers.Money(outstanding),
+	})
+}
+
+// 删除还款记录请求体
+type DeleteLoanRepaymentRequest struct {
+	ID uint `json:"id" binding:"required"` // ID
+}
+
+// 删除还款记录接口
+func DeleteLoanRepaymentHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(DeleteLoanRepaymentRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	// 删除数据
+	if err := config.DB.Where("id = ? and user_id = ?", req.ID, userID).Delete(&model.LoanRepayment{}).Error; err != nil {
+		response.Fail(c, 100014)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{})
+}
+
+type PersonBalance struct {
+	PersonID     uint          `json:"person_id"`
+	PersonName   string        `json:"person_name"`
+	Receivable   helpers.Money `json:"receivable"`
+	Payable      helpers.Money `json:"payable"`
+	Net          helpers.Money `json:"net"`
+	OverdueCount int           `json:"overdue_count"`
+}
+
+// 联系人借贷余额接口：每个联系人欠我、我欠对方的未还金额
+func PersonBalanceHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	items, balances, err := loanItems(userID, nil)
+	if err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	names := map[uint]string{}
+	for _, item := range items {
+		names[item.PersonID] = item.PersonName
+	}
+	var receivable, payable float64
+	list := []PersonBalance{}
+	for _, p := range service.SummarizePersonLoans(balances) {
+		receivable += p.Receivable
+		payable += p.Payable
+		list = append(list, PersonBalance{
+			PersonID:     p.PersonID,
+			PersonName:   names[p.PersonID],
+			Receivable:   helpers.Money(p.Receivable),
+			Payable:      helpers.Money(p.Payable),
+			Net:          helpers.Money(p.Net),
+			OverdueCount: p.OverdueCount,
+		})
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"receivable": helpers.Money(receivable),
+		"payable":    helpers.Money(payable),
+		"list":       list,
+	})
+}
+
+// 逾期借贷接口：已过约定还款日仍未还清的记录，按到期时间排序
+func OverdueLoanHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	items, _, err := loanItems(userID, nil)
+	if err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	now := time.Now().Unix()
+	list := []gin.H{}
+	for _, item := range items {
+		if !item.Overdue {
+			continue
+		}
+		list = append(list, gin.H{
+			"loan":         item,
+			"overdue_days": (now - item.DueAt) / 86400,
+		})
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i]["loan"].(LoanItem).DueAt < list[j]["loan"].(LoanItem).DueAt
+	})
+	// 返回成功
+	response.Ok(c, gin.H{
+		"list": list,
+	})
+}
+
+// 查询借贷记录及已还、未还金额，按借贷时间倒序
+func loanItems(userID uint, personID *uint) ([]LoanItem, []service.LoanBalance, error) {
+	var loans []model.Loan
+	db := config.DB.Where("user_id = ?", userID)
+	if personID != nil {
+		db = db.Where("person_id = ?", *personID)
+	}
+	if err := db.Order("loaned_at DESC, id DESC").Find(&loans).Error; err != nil {
+		return nil, nil, err
+	}
+	type loanRepaid struct {
+		LoanID uint
+		Repaid float64
+	}
+	var rows []loanRepaid
+	if err := config.DB.Model(&model.LoanRepayment{}).
+		Select("loan_id, COALESCE(SUM(amount),0) AS repaid").
+		Where("user_id = ?", userID).
+		Group("loan_id").
+		Scan(&rows).Error; err != nil {
+		return nil, nil, err
+	}
+	repaid := map[uint]float64{}
+	for _, r := range rows {
+		repaid[r.LoanID] = r.Repaid
+	}
+	var persons []model.Person
+	if err := config.DB.Unscoped().Where("user_id = ?", userID).Find(&persons).Error; err != nil {
+		return nil, nil, err
+	}
+	names := map[uint]string{}
+	for _, p := range persons {
+		names[p.ID] = p.Name
+	}
+	balances := service.BuildLoanBalances(loans, repaid, time.Now())
+	items := make([]LoanItem, 0, len(balances))
+	for _, b := range balances {
+		items = append(items, LoanItem{
+			ID:           b.Loan.ID,
+			PersonID:     b.Loan.PersonID,
+			PersonName:   names[b.Loan.PersonID],
+			Direction:    b.Loan.Direction,
+			Amount:       helpers.Money(b.Loan.Amount),
+			Repaid:       helpers.Money(b.Repaid),
+			Outstanding:  helpers.Money(b.Outstanding),
+			LoanedAt:     b.Loan.LoanedAt,
+			DueAt:        b.Loan.DueAt,
+			Overdue:      b.Overdue,
+			BillRecordID: b.Loan.BillRecordID,
+			Remark:       b.Loan.Remark,
+		})
+	}
+	return items, balances, nil
+}
+
+// 校验关联的账单属于当前账本，未关联时跳过，失败时返回错误码
+func checkOwnedBillRecord(ledgerID uint, billRecordID uint) int {
+	if billRecordID == 0 {
+		return 0
+	}
+	var count int64
+	if err := config.DB.Model(&model.BillRecord{}).Where("id = ? AND ledger_id = ?", billRecordID, ledgerID).Count(&count).Error; err != nil {
+		return 100001
+	}
+	if count == 0 {
+		return 100051
+	}
+	return 0
+}
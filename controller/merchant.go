@@ -0,0 +1,261 @@
+package controller
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zxc7563598/fintrack-backend/config"
+	"github.com/zxc7563598/fintrack-backend/dto"
+	"github.com/zxc7563598/fintrack-backend/model"
+	"github.com/zxc7563598/fintrack-backend/service"
+	"github.com/zxc7563598/fintrack-backend/utils/response"
+	"gorm.io/gorm"
+)
+
+// 获取商户列表接口
+func GetMerchantListHandler(c *gin.Context) {
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取商户（附带关联的账单数量）
+	var list []dto.MerchantListItem
+	err := config.DB.Model(&model.Merchant{}).
+		Select("merchants.id, merchants.name, COUNT(bill_records.id) AS count").
+		Joins("LEFT JOIN bill_records ON bill_records.merchant_id = merchants.id AND bill_records.deleted_at IS NULL").
+		Where("merchants.ledger_id = ?", ledgerID).
+		Group("merchants.id, merchants.name").
+		Order("merchants.id").
+		Scan(&list).Error
+	if err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	// 获取别名规则
+	var aliases []model.MerchantAlias
+	if err := config.DB.Where("ledger_id = ?", ledgerID).Order("id").Find(&aliases).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	aliasMap := map[uint][]dto.MerchantAliasItem{}
+	for _, a := range aliases {
+		aliasMap[a.MerchantID] = append(aliasMap[a.MerchantID], dto.MerchantAliasItem{
+			MatchType: a.MatchType,
+			Pattern:   a.Pattern,
+		})
+	}
+	for i := range list {
+		list[i].Aliases = aliasMap[list[i].ID]
+		if list[i].Aliases == nil {
+			list[i].Aliases = []dto.MerchantAliasItem{}
+		}
+	}
+	// 返回信息
+	response.Ok(c, gin.H{
+		"list": list,
+	})
+}
+
+// 存储商户请求体
+type StoreMerchantRequest struct {
+	ID      uint                    `json:"id"`                      // ID，修改透传，添加为0
+	Name    string                  `json:"name" binding:"required"` // 商户名称
+	Aliases []dto.MerchantAliasItem `json:"aliases"`                 // 别名及匹配规则
+}
+
+// 存储商户接口，保存后会对已有账单重新进行商户规范化
+func StoreMerchantHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(StoreMerchantRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		response.Fail(c, 300013)
+		return
+	}
+	// 校验匹配规则
+	aliases := make([]model.MerchantAlias, 0, len(req.Aliases))
+	for _, a := range req.Aliases {
+		pattern := strings.TrimSpace(a.Pattern)
+		if pattern == "" {
+			continue
+		}
+		switch model.MatchType(a.MatchType) {
+		case model.MatchTypeExact, model.MatchTypeContains:
+		case model.MatchTypeRegexp:
+			if _, err := regexp.Compile(pattern); err != nil {
+				response.Fail(c, 100031)
+				return
+			}
+		default:
+			response.Fail(c, 100031)
+			return
+		}
+		aliases = append(aliases, model.MerchantAlias{
+			UserID:    userID,
+			LedgerID:  ledgerID,
+			MatchType: a.MatchType,
+			Pattern:   pattern,
+		})
+	}
+	// 存储商户及别名
+	merchant := model.Merchant{
+		UserID:   userID,
+		LedgerID: ledgerID,
+		Name:     name,
+	}
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if req.ID > 0 {
+			result := tx.Model(&model.Merchant{}).
+				Where("id = ? AND ledger_id = ?", req.ID, ledgerID).
+				Omit("user_id").
+				Updates(merchant)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return gorm.ErrRecordNotFound
+			}
+			merchant.ID = req.ID
+		} else if err := tx.Create(&merchant).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("merchant_id = ?", merchant.ID).Delete(&model.MerchantAlias{}).Error; err != nil {
+			return err
+		}
+		if len(aliases) == 0 {
+			return nil
+		}
+		for i := range aliases {
+			aliases[i].MerchantID = merchant.ID
+		}
+		return tx.Create(&aliases).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		response.Fail(c, 100030)
+		return
+	}
+	if err != nil {
+		response.Fail(c, 100013)
+		return
+	}
+	// 重新规范化已有账单
+	affected, code := applyMerchantNormalization(userID, ledgerID)
+	if code != 0 {
+		response.Fail(c, code)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"id":       merchant.ID,
+		"affected": affected,
+	})
+}
+
+// 删除商户请求体
+type DeleteMerchantRequest struct {
+	ID uint `json:"id" binding:"required"` // ID
+}
+
+// 删除商户接口
+func DeleteMerchantHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(DeleteMerchantRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	// 删除商户及其别名
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND ledger_id = ?", req.ID, ledgerID).Delete(&model.Merchant{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		return tx.Where("merchant_id = ?", req.ID).Delete(&model.MerchantAlias{}).Error
+	})
+	if err != nil {
+		response.Fail(c, 100014)
+		return
+	}
+	// 重新规范化已有账单
+	affected, code := applyMerchantNormalization(userID, ledgerID)
+	if code != 0 {
+		response.Fail(c, code)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"affected": affected,
+	})
+}
+
+// 重新规范化已有账单商户接口
+func ApplyMerchantHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	affected, code := applyMerchantNormalization(userID, ledgerID)
+	if code != 0 {
+		response.Fail(c, code)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"affected": affected,
+	})
+}
+
+// 按当前商户规则重新规范化账本全部账单，失败时返回错误码
+func applyMerchantNormalization(userID, ledgerID uint) (int64, int) {
+	normalizer, err := service.NewMerchantNormalizer(config.DB, ledgerID)
+	if err != nil {
+		return 0, 100001
+	}
+	affected, err := normalizer.Apply(config.DB, userID, ledgerID)
+	if err != nil {
+		return 0, 100023
+	}
+	return affected, 0
+}
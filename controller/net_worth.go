@@ -0,0 +1,236 @@
+package controller
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zxc7563598/fintrack-backend/config"
+	"github.com/zxc7563598/fintrack-backend/model"
+	"github.com/zxc7563598/fintrack-backend/service"
+	"github.com/zxc7563598/fintrack-backend/utils/helpers"
+	"github.com/zxc7563598/fintrack-backend/utils/response"
+)
+
+// 获取资产负债项列表接口
+func GetNetWorthItemListHandler(c *gin.Context) {
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取数据
+	var list []model.NetWorthItem
+	if err := config.DB.Where("ledger_id = ?", ledgerID).Order("type, id").Find(&list).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	// 返回信息
+	response.Ok(c, gin.H{
+		"list": list,
+	})
+}
+
+// 存储资产负债项请求体
+type StoreNetWorthItemRequest struct {
+	ID            uint    `json:"id"`                      // ID，修改透传，添加为0
+	Name          string  `json:"name" binding:"required"` // 名称
+	Type          uint8   `json:"type" binding:"required"` // 类型（1资产、2负债）
+	Category      string  `json:"category"`                // 分类（账户、房产、车辆、房贷等）
+	PaymentMethod string  `json:"payment_method"`          // 关联账户（仅资产）
+	Value         float64 `json:"value"`                   // 当前估值（关联账户时为当前余额）
+}
+
+// 存储资产负债项接口，每次保存视为重新估值
+func StoreNetWorthItemHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(StoreNetWorthItemRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" || (req.Type != uint8(model.NetWorthItemAsset) && req.Type != uint8(model.NetWorthItemLiability)) {
+		response.Fail(c, 300013)
+		return
+	}
+	if req.Type == uint8(model.NetWorthItemLiability) {
+		req.PaymentMethod = ""
+	}
+	item := model.NetWorthItem{
+		UserID:        userID,
+		LedgerID:      ledgerID,
+		Name:          name,
+		Type:          req.Type,
+		Category:      strings.TrimSpace(req.Category),
+		PaymentMethod: req.PaymentMethod,
+		Value:         req.Value,
+		ValuedAt:      time.Now().Unix(),
+	}
+	if req.ID > 0 {
+		// 修改
+		var exist model.NetWorthItem
+		if err := config.DB.Where("id = ? AND ledger_id = ?", req.ID, ledgerID).First(&exist).Error; err != nil {
+			response.Fail(c, 100048)
+			return
+		}
+		item.ID = exist.ID
+		item.CreatedAt = exist.CreatedAt
+		if err := config.DB.Save(&item).Error; err != nil {
+			response.Fail(c, 100013)
+			return
+		}
+	} else {
+		// 新增
+		if err := config.DB.Create(&item).Error; err != nil {
+			response.Fail(c, 100013)
+			return
+		}
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"id": item.ID,
+	})
+}
+
+// 删除资产负债项请求体
+type DeleteNetWorthItemRequest struct {
+	ID uint `json:"id" binding:"required"` // ID
+}
+
+// 删除资产负债项接口
+func DeleteNetWorthItemHandler(c *gin.Context) {
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(DeleteNetWorthItemRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	// 删除数据
+	if err := config.DB.Where("id = ? and ledger_id = ?", req.ID, ledgerID).Delete(&model.NetWorthItem{}).Error; err != nil {
+		response.Fail(c, 100014)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{})
+}
+
+// 当前净资产接口
+func NetWorthHandler(c *gin.Context) {
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 计算数据
+	snapshot, err := service.ComputeNetWorth(config.DB, ledgerID, time.Now())
+	if err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"total_assets":      helpers.Money(snapshot.TotalAssets),
+		"total_liabilities": helpers.Money(snapshot.TotalLiabilities),
+		"net_worth":         helpers.Money(snapshot.NetWorth),
+		"items":             snapshot.Items,
+	})
+}
+
+// 生成净资产快照接口，覆盖当月已有快照
+func TakeNetWorthSnapshotHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 生成快照
+	snapshot, err := service.TakeNetWorthSnapshot(config.DB, userID, ledgerID, time.Now())
+	if err != nil {
+		response.Fail(c, 100023)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"id":    snapshot.ID,
+		"month": snapshot.Month,
+	})
+}
+
+// 净资产趋势请求体
+type NetWorthTrendRequest struct {
+	Months int `json:"months"` // 最近多少个月，默认12
+}
+
+// 净资产趋势接口：按月返回净资产、总资产及总负债，没有快照的月份为空
+func NetWorthTrendHandler(c *gin.Context) {
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(NetWorthTrendRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	count := 12
+	if req.Months > 0 && req.Months <= 120 {
+		count = req.Months
+	}
+	// 确定月份列表
+	layoutMonth := "2006-01"
+	monthStart := helpers.StartOfMonth(time.Now())
+	months := []string{}
+	for i := count - 1; i >= 0; i-- {
+		months = append(months, monthStart.AddDate(0, -i, 0).Format(layoutMonth))
+	}
+	// 获取快照
+	var snapshots []model.NetWorthSnapshot
+	if err := config.DB.Where("ledger_id = ? AND month >= ?", ledgerID, months[0]).Find(&snapshots).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	byMonth := map[string]model.NetWorthSnapshot{}
+	for _, s := range snapshots {
+		byMonth[s.Month] = s
+	}
+	netWorth := []*helpers.Money{}
+	assets := []*helpers.Money{}
+	liabilities := []*helpers.Money{}
+	for _, m := range months {
+		s, ok := byMonth[m]
+		if !ok {
+			netWorth = append(netWorth, nil)
+			assets = append(assets, nil)
+			liabilities = append(liabilities, nil)
+			continue
+		}
+		n, a, l := helpers.Money(s.NetWorth), helpers.Money(s.TotalAssets), helpers.Money(s.TotalLiabilities)
+		netWorth = append(netWorth, &n)
+		assets = append(assets, &a)
+		liabilities = append(liabilities, &l)
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"months":            months,
+		"net_worth":         netWorth,
+		"total_assets":      assets,
+		"total_liabilities": liabilities,
+	})
+}
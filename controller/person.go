@@ -0,0 +1,153 @@
+package controller
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zxc7563598/fintrack-backend/config"
+	"github.com/zxc7563598/fintrack-backend/model"
+	"github.com/zxc7563598/fintrack-backend/utils/response"
+)
+
+// 获取联系人列表接口
+func GetPersonListHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取数据
+	var list []model.Person
+	if err := config.DB.Where("user_id = ?", userID).Order("id").Find(&list).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	// 返回信息
+	response.Ok(c, gin.H{
+		"list": list,
+	})
+}
+
+// 存储联系人请求体
+type StorePersonRequest struct {
+	ID     uint   `json:"id"`                      // ID，修改透传，添加为0
+	Name   string `json:"name" binding:"required"` // 姓名
+	Remark string `json:"remark"`                  // 备注
+}
+
+// 存储联系人接口
+func StorePersonHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(StorePersonRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		response.Fail(c, 300013)
+		return
+	}
+	// 存储数据
+	person := model.Person{
+		UserID: userID,
+		Name:   name,
+		Remark: req.Remark,
+	}
+	if req.ID > 0 {
+		// 修改
+		result := config.DB.Model(&model.Person{}).
+			Where("id = ? AND user_id = ?", req.ID, userID).
+			Updates(map[string]any{
+				"name":   person.Name,
+				"remark": person.Remark,
+			})
+		if result.Error != nil {
+			response.Fail(c, 100013)
+			return
+		}
+		if result.RowsAffected == 0 {
+			response.Fail(c, 100053)
+			return
+		}
+		person.ID = req.ID
+	} else {
+		// 新增
+		if err := config.DB.Create(&person).Error; err != nil {
+			response.Fail(c, 100013)
+			return
+		}
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"id": person.ID,
+	})
+}
+
+// 删除联系人请求体
+type DeletePersonRequest struct {
+	ID uint `json:"id" binding:"required"` // ID
+}
+
+// 删除联系人接口，存在借贷记录或属于分摊组时不允许删除
+func DeletePersonHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(DeletePersonRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	var count int64
+	if err := config.DB.Model(&model.Loan{}).Where("user_id = ? AND person_id = ?", userID, req.ID).Count(&count).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	if count == 0 {
+		if err := config.DB.Model(&model.SplitGroupMember{}).
+			Joins("JOIN split_groups ON split_groups.id = split_group_members.group_id AND split_groups.deleted_at IS NULL").
+			Where("split_groups.user_id = ? AND split_group_members.person_id = ?", userID, req.ID).
+			Count(&count).Error; err != nil {
+			response.Fail(c, 100001)
+			return
+		}
+	}
+	if count > 0 {
+		response.Fail(c, 100054)
+		return
+	}
+	// 删除数据
+	if err := config.DB.Where("id = ? and user_id = ?", req.ID, userID).Delete(&model.Person{}).Error; err != nil {
+		response.Fail(c, 100014)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{})
+}
@@ -0,0 +1,415 @@
+package controller
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zxc7563598/fintrack-backend/config"
+	"github.com/zxc7563598/fintrack-backend/dto"
+	"github.com/zxc7563598/fintrack-backend/model"
+	"github.com/zxc7563598/fintrack-backend/service"
+	"github.com/zxc7563598/fintrack-backend/utils/response"
+	"gorm.io/gorm"
+)
+
+// 获取周期记账模板列表接口
+func GetRecurringBillListHandler(c *gin.Context) {
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取数据
+	var list []model.RecurringBill
+	if err := config.DB.Where("ledger_id = ?", ledgerID).Order("id").Find(&list).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	// 返回信息
+	response.Ok(c, gin.H{
+		"list": list,
+	})
+}
+
+// 存储周期记账模板请求体
+type StoreRecurringBillRequest struct {
+	ID            uint    `json:"id"`                                // ID，修改透传，添加为0
+	Name          string  `json:"name" binding:"required"`           // 模板名称
+	Platform      uint8   `json:"platform"`                          // 交易平台
+	IncomeType    uint8   `json:"income_type" binding:"required"`    // 收支类型
+	TradeType     string  `json:"trade_type" binding:"required"`     // 交易类型
+	ProductName   string  `json:"product_name"`                      // 交易名称
+	Counterparty  string  `json:"counterparty"`                      // 商户名称
+	PaymentMethod string  `json:"payment_method" binding:"required"` // 支付方式
+	Amount        float64 `json:"amount" binding:"required"`         // 金额
+	Remark        string  `json:"remark"`                            // 备注
+	Frequency     uint8   `json:"frequency" binding:"required"`      // 重复频率（1每天、2每周、3每月、4每年）
+	Interval      int     `json:"interval"`                          // 重复间隔，默认为1
+	DayOfMonth    int     `json:"day_of_month"`                      // 每月第几日，按月重复时有效
+	StartTime     string  `json:"start_time" binding:"required"`     // 首次发生时间
+	EndDate       string  `json:"end_date"`                          // 结束日期，为空时不结束
+	Enabled       bool    `json:"enabled"`                           // 是否启用
+}
+
+// 存储周期记账模板接口，保存后会立即生成已到期的账单
+func StoreRecurringBillHandler(c *gin.Context) {
+	layout := "2006-01-02 15:04:05"
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(StoreRecurringBillRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	// 校验参数
+	if strings.TrimSpace(req.Name) == "" || req.Amount <= 0 {
+		response.Fail(c, 300013)
+		return
+	}
+	if req.Frequency < uint8(model.FrequencyDaily) || req.Frequency > uint8(model.FrequencyYearly) || req.Interval < 0 || req.DayOfMonth < 0 || req.DayOfMonth > 31 {
+		response.Fail(c, 100036)
+		return
+	}
+	if req.Interval == 0 {
+		req.Interval = 1
+	}
+	start, err := time.ParseInLocation(layout, req.StartTime, time.Local)
+	if err != nil {
+		response.Fail(c, 100012)
+		return
+	}
+	var endAt int64
+	if req.EndDate != "" {
+		end, err := time.ParseInLocation("2006-01-02", req.EndDate, time.Local)
+		if err != nil {
+			response.Fail(c, 100012)
+			return
+		}
+		endAt = end.Add(23*time.Hour + 59*time.Minute + 59*time.Second).Unix()
+		if endAt < start.Unix() {
+			response.Fail(c, 100036)
+			return
+		}
+	}
+	tpl := model.RecurringBill{
+		UserID:        userID,
+		LedgerID:      ledgerID,
+		Name:          strings.TrimSpace(req.Name),
+		Platform:      req.Platform,
+		IncomeType:    req.IncomeType,
+		TradeType:     req.TradeType,
+		ProductName:   req.ProductName,
+		Counterparty:  req.Counterparty,
+		PaymentMethod: req.PaymentMethod,
+		Amount:        req.Amount,
+		Remark:        req.Remark,
+		Frequency:     req.Frequency,
+		Interval:      req.Interval,
+		DayOfMonth:    req.DayOfMonth,
+		StartAt:       start.Unix(),
+		EndAt:         endAt,
+		Enabled:       req.Enabled,
+	}
+	if req.ID > 0 {
+		// 修改：从最后一次已处理的期次之后继续生成，避免重复生成
+		var exist model.RecurringBill
+		if err := config.DB.Where("id = ? AND ledger_id = ?", req.ID, ledgerID).First(&exist).Error; err != nil {
+			response.Fail(c, 100037)
+			return
+		}
+		var lastAt int64
+		if err := config.DB.Model(&model.RecurringBillOccurrence{}).
+			Select("COALESCE(MAX(scheduled_at),0)").
+			Where("recurring_bill_id = ? AND status <> ?", exist.ID, model.OccurrenceStatusEdited).
+			Scan(&lastAt).Error; err != nil {
+			response.Fail(c, 100001)
+			return
+		}
+		// 模板保留在创建时所属的账本
+		tpl.ID = exist.ID
+		tpl.LedgerID = exist.LedgerID
+		tpl.CreatedAt = exist.CreatedAt
+		if lastAt > 0 {
+			tpl.NextIndex = service.RecurringIndexAfter(&tpl, lastAt)
+		}
+	}
+	next := service.RecurringOccurrence(&tpl, tpl.NextIndex)
+	tpl.NextRunAt = next.Unix()
+	if service.RecurringEnded(&tpl, next) {
+		tpl.NextRunAt = 0
+	}
+	if err := config.DB.Save(&tpl).Error; err != nil {
+		response.Fail(c, 100013)
+		return
+	}
+	// 生成已到期的账单
+	generated := 0
+	if tpl.Enabled {
+		generated, err = service.MaterializeRecurringBill(config.DB, &tpl, time.Now())
+		if errors.Is(err, service.ErrRecurringLedgerDenied) {
+			response.Fail(c, 300015)
+			return
+		}
+		if err != nil {
+			response.Fail(c, 100023)
+			return
+		}
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"id":        tpl.ID,
+		"generated": generated,
+	})
+}
+
+// 删除周期记账模板请求体
+type DeleteRecurringBillRequest struct {
+	ID uint `json:"id" binding:"required"` // ID
+}
+
+// 删除周期记账模板接口（已生成的账单保留）
+func DeleteRecurringBillHandler(c *gin.Context) {
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(DeleteRecurringBillRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	// 删除数据
+	if err := config.DB.Where("id = ? AND ledger_id = ?", req.ID, ledgerID).Delete(&model.RecurringBill{}).Error; err != nil {
+		response.Fail(c, 100014)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{})
+}
+
+// 获取周期记账期次请求体
+type GetRecurringOccurrenceRequest struct {
+	ID    uint `json:"id" binding:"required"` // 模板ID
+	Count int  `json:"count"`                 // 返回的后续期数，默认12
+}
+
+// 获取周期记账期次接口，返回已处理的历史期次及后续待生成的期次
+func GetRecurringOccurrenceHandler(c *gin.Context) {
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(GetRecurringOccurrenceRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	count := 12
+	if req.Count > 0 && req.Count <= 120 {
+		count = req.Count
+	}
+	var tpl model.RecurringBill
+	if err := config.DB.Where("id = ? AND ledger_id = ?", req.ID, ledgerID).First(&tpl).Error; err != nil {
+		response.Fail(c, 100037)
+		return
+	}
+	var occurrences []model.RecurringBillOccurrence
+	if err := config.DB.Where("recurring_bill_id = ?", tpl.ID).Order("scheduled_at DESC").Find(&occurrences).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	nextAt := service.RecurringOccurrence(&tpl, tpl.NextIndex).Unix()
+	occMap := map[int64]model.RecurringBillOccurrence{}
+	history := []dto.RecurringOccurrenceItem{}
+	for _, o := range occurrences {
+		occMap[o.ScheduledAt] = o
+		if o.ScheduledAt >= nextAt {
+			continue
+		}
+		history = append(history, dto.RecurringOccurrenceItem{
+			ScheduledAt:  o.ScheduledAt,
+			Amount:       o.Amount,
+			Remark:       o.Remark,
+			Status:       o.Status,
+			BillRecordID: o.BillRecordID,
+		})
+	}
+	// 后续期次，合并单独修改或跳过的设置
+	upcoming := []dto.RecurringOccurrenceItem{}
+	for n := tpl.NextIndex; len(upcoming) < count; n++ {
+		t := service.RecurringOccurrence(&tpl, n)
+		if service.RecurringEnded(&tpl, t) {
+			break
+		}
+		item := dto.RecurringOccurrenceItem{
+			ScheduledAt: t.Unix(),
+			Amount:      tpl.Amount,
+			Remark:      tpl.Remark,
+		}
+		if o, ok := occMap[t.Unix()]; ok {
+			item.Status = o.Status
+			if o.Amount > 0 {
+				item.Amount = o.Amount
+			}
+			if o.Remark != "" {
+				item.Remark = o.Remark
+			}
+		}
+		upcoming = append(upcoming, item)
+	}
+	// 返回信息
+	response.Ok(c, gin.H{
+		"history":  history,
+		"upcoming": upcoming,
+	})
+}
+
+// 周期记账单期操作请求体
+type RecurringOccurrenceRequest struct {
+	ID          uint    `json:"id" binding:"required"`           // 模板ID
+	ScheduledAt int64   `json:"scheduled_at" binding:"required"` // 期次的计划发生时间
+	Amount      float64 `json:"amount"`                          // 单独修改的金额，跳过时忽略
+	Remark      string  `json:"remark"`                          // 单独修改的备注，跳过时忽略
+}
+
+// 跳过周期记账单期接口，已生成的账单会被删除
+func SkipRecurringOccurrenceHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(RecurringOccurrenceRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	_, occ, code := findRecurringOccurrence(userID, ledgerID, req.ID, req.ScheduledAt)
+	if code != 0 {
+		response.Fail(c, code)
+		return
+	}
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if occ.Status == uint8(model.OccurrenceStatusGenerated) && occ.BillRecordID > 0 {
+			befores, err := service.SnapshotBillRecords(tx.Where("ledger_id = ? AND deleted_at IS NULL", ledgerID), []uint{occ.BillRecordID})
+			if err != nil {
+				return err
+			}
+			if err := tx.Where("id = ? AND ledger_id = ?", occ.BillRecordID, ledgerID).Delete(&model.BillRecord{}).Error; err != nil {
+				return err
+			}
+			if err := service.RecordBillChanges(tx, userID, model.BillSourceManual, model.BillActionDelete, befores); err != nil {
+				return err
+			}
+		}
+		occ.Status = uint8(model.OccurrenceStatusSkipped)
+		occ.BillRecordID = 0
+		return tx.Save(&occ).Error
+	})
+	if err != nil {
+		response.Fail(c, 100023)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{})
+}
+
+// 单独修改周期记账单期接口，已生成的账单会同步修改
+func StoreRecurringOccurrenceHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(RecurringOccurrenceRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	if req.Amount < 0 {
+		response.Fail(c, 300013)
+		return
+	}
+	tpl, occ, code := findRecurringOccurrence(userID, ledgerID, req.ID, req.ScheduledAt)
+	if code != 0 {
+		response.Fail(c, code)
+		return
+	}
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		occ.Amount = req.Amount
+		occ.Remark = req.Remark
+		switch {
+		case occ.Status == uint8(model.OccurrenceStatusGenerated):
+			updates := map[string]any{"remark": req.Remark}
+			if req.Amount > 0 {
+				updates["amount"] = req.Amount
+			}
+			if _, err := service.UpdateBillRecords(tx, userID, model.BillSourceManual, updates,
+				"id = ? AND ledger_id = ?", occ.BillRecordID, ledgerID); err != nil {
+				return err
+			}
+		case service.RecurringOccurrenceDue(&tpl, occ.ScheduledAt):
+			// 已跳过的历史期次，修改即恢复该期，立即补生成账单
+			return service.GenerateRecurringOccurrence(tx, &tpl, &occ)
+		default:
+			occ.Status = uint8(model.OccurrenceStatusEdited)
+		}
+		return tx.Save(&occ).Error
+	})
+	if errors.Is(err, service.ErrRecurringLedgerDenied) {
+		response.Fail(c, 300015)
+		return
+	}
+	if err != nil {
+		response.Fail(c, 100023)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{})
+}
+
+// 获取当前账本中的模板及其指定期次（尚无记录时初始化），失败时返回错误码
+func findRecurringOccurrence(userID uint, ledgerID uint, recurringID uint, scheduledAt int64) (model.RecurringBill, model.RecurringBillOccurrence, int) {
+	var occ model.RecurringBillOccurrence
+	var tpl model.RecurringBill
+	if err := config.DB.Where("id = ? AND ledger_id = ?", recurringID, ledgerID).First(&tpl).Error; err != nil {
+		return tpl, occ, 100037
+	}
+	if !service.IsRecurringOccurrence(&tpl, scheduledAt) {
+		return tpl, occ, 100038
+	}
+	if err := config.DB.Where("recurring_bill_id = ? AND scheduled_at = ?", tpl.ID, scheduledAt).Limit(1).Find(&occ).Error; err != nil {
+		return tpl, occ, 100001
+	}
+	occ.UserID = userID
+	occ.RecurringBillID = tpl.ID
+	occ.ScheduledAt = scheduledAt
+	return tpl, occ, 0
+}
@@ -0,0 +1,586 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/csv"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zxc7563598/fintrack-backend/config"
+	"github.com/zxc7563598/fintrack-backend/dto"
+	"github.com/zxc7563598/fintrack-backend/model"
+	"github.com/zxc7563598/fintrack-backend/service"
+	"github.com/zxc7563598/fintrack-backend/utils/helpers"
+	"github.com/zxc7563598/fintrack-backend/utils/response"
+	"gorm.io/gorm"
+)
+
+type ReimbursementClaimItem struct {
+	model.ReimbursementClaim
+	ItemCount int64         `json:"item_count"`
+	Total     helpers.Money `json:"total"`
+}
+
+// 获取报销单列表接口
+func GetReimbursementClaimListHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取数据
+	var claims []model.ReimbursementClaim
+	if err := config.DB.Where("user_id = ?", userID).Order("id DESC").Find(&claims).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	totals, err := service.ReimbursementClaimTotals(config.DB, userID)
+	if err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	type claimCount struct {
+		ClaimID uint
+		Count   int64
+	}
+	var counts []claimCount
+	if err := config.DB.Model(&model.ReimbursementItem{}).
+		Select("claim_id, COUNT(*) AS count").
+		Where("user_id = ? AND claim_id > 0", userID).
+		Group("claim_id").
+		Scan(&counts).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	countMap := map[uint]int64{}
+	for _, r := range counts {
+		countMap[r.ClaimID] = r.Count
+	}
+	list := make([]ReimbursementClaimItem, 0, len(claims))
+	for _, claim := range claims {
+		list = append(list, ReimbursementClaimItem{
+			ReimbursementClaim: claim,
+			ItemCount:          countMap[claim.ID],
+			Total:              helpers.Money(totals[claim.ID]),
+		})
+	}
+	// 返回信息
+	response.Ok(c, gin.H{
+		"list": list,
+	})
+}
+
+// 存储报销单请求体
+type StoreReimbursementClaimRequest struct {
+	ID     uint   `json:"id"`                       // ID，修改透传，添加为0
+	Title  string `json:"title" binding:"required"` // 报销单标题
+	Remark string `json:"remark"`                   // 备注
+}
+
+// 存储报销单接口，状态通过状态接口修改
+func StoreReimbursementClaimHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(StoreReimbursementClaimRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		response.Fail(c, 300013)
+		return
+	}
+	claim := model.ReimbursementClaim{
+		UserID: userID,
+		Title:  title,
+		Status: uint8(model.ReimbursementDraft),
+		Remark: req.Remark,
+	}
+	if req.ID > 0 {
+		// 修改
+		result := config.DB.Model(&model.ReimbursementClaim{}).
+			Where("id = ? AND user_id = ?", req.ID, userID).
+			Updates(map[string]any{
+				"title":  claim.Title,
+				"remark": claim.Remark,
+			})
+		if result.Error != nil {
+			response.Fail(c, 100013)
+			return
+		}
+		if result.RowsAffected == 0 {
+			response.Fail(c, 100057)
+			return
+		}
+		claim.ID = req.ID
+	} else {
+		// 新增
+		if err := config.DB.Create(&claim).Error; err != nil {
+			response.Fail(c, 100013)
+			return
+		}
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"id": claim.ID,
+	})
+}
+
+// 删除报销单请求体
+type DeleteReimbursementClaimRequest struct {
+	ID uint `json:"id" binding:"required"` // ID
+}
+
+// 删除报销单接口，单内账单仍保留可报销标记
+func DeleteReimbursementClaimHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(DeleteReimbursementClaimRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	// 删除数据
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND user_id = ?", req.ID, userID).Delete(&model.ReimbursementClaim{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return tx.Model(&model.ReimbursementItem{}).Where("user_id = ? AND claim_id = ?", userID, req.ID).Update("claim_id", 0).Error
+	})
+	if err != nil {
+		response.Fail(c, 100014)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{})
+}
+
+// 修改报销单状态请求体
+type UpdateReimbursementStatusRequest struct {
+	ID                 uint   `json:"id" binding:"required"`     // 报销单ID
+	Status             uint8  `json:"status" binding:"required"` // 状态（1未提交、2已提交、3已批准、4已到账）
+	IncomeBillRecordID uint   `json:"income_bill_record_id"`     // 报销收入账单ID，仅标记已到账时有效，为空时不关联收入
+	Date               string `json:"date"`                      // 状态发生日期，为空时为当前时间
+}
+
+// 修改报销单状态接口，提交或批准后会自动尝试匹配报销收入
+func UpdateReimbursementStatusHandler(c *gin.Context) {
+	layout := "2006-01-02"
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(UpdateReimbursementStatusRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	if req.Status < uint8(model.ReimbursementDraft) || req.Status > uint8(model.ReimbursementPaid) {
+		response.Fail(c, 300013)
+		return
+	}
+	at := time.Now()
+	if req.Date != "" {
+		t, err := time.ParseInLocation(layout, req.Date, time.Local)
+		if err != nil {
+			response.Fail(c, 100012)
+			return
+		}
+		at = t
+	}
+	var claim model.ReimbursementClaim
+	if err := config.DB.Where("id = ? AND user_id = ?", req.ID, userID).First(&claim).Error; err != nil {
+		response.Fail(c, 100057)
+		return
+	}
+	var count int64
+	if err := config.DB.Model(&model.ReimbursementItem{}).Where("user_id = ? AND claim_id = ?", userID, claim.ID).Count(&count).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	if req.Status != uint8(model.ReimbursementDraft) && count == 0 {
+		response.Fail(c, 100060)
+		return
+	}
+	// 回退状态时清除之后阶段的时间及匹配的收入
+	updates := map[string]any{"status": req.Status}
+	switch model.ReimbursementStatus(req.Status) {
+	case model.ReimbursementDraft:
+		updates["submitted_at"], updates["approved_at"] = 0, 0
+		updates["paid_at"], updates["income_bill_record_id"] = 0, 0
+	case model.ReimbursementSubmitted:
+		if claim.SubmittedAt == 0 || req.Date != "" {
+			updates["submitted_at"] = at.Unix()
+		}
+		updates["approved_at"], updates["paid_at"], updates["income_bill_record_id"] = 0, 0, 0
+	case model.ReimbursementApproved:
+		if claim.SubmittedAt == 0 {
+			updates["submitted_at"] = at.Unix()
+		}
+		updates["approved_at"] = at.Unix()
+		updates["paid_at"], updates["income_bill_record_id"] = 0, 0
+	case model.ReimbursementPaid:
+		if claim.SubmittedAt == 0 {
+			updates["submitted_at"] = at.Unix()
+		}
+		updates["paid_at"] = at.Unix()
+		updates["income_bill_record_id"] = 0
+		if req.IncomeBillRecordID > 0 {
+			var income model.BillRecord
+			if err := config.DB.Where("id = ? AND ledger_id = ? AND income_type = ?", req.IncomeBillRecordID, ledgerID, model.IncomeTypeIncome).First(&income).Error; err != nil {
+				response.Fail(c, 100051)
+				return
+			}
+			updates["paid_at"] = income.TradeTime
+			updates["income_bill_record_id"] = income.ID
+		}
+	}
+	if err := config.DB.Model(&claim).Updates(updates).Error; err != nil {
+		response.Fail(c, 100023)
+		return
+	}
+	// 自动匹配报销收入
+	matched := 0
+	if req.Status == uint8(model.ReimbursementSubmitted) || req.Status == uint8(model.ReimbursementApproved) {
+		n, err := service.MatchReimbursements(config.DB, userID, ledgerID)
+		if err != nil {
+			response.Fail(c, 100023)
+			return
+		}
+		matched = n
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"matched": matched,
+	})
+}
+
+// 自动匹配报销收入接口：为已提交、已批准的报销单查找金额一致的报销到账收入
+func MatchReimbursementHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 匹配数据
+	matched, err := service.MatchReimbursements(config.DB, userID, ledgerID)
+	if err != nil {
+		response.Fail(c, 100023)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"matched": matched,
+	})
+}
+
+type ReimbursableBillItem struct {
+	dto.BillListItem
+	Counterparty string `json:"counterparty"`
+	ClaimID      uint   `json:"claim_id"`
+}
+
+// 获取可报销账单请求体
+type GetReimbursableListRequest struct {
+	ClaimID *uint `json:"claim_id"` // 报销单ID，为空时返回全部，为0时返回尚未归入报销单的账单
+}
+
+// 获取可报销账单接口
+func GetReimbursableListHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(GetReimbursableListRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	// 获取数据
+	list, err := reimbursableBills(userID, req.ClaimID)
+	if err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	// 返回信息
+	response.Ok(c, gin.H{
+		"list": list,
+	})
+}
+
+// 标记可报销账单请求体
+type MarkReimbursableRequest struct {
+	BillRecordIDs []uint `json:"bill_record_ids" binding:"required"` // 账单ID列表
+	ClaimID       uint   `json:"claim_id"`                           // 归入的报销单ID，0为暂不归入
+}
+
+// 标记可报销账单接口，已标记的账单会移动到指定报销单
+func MarkReimbursableHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(MarkReimbursableRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	if len(req.BillRecordIDs) == 0 {
+		response.Fail(c, 300013)
+		return
+	}
+	if req.ClaimID > 0 {
+		var claim model.ReimbursementClaim
+		if err := config.DB.Where("id = ? AND user_id = ?", req.ClaimID, userID).First(&claim).Error; err != nil {
+			response.Fail(c, 100057)
+			return
+		}
+		if claim.Status == uint8(model.ReimbursementPaid) {
+			response.Fail(c, 100059)
+			return
+		}
+	}
+	// 仅本人的支出账单可标记
+	var count int64
+	if err := config.DB.Model(&model.BillRecord{}).
+		Where("ledger_id = ? AND id IN ? AND income_type = ?", ledgerID, req.BillRecordIDs, model.IncomeTypeExpense).
+		Count(&count).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	if count != int64(len(uniqueUints(req.BillRecordIDs))) {
+		response.Fail(c, 100058)
+		return
+	}
+	if code := checkPaidReimbursementItems(userID, req.BillRecordIDs); code != 0 {
+		response.Fail(c, code)
+		return
+	}
+	// 存储数据
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		for _, id := range uniqueUints(req.BillRecordIDs) {
+			var item model.ReimbursementItem
+			if err := tx.Where(model.ReimbursementItem{BillRecordID: id}).
+				Assign(map[string]any{"user_id": userID, "claim_id": req.ClaimID}).
+				FirstOrCreate(&item).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		response.Fail(c, 100023)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{})
+}
+
+// 取消可报销标记请求体
+type UnmarkReimbursableRequest struct {
+	BillRecordIDs []uint `json:"bill_record_ids" binding:"required"` // 账单ID列表
+}
+
+// 取消可报销标记接口
+func UnmarkReimbursableHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(UnmarkReimbursableRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	if code := checkPaidReimbursementItems(userID, req.BillRecordIDs); code != 0 {
+		response.Fail(c, code)
+		return
+	}
+	// 删除数据
+	if err := config.DB.Where("user_id = ? AND bill_record_id IN ?", userID, req.BillRecordIDs).Delete(&model.ReimbursementItem{}).Error; err != nil {
+		response.Fail(c, 100014)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{})
+}
+
+// 导出报销单请求体
+type ExportReimbursementClaimRequest struct {
+	ID uint `json:"id" binding:"required"` // 报销单ID
+}
+
+// 导出报销单明细接口
+func ExportReimbursementClaimHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(ExportReimbursementClaimRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	var claim model.ReimbursementClaim
+	if err := config.DB.Where("id = ? AND user_id = ?", req.ID, userID).First(&claim).Error; err != nil {
+		response.Fail(c, 100057)
+		return
+	}
+	records, err := reimbursableBills(userID, &claim.ID)
+	if err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	// 新建 CSV writer
+	var buf bytes.Buffer
+	buf.WriteString("\xEF\xBB\xBF")
+	writer := csv.NewWriter(&buf)
+	// 写数据
+	writer.Write([]string{"报销单", claim.Title})
+	writer.Write([]string{"序号", "交易时间", "交易类型", "商品名称", "对方", "支付方式", "金额", "备注"})
+	var total float64
+	for i, r := range records {
+		total += r.Amount
+		writer.Write([]string{
+			strconv.Itoa(i + 1),
+			time.Unix(r.TradeTime, 0).Format("2006-01-02 15:04:05"),
+			r.TradeType,
+			r.ProductName,
+			r.Counterparty,
+			r.PaymentMethod,
+			strconv.FormatFloat(r.Amount, 'f', 2, 64),
+			r.Remark,
+		})
+	}
+	writer.Write([]string{"合计", "", "", "", "", "", strconv.FormatFloat(total, 'f', 2, 64), ""})
+	writer.Flush()
+	// 返回字节流
+	c.Data(http.StatusOK, "text/csv", buf.Bytes())
+}
+
+// 查询可报销账单，claimID 为空时返回全部，按交易时间排序
+func reimbursableBills(userID uint, claimID *uint) ([]ReimbursableBillItem, error) {
+	db := config.DB.Model(&model.BillRecord{}).
+		Select("bill_records.id, bill_records.trade_time, bill_records.trade_type, bill_records.amount, bill_records.payment_method, bill_records.product_name, bill_records.income_type, bill_records.remark, bill_records.trade_status_type, bill_records.counterparty, reimbursement_items.claim_id").
+		Joins("JOIN reimbursement_items ON reimbursement_items.bill_record_id = bill_records.id").
+		Where("bill_records.user_id = ?", userID)
+	if claimID != nil {
+		db = db.Where("reimbursement_items.claim_id = ?", *claimID)
+	}
+	list := []ReimbursableBillItem{}
+	if err := db.Order("bill_records.trade_time").Scan(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// 校验账单不在已到账的报销单中，失败时返回错误码
+func checkPaidReimbursementItems(userID uint, billRecordIDs []uint) int {
+	var count int64
+	if err := config.DB.Model(&model.ReimbursementItem{}).
+		Joins("JOIN reimbursement_claims ON reimbursement_claims.id = reimbursement_items.claim_id AND reimbursement_claims.deleted_at IS NULL").
+		Where("reimbursement_items.user_id = ? AND reimbursement_items.bill_record_id IN ? AND reimbursement_claims.status = ?", userID, billRecordIDs, model.ReimbursementPaid).
+		Count(&count).Error; err != nil {
+		return 100001
+	}
+	if count > 0 {
+		return 100059
+	}
+	return 0
+}
+
+// 去除重复的ID
+func uniqueUints(ids []uint) []uint {
+	seen := map[uint]bool{}
+	result := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
@@ -0,0 +1,312 @@
+package controller
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zxc7563598/fintrack-backend/config"
+	"github.com/zxc7563598/fintrack-backend/dto"
+	"github.com/zxc7563598/fintrack-backend/model"
+	"github.com/zxc7563598/fintrack-backend/service"
+	"github.com/zxc7563598/fintrack-backend/utils/response"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 获取账单规则列表接口
+func GetBillRuleListHandler(c *gin.Context) {
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取数据
+	var list []model.BillRule
+	if err := config.DB.Where("ledger_id = ?", ledgerID).Order("priority, id").Find(&list).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	// 返回信息
+	response.Ok(c, gin.H{
+		"list": list,
+	})
+}
+
+// 存储账单规则请求体
+type StoreBillRuleRequest struct {
+	ID         uint                    `json:"id"`                      // ID，修改透传，添加为0
+	Name       string                  `json:"name" binding:"required"` // 规则名称
+	Priority   int                     `json:"priority"`                // 优先级，数值越小越先执行
+	Enabled    bool                    `json:"enabled"`                 // 是否启用
+	Conditions model.BillRuleCondition `json:"conditions"`              // 匹配条件
+	Actions    model.BillRuleAction    `json:"actions"`                 // 执行动作
+}
+
+// 存储账单规则接口
+func StoreBillRuleHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(StoreBillRuleRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		response.Fail(c, 300013)
+		return
+	}
+	// 校验条件与动作
+	if !service.ValidBillRuleCondition(req.Conditions) {
+		response.Fail(c, 100033)
+		return
+	}
+	action := req.Actions
+	if action.IncomeType > uint8(model.IncomeTypeUnknown) {
+		response.Fail(c, 100034)
+		return
+	}
+	if !action.Ignore && action.TradeType == "" && action.PaymentMethod == "" && action.IncomeType == 0 && action.Remark == "" && len(action.TagIDs) == 0 {
+		response.Fail(c, 100034)
+		return
+	}
+	if len(action.TagIDs) > 0 {
+		var count int64
+		if err := config.DB.Model(&model.Tag{}).Where("ledger_id = ? AND id IN ?", ledgerID, action.TagIDs).Count(&count).Error; err != nil {
+			response.Fail(c, 100001)
+			return
+		}
+		if count != int64(len(action.TagIDs)) {
+			response.Fail(c, 100025)
+			return
+		}
+	}
+	// 存储数据
+	rule := model.BillRule{
+		UserID:     userID,
+		LedgerID:   ledgerID,
+		Name:       name,
+		Priority:   req.Priority,
+		Enabled:    req.Enabled,
+		Conditions: req.Conditions,
+		Actions:    req.Actions,
+	}
+	if req.ID > 0 {
+		// 修改（整行保存，允许将优先级、启用状态改为零值）
+		var exist model.BillRule
+		if err := config.DB.Where("id = ? AND ledger_id = ?", req.ID, ledgerID).First(&exist).Error; err != nil {
+			response.Fail(c, 100032)
+			return
+		}
+		rule.ID = exist.ID
+		rule.CreatedAt = exist.CreatedAt
+		if err := config.DB.Save(&rule).Error; err != nil {
+			response.Fail(c, 100013)
+			return
+		}
+	} else {
+		// 新增
+		if err := config.DB.Create(&rule).Error; err != nil {
+			response.Fail(c, 100013)
+			return
+		}
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"id": rule.ID,
+	})
+}
+
+// 删除账单规则请求体
+type DeleteBillRuleRequest struct {
+	ID uint `json:"id" binding:"required"` // ID
+}
+
+// 删除账单规则接口
+func DeleteBillRuleHandler(c *gin.Context) {
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(DeleteBillRuleRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	// 删除数据
+	if err := config.DB.Where("id = ? and ledger_id = ?", req.ID, ledgerID).Delete(&model.BillRule{}).Error; err != nil {
+		response.Fail(c, 100014)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{})
+}
+
+// 对已有账单执行规则请求体
+type RunBillRuleRequest struct {
+	RuleIDs       []uint `json:"rule_ids"`       // 指定执行的规则，为空时执行全部启用的规则
+	DryRun        bool   `json:"dry_run"`        // 仅预览受影响的账单，不做修改
+	DeleteIgnored bool   `json:"delete_ignored"` // 是否将命中忽略规则的账单移入回收站，默认只列出不删除
+}
+
+// 对已有账单执行规则接口
+// 回收站中的账单到期后会被彻底删除，命中忽略规则的账单默认只列出，需显式指定 delete_ignored 才会删除
+func RunBillRuleHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(RunBillRuleRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	engine, err := service.NewBillRuleEngine(config.DB, ledgerID, req.RuleIDs...)
+	if err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	// 获取账单及已有标签
+	var records []model.BillRecord
+	if err := config.DB.Where("ledger_id = ?", ledgerID).Order("trade_time DESC").Find(&records).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	var links []model.BillRecordTag
+	if err := config.DB.Model(&model.BillRecordTag{}).
+		Where("bill_record_id IN (?)", config.DB.Model(&model.BillRecord{}).Select("id").Where("ledger_id = ?", ledgerID)).
+		Find(&links).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	existTags := map[uint]map[uint]bool{}
+	for _, l := range links {
+		if existTags[l.BillRecordID] == nil {
+			existTags[l.BillRecordID] = map[uint]bool{}
+		}
+		existTags[l.BillRecordID][l.TagID] = true
+	}
+	// 计算变更
+	changes := []dto.BillRuleChangeItem{}
+	for _, r := range records {
+		bill := r
+		result := engine.Apply(&bill)
+		if !result.Matched {
+			continue
+		}
+		item := dto.BillRuleChangeItem{
+			ID:           r.ID,
+			TradeTime:    r.TradeTime,
+			Counterparty: r.Counterparty,
+			ProductName:  r.ProductName,
+			Amount:       r.Amount,
+			Before:       dto.BillRuleFields{TradeType: r.TradeType, PaymentMethod: r.PaymentMethod, IncomeType: r.IncomeType, Remark: r.Remark},
+			After:        dto.BillRuleFields{TradeType: bill.TradeType, PaymentMethod: bill.PaymentMethod, IncomeType: bill.IncomeType, Remark: bill.Remark},
+			AddTagIDs:    []uint{},
+			Ignored:      result.Ignore,
+			Delete:       result.Ignore && req.DeleteIgnored,
+			RuleIDs:      result.RuleIDs,
+		}
+		for _, tagID := range result.TagIDs {
+			if !existTags[r.ID][tagID] {
+				item.AddTagIDs = append(item.AddTagIDs, tagID)
+			}
+		}
+		if !item.Ignored && item.Before == item.After && len(item.AddTagIDs) == 0 {
+			continue
+		}
+		changes = append(changes, item)
+	}
+	if req.DryRun {
+		response.Ok(c, gin.H{
+			"total": len(changes),
+			"list":  changes,
+		})
+		return
+	}
+	// 执行变更
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		ids := make([]uint, 0, len(changes))
+		for _, item := range changes {
+			ids = append(ids, item.ID)
+		}
+		befores, err := service.SnapshotBillRecords(tx, ids)
+		if err != nil {
+			return err
+		}
+		tagBefores, err := service.SnapshotBillTags(tx, ids)
+		if err != nil {
+			return err
+		}
+		var deleted []uint
+		for _, item := range changes {
+			if item.Delete {
+				if err := tx.Where("id = ? AND ledger_id = ?", item.ID, ledgerID).Delete(&model.BillRecord{}).Error; err != nil {
+					return err
+				}
+				deleted = append(deleted, item.ID)
+				continue
+			}
+			if item.Before != item.After {
+				if err := tx.Model(&model.BillRecord{}).
+					Where("id = ? AND ledger_id = ?", item.ID, ledgerID).
+					Updates(map[string]any{
+						"trade_type":     item.After.TradeType,
+						"payment_method": item.After.PaymentMethod,
+						"income_type":    item.After.IncomeType,
+						"remark":         item.After.Remark,
+					}).Error; err != nil {
+					return err
+				}
+			}
+			if len(item.AddTagIDs) > 0 {
+				tags := make([]model.BillRecordTag, 0, len(item.AddTagIDs))
+				for _, tagID := range item.AddTagIDs {
+					tags = append(tags, model.BillRecordTag{BillRecordID: item.ID, TagID: tagID})
+				}
+				if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&tags).Error; err != nil {
+					return err
+				}
+			}
+		}
+		// 记录变更历史，删除与修改分开记录
+		deletedBefores := map[uint]model.BillRecord{}
+		for _, id := range deleted {
+			deletedBefores[id] = befores[id]
+			delete(befores, id)
+		}
+		if err := service.RecordBillChanges(tx, userID, model.BillSourceRule, model.BillActionDelete, deletedBefores); err != nil {
+			return err
+		}
+		if err := service.RecordBillChanges(tx, userID, model.BillSourceRule, model.BillActionUpdate, befores); err != nil {
+			return err
+		}
+		return service.RecordBillTagChanges(tx, userID, model.BillSourceRule, tagBefores)
+	})
+	if err != nil {
+		response.Fail(c, 100023)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"total": len(changes),
+	})
+}
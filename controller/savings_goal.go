@@ -0,0 +1,275 @@
+package controller
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zxc7563598/fintrack-backend/config"
+	"github.com/zxc7563598/fintrack-backend/model"
+	"github.com/zxc7563598/fintrack-backend/service"
+	"github.com/zxc7563598/fintrack-backend/utils/helpers"
+	"github.com/zxc7563598/fintrack-backend/utils/response"
+)
+
+// 获取储蓄目标列表接口
+func GetSavingsGoalListHandler(c *gin.Context) {
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取数据
+	var list []model.SavingsGoal
+	if err := config.DB.Where("ledger_id = ?", ledgerID).Order("deadline, id").Find(&list).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	// 返回信息
+	response.Ok(c, gin.H{
+		"list": list,
+	})
+}
+
+// 存储储蓄目标请求体
+type StoreSavingsGoalRequest struct {
+	ID            uint    `json:"id"`                               // ID，修改透传，添加为0
+	Name          string  `json:"name" binding:"required"`          // 目标名称
+	TargetAmount  float64 `json:"target_amount" binding:"required"` // 目标金额
+	InitialAmount float64 `json:"initial_amount"`                   // 开始前已有金额
+	StartDate     string  `json:"start_date"`                       // 开始日期，为空时为今天
+	Deadline      string  `json:"deadline"`                         // 截止日期，为空时不限
+	PaymentMethod string  `json:"payment_method"`                   // 关联账户
+	TagID         uint    `json:"tag_id"`                           // 关联标签
+}
+
+// 存储储蓄目标接口，需关联账户或标签其一
+func StoreSavingsGoalHandler(c *gin.Context) {
+	layout := "2006-01-02"
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(StoreSavingsGoalRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" || req.TargetAmount <= 0 || req.InitialAmount < 0 {
+		response.Fail(c, 300013)
+		return
+	}
+	if (req.PaymentMethod == "") == (req.TagID == 0) {
+		response.Fail(c, 100045)
+		return
+	}
+	if req.TagID > 0 {
+		var count int64
+		if err := config.DB.Model(&model.Tag{}).Where("id = ? AND ledger_id = ?", req.TagID, ledgerID).Count(&count).Error; err != nil {
+			response.Fail(c, 100001)
+			return
+		}
+		if count == 0 {
+			response.Fail(c, 100025)
+			return
+		}
+	}
+	startAt := helpers.StartOfDay(time.Now()).Unix()
+	if req.StartDate != "" {
+		t, err := time.ParseInLocation(layout, req.StartDate, time.Local)
+		if err != nil {
+			response.Fail(c, 100012)
+			return
+		}
+		startAt = t.Unix()
+	}
+	var deadline int64
+	if req.Deadline != "" {
+		t, err := time.ParseInLocation(layout, req.Deadline, time.Local)
+		if err != nil {
+			response.Fail(c, 100012)
+			return
+		}
+		deadline = t.Add(23*time.Hour + 59*time.Minute + 59*time.Second).Unix()
+	}
+	goal := model.SavingsGoal{
+		UserID:        userID,
+		LedgerID:      ledgerID,
+		Name:          name,
+		TargetAmount:  req.TargetAmount,
+		InitialAmount: req.InitialAmount,
+		StartAt:       startAt,
+		Deadline:      deadline,
+		PaymentMethod: req.PaymentMethod,
+		TagID:         req.TagID,
+	}
+	if req.ID > 0 {
+		// 修改
+		var exist model.SavingsGoal
+		if err := config.DB.Where("id = ? AND ledger_id = ?", req.ID, ledgerID).First(&exist).Error; err != nil {
+			response.Fail(c, 100044)
+			return
+		}
+		goal.ID = exist.ID
+		goal.CreatedAt = exist.CreatedAt
+		if err := config.DB.Save(&goal).Error; err != nil {
+			response.Fail(c, 100013)
+			return
+		}
+	} else {
+		// 新增
+		if err := config.DB.Create(&goal).Error; err != nil {
+			response.Fail(c, 100013)
+			return
+		}
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"id": goal.ID,
+	})
+}
+
+// 删除储蓄目标请求体
+type DeleteSavingsGoalRequest struct {
+	ID uint `json:"id" binding:"required"` // ID
+}
+
+// 删除储蓄目标接口
+func DeleteSavingsGoalHandler(c *gin.Context) {
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(DeleteSavingsGoalRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	// 删除数据
+	if err := config.DB.Where("id = ? and ledger_id = ?", req.ID, ledgerID).Delete(&model.SavingsGoal{}).Error; err != nil {
+		response.Fail(c, 100014)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{})
+}
+
+type SavingsGoalProgress struct {
+	ID              uint          `json:"id"`
+	Name            string        `json:"name"`
+	TargetAmount    helpers.Money `json:"target_amount"`
+	Saved           helpers.Money `json:"saved"`
+	Remaining       helpers.Money `json:"remaining"`
+	Percent         float64       `json:"percent"`
+	Deadline        int64         `json:"deadline"`
+	MonthsLeft      int           `json:"months_left"`
+	RequiredMonthly helpers.Money `json:"required_monthly"`
+	AverageMonthly  helpers.Money `json:"average_monthly"`
+	ProjectedAt     int64         `json:"projected_at"`
+	OnTrack         bool          `json:"on_track"`
+}
+
+// 储蓄目标进度请求体
+type SavingsGoalProgressRequest struct {
+	ID     *uint `json:"id"`     // 目标ID，为空时返回全部目标
+	Months int   `json:"months"` // 按最近几个完整月份的存入预测完成时间，默认3
+}
+
+// 储蓄目标进度接口：完成百分比、按期完成所需月存金额及预计完成时间
+func SavingsGoalProgressHandler(c *gin.Context) {
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(SavingsGoalProgressRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	months := 3
+	if req.Months > 0 && req.Months <= 24 {
+		months = req.Months
+	}
+	var goals []model.SavingsGoal
+	db := config.DB.Where("ledger_id = ?", ledgerID)
+	if req.ID != nil {
+		db = db.Where("id = ?", *req.ID)
+	}
+	if err := db.Order("deadline, id").Find(&goals).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	if req.ID != nil && len(goals) == 0 {
+		response.Fail(c, 100044)
+		return
+	}
+	now := time.Now()
+	monthStart := helpers.StartOfMonth(now)
+	recentMonths := []string{}
+	for i := months; i >= 1; i-- {
+		recentMonths = append(recentMonths, monthStart.AddDate(0, -i, 0).Format(service.BudgetMonthLayout))
+	}
+	list := make([]SavingsGoalProgress, 0, len(goals))
+	for _, goal := range goals {
+		// 按月汇总存入金额（包含拆分明细）
+		lines := billLines(false).Where("ledger_id = ? AND trade_time >= ?", ledgerID, goal.StartAt)
+		// 收入计为存入，支出计为取出
+		amountExpr := "CASE WHEN income_type = 1 THEN amount WHEN income_type = 2 THEN -amount ELSE 0 END"
+		if goal.TagID > 0 {
+			lines = lines.Where("id IN (?)", config.DB.Model(&model.BillRecordTag{}).Select("bill_record_id").Where("tag_id = ?", goal.TagID))
+		} else {
+			lines = lines.Where("payment_method = ?", goal.PaymentMethod)
+		}
+		type monthAmount struct {
+			Month  string
+			Amount float64
+		}
+		var rows []monthAmount
+		if err := lines.
+			Select("strftime('%Y-%m', trade_time, 'unixepoch', 'localtime') AS month, COALESCE(SUM(" + amountExpr + "),0) AS amount").
+			Group("month").
+			Scan(&rows).Error; err != nil {
+			response.Fail(c, 100001)
+			return
+		}
+		var contributed float64
+		byMonth := map[string]float64{}
+		for _, r := range rows {
+			contributed += r.Amount
+			byMonth[r.Month] = r.Amount
+		}
+		// 目标开始之前的月份不参与平均
+		recent := []float64{}
+		startMonth := time.Unix(goal.StartAt, 0).Format(service.BudgetMonthLayout)
+		for _, m := range recentMonths {
+			if m >= startMonth {
+				recent = append(recent, byMonth[m])
+			}
+		}
+		p := service.ProjectSavingsGoal(goal, contributed, recent, now)
+		list = append(list, SavingsGoalProgress{
+			ID:              goal.ID,
+			Name:            goal.Name,
+			TargetAmount:    helpers.Money(goal.TargetAmount),
+			Saved:           helpers.Money(p.Saved),
+			Remaining:       helpers.Money(p.Remaining),
+			Percent:         p.Percent,
+			Deadline:        goal.Deadline,
+			MonthsLeft:      p.MonthsLeft,
+			RequiredMonthly: helpers.Money(p.RequiredMonthly),
+			AverageMonthly:  helpers.Money(p.AverageMonthly),
+			ProjectedAt:     p.ProjectedAt,
+			OnTrack:         p.OnTrack,
+		})
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"list": list,
+	})
+}
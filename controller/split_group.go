@@ -0,0 +1,795 @@
+package controller
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zxc7563598/fintrack-backend/config"
+	"github.com/zxc7563598/fintrack-backend/model"
+	"github.com/zxc7563598/fintrack-backend/service"
+	"github.com/zxc7563598/fintrack-backend/utils/helpers"
+	"github.com/zxc7563598/fintrack-backend/utils/response"
+	"gorm.io/gorm"
+)
+
+type SplitMemberItem struct {
+	ID       uint   `json:"id"`
+	PersonID uint   `json:"person_id"`
+	Name     string `json:"name"`
+}
+
+type SplitGroupItem struct {
+	model.SplitGroup
+	Members []SplitMemberItem `json:"members"`
+	Total   helpers.Money     `json:"total"`
+}
+
+// 获取分摊组列表接口
+func GetSplitGroupListHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取数据
+	var groups []model.SplitGroup
+	if err := config.DB.Where("user_id = ?", userID).Order("id DESC").Find(&groups).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	type groupTotal struct {
+		GroupID uint
+		Total   float64
+	}
+	var totals []groupTotal
+	if err := config.DB.Model(&model.SplitExpense{}).
+		Select("group_id, COALESCE(SUM(amount),0) AS total").
+		Where("user_id = ?", userID).
+		Group("group_id").
+		Scan(&totals).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	totalMap := map[uint]float64{}
+	for _, t := range totals {
+		totalMap[t.GroupID] = t.Total
+	}
+	list := make([]SplitGroupItem, 0, len(groups))
+	for _, g := range groups {
+		members, err := splitMembers(userID, g.ID)
+		if err != nil {
+			response.Fail(c, 100001)
+			return
+		}
+		list = append(list, SplitGroupItem{SplitGroup: g, Members: members, Total: helpers.Money(totalMap[g.ID])})
+	}
+	// 返回信息
+	response.Ok(c, gin.H{
+		"list": list,
+	})
+}
+
+// 存储分摊组请求体
+type StoreSplitGroupRequest struct {
+	ID        uint   `json:"id"`                      // ID，修改透传，添加为0
+	Name      string `json:"name" binding:"required"` // 名称
+	Remark    string `json:"remark"`                  // 备注
+	PersonIDs []uint `json:"person_ids"`              // 成员联系人ID列表，本人自动加入
+}
+
+// 存储分摊组接口，成员以本次提交为准，已有账目的成员不能移除
+func StoreSplitGroupHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(StoreSplitGroupRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		response.Fail(c, 300013)
+		return
+	}
+	personIDs := []uint{}
+	for _, id := range uniqueUints(req.PersonIDs) {
+		if id > 0 {
+			personIDs = append(personIDs, id)
+		}
+	}
+	var count int64
+	if len(personIDs) > 0 {
+		if err := config.DB.Model(&model.Person{}).Where("user_id = ? AND id IN ?", userID, personIDs).Count(&count).Error; err != nil {
+			response.Fail(c, 100001)
+			return
+		}
+	}
+	if count != int64(len(personIDs)) {
+		response.Fail(c, 100053)
+		return
+	}
+	group := model.SplitGroup{
+		UserID: userID,
+		Name:   name,
+		Remark: req.Remark,
+	}
+	if req.ID > 0 {
+		var exist model.SplitGroup
+		if err := config.DB.Where("id = ? AND user_id = ?", req.ID, userID).First(&exist).Error; err != nil {
+			response.Fail(c, 100061)
+			return
+		}
+		group.ID = exist.ID
+		group.CreatedAt = exist.CreatedAt
+	}
+	// 存储数据
+	code := 0
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&group).Error; err != nil {
+			return err
+		}
+		keep := append([]uint{0}, personIDs...)
+		// 移除的成员不能存在账目或结算
+		var removed []model.SplitGroupMember
+		if err := tx.Where("group_id = ? AND person_id NOT IN ?", group.ID, keep).Find(&removed).Error; err != nil {
+			return err
+		}
+		for _, m := range removed {
+			used, err := splitMemberUsed(tx, m)
+			if err != nil {
+				return err
+			}
+			if used {
+				code = 100063
+				return gorm.ErrInvalidData
+			}
+			if err := tx.Delete(&m).Error; err != nil {
+				return err
+			}
+		}
+		for _, personID := range keep {
+			var member model.SplitGroupMember
+			if err := tx.Where(map[string]any{"group_id": group.ID, "person_id": personID}).FirstOrCreate(&member).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if code != 0 {
+			response.Fail(c, code)
+			return
+		}
+		response.Fail(c, 100023)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"id": group.ID,
+	})
+}
+
+// 删除分摊组请求体
+type DeleteSplitGroupRequest struct {
+	ID uint `json:"id" binding:"required"` // ID
+}
+
+// 删除分摊组接口，同时删除其成员、账目及结算记录（关联的账单保留）
+func DeleteSplitGroupHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(DeleteSplitGroupRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	// 删除数据
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND user_id = ?", req.ID, userID).Delete(&model.SplitGroup{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		if err := tx.Where("expense_id IN (?)", tx.Model(&model.SplitExpense{}).Select("id").Where("group_id = ?", req.ID)).Delete(&model.SplitShare{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("group_id = ?", req.ID).Delete(&model.SplitExpense{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("group_id = ?", req.ID).Delete(&model.SplitSettlement{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("group_id = ?", req.ID).Delete(&model.SplitGroupMember{}).Error
+	})
+	if err != nil {
+		response.Fail(c, 100014)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{})
+}
+
+// 分摊组详情请求体
+type SplitGroupDetailRequest struct {
+	GroupID uint `json:"group_id" binding:"required"` // 分摊组ID
+}
+
+// 获取分摊账目及结算记录接口
+func GetSplitExpenseListHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(SplitGroupDetailRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	// 获取数据
+	expenses, settlements, err := splitGroupLedger(userID, req.GroupID)
+	if err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	// 返回信息
+	response.Ok(c, gin.H{
+		"expenses":    expenses,
+		"settlements": settlements,
+	})
+}
+
+// 分摊成员参数
+type SplitShareRequest struct {
+	MemberID uint    `json:"member_id" binding:"required"` // 成员ID
+	Value    float64 `json:"value"`                        // 按比例为百分比，指定金额为金额，均摊时忽略
+}
+
+// 存储分摊账目请求体
+type StoreSplitExpenseRequest struct {
+	ID            uint                `json:"id"`                              // ID，修改透传，添加为0
+	GroupID       uint                `json:"group_id" binding:"required"`     // 分摊组ID
+	BillRecordID  uint                `json:"bill_record_id"`                  // 关联的账单ID，关联时金额、名称、时间为空则取自账单
+	PayerMemberID uint                `json:"payer_member_id"`                 // 付款成员ID，为空时为本人
+	Title         string              `json:"title"`                           // 名称
+	Amount        float64             `json:"amount"`                          // 金额
+	SpentDate     string              `json:"spent_date"`                      // 消费日期
+	SplitMode     uint8               `json:"split_mode" binding:"required"`   // 分摊方式（1均摊、2按比例、3指定金额）
+	Shares        []SplitShareRequest `json:"shares" binding:"required,min=1"` // 参与分摊的成员
+}
+
+// 存储分摊账目接口
+func StoreSplitExpenseHandler(c *gin.Context) {
+	layout := "2006-01-02"
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(StoreSplitExpenseRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	members, err := splitMembers(userID, req.GroupID)
+	if err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	if len(members) == 0 {
+		response.Fail(c, 100061)
+		return
+	}
+	memberIDs := map[uint]bool{}
+	selfID := uint(0)
+	for _, m := range members {
+		memberIDs[m.ID] = true
+		if m.PersonID == 0 {
+			selfID = m.ID
+		}
+	}
+	if req.PayerMemberID == 0 {
+		req.PayerMemberID = selfID
+	}
+	if !memberIDs[req.PayerMemberID] {
+		response.Fail(c, 100062)
+		return
+	}
+	inputs := make([]service.SplitShareInput, 0, len(req.Shares))
+	seen := map[uint]bool{}
+	for _, s := range req.Shares {
+		if !memberIDs[s.MemberID] || seen[s.MemberID] {
+			response.Fail(c, 100062)
+			return
+		}
+		seen[s.MemberID] = true
+		inputs = append(inputs, service.SplitShareInput{MemberID: s.MemberID, Value: s.Value})
+	}
+	expense := model.SplitExpense{
+		UserID:        userID,
+		GroupID:       req.GroupID,
+		BillRecordID:  req.BillRecordID,
+		PayerMemberID: req.PayerMemberID,
+		Title:         strings.TrimSpace(req.Title),
+		Amount:        req.Amount,
+		SpentAt:       time.Now().Unix(),
+		SplitMode:     req.SplitMode,
+	}
+	// 关联账单，补全金额、名称和时间
+	if req.BillRecordID > 0 {
+		var bill model.BillRecord
+		if err := config.DB.Where("id = ? AND ledger_id = ?", req.BillRecordID, ledgerID).First(&bill).Error; err != nil {
+			response.Fail(c, 100051)
+			return
+		}
+		if expense.Amount == 0 {
+			expense.Amount = bill.Amount
+		}
+		if expense.Title == "" {
+			expense.Title = bill.ProductName
+		}
+		expense.SpentAt = bill.TradeTime
+	}
+	if req.SpentDate != "" {
+		t, err := time.ParseInLocation(layout, req.SpentDate, time.Local)
+		if err != nil {
+			response.Fail(c, 100012)
+			return
+		}
+		expense.SpentAt = t.Unix()
+	}
+	shares, err := service.ComputeSplitShares(model.SplitMode(req.SplitMode), expense.Amount, inputs)
+	if err != nil {
+		response.Fail(c, 100064)
+		return
+	}
+	if req.ID > 0 {
+		var exist model.SplitExpense
+		if err := config.DB.Where("id = ? AND user_id = ? AND group_id = ?", req.ID, userID, req.GroupID).First(&exist).Error; err != nil {
+			response.Fail(c, 100065)
+			return
+		}
+		expense.ID = exist.ID
+		expense.CreatedAt = exist.CreatedAt
+	}
+	// 存储数据，份额整体替换
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Shares").Save(&expense).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("expense_id = ?", expense.ID).Delete(&model.SplitShare{}).Error; err != nil {
+			return err
+		}
+		for i := range shares {
+			shares[i].ExpenseID = expense.ID
+		}
+		return tx.Create(&shares).Error
+	})
+	if err != nil {
+		response.Fail(c, 100023)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"id":     expense.ID,
+		"shares": shares,
+	})
+}
+
+// 删除分摊账目请求体
+type DeleteSplitExpenseRequest struct {
+	ID uint `json:"id" binding:"required"` // ID
+}
+
+// 删除分摊账目接口
+func DeleteSplitExpenseHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(DeleteSplitExpenseRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	// 删除数据
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND user_id = ?", req.ID, userID).Delete(&model.SplitExpense{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return tx.Where("expense_id = ?", req.ID).Delete(&model.SplitShare{}).Error
+	})
+	if err != nil {
+		response.Fail(c, 100014)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{})
+}
+
+// 存储结算记录请求体
+type StoreSplitSettlementRequest struct {
+	GroupID      uint    `json:"group_id" binding:"required"`       // 分摊组ID
+	FromMemberID uint    `json:"from_member_id" binding:"required"` // 付款成员ID
+	ToMemberID   uint    `json:"to_member_id" binding:"required"`   // 收款成员ID
+	Amount       float64 `json:"amount" binding:"required"`         // 金额
+	SettledDate  string  `json:"settled_date"`                      // 结算日期，为空时为当前时间
+	BillRecordID uint    `json:"bill_record_id"`                    // 关联的转账账单ID
+}
+
+// 存储结算记录接口
+func StoreSplitSettlementHandler(c *gin.Context) {
+	layout := "2006-01-02"
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(StoreSplitSettlementRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	if req.Amount <= 0 {
+		response.Fail(c, 300013)
+		return
+	}
+	members, err := splitMembers(userID, req.GroupID)
+	if err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	memberIDs := map[uint]bool{}
+	for _, m := range members {
+		memberIDs[m.ID] = true
+	}
+	if !memberIDs[req.FromMemberID] || !memberIDs[req.ToMemberID] || req.FromMemberID == req.ToMemberID {
+		response.Fail(c, 100062)
+		return
+	}
+	if code := checkOwnedBillRecord(ledgerID, req.BillRecordID); code != 0 {
+		response.Fail(c, code)
+		return
+	}
+	settledAt := time.Now()
+	if req.SettledDate != "" {
+		t, err := time.ParseInLocation(layout, req.SettledDate, time.Local)
+		if err != nil {
+			response.Fail(c, 100012)
+			return
+		}
+		settledAt = t
+	}
+	settlement := model.SplitSettlement{
+		UserID:       userID,
+		GroupID:      req.GroupID,
+		FromMemberID: req.FromMemberID,
+		ToMemberID:   req.ToMemberID,
+		Amount:       req.Amount,
+		SettledAt:    settledAt.Unix(),
+		BillRecordID: req.BillRecordID,
+	}
+	if err := config.DB.Create(&settlement).Error; err != nil {
+		response.Fail(c, 100013)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"id": settlement.ID,
+	})
+}
+
+// 删除结算记录请求体
+type DeleteSplitSettlementRequest struct {
+	ID uint `json:"id" binding:"required"` // ID
+}
+
+// 删除结算记录接口
+func DeleteSplitSettlementHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(DeleteSplitSettlementRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	// 删除数据
+	if err := config.DB.Where("id = ? and user_id = ?", req.ID, userID).Delete(&model.SplitSettlement{}).Error; err != nil {
+		response.Fail(c, 100014)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{})
+}
+
+type SplitBalanceItem struct {
+	SplitMemberItem
+	Paid    helpers.Money `json:"paid"`
+	Share   helpers.Money `json:"share"`
+	Balance helpers.Money `json:"balance"`
+}
+
+// 分摊组结余接口：每个成员的净余额（正数应收、负数应付）及最少的结算转账方案
+func SplitBalanceHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(SplitGroupDetailRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	members, err := splitMembers(userID, req.GroupID)
+	if err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	if len(members) == 0 {
+		response.Fail(c, 100061)
+		return
+	}
+	expenses, settlements, err := splitGroupLedger(userID, req.GroupID)
+	if err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	// 计算数据
+	paid := map[uint]float64{}
+	share := map[uint]float64{}
+	for _, e := range expenses {
+		paid[e.PayerMemberID] += e.Amount
+		for _, s := range e.Shares {
+			share[s.MemberID] += s.Amount
+		}
+	}
+	balances := service.ComputeSplitBalances(expenses, settlements)
+	list := make([]SplitBalanceItem, 0, len(members))
+	for _, m := range members {
+		list = append(list, SplitBalanceItem{
+			SplitMemberItem: m,
+			Paid:            helpers.Money(paid[m.ID]),
+			Share:           helpers.Money(share[m.ID]),
+			Balance:         helpers.Money(balances[m.ID]),
+		})
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"list":      list,
+		"transfers": service.SettleUp(balances),
+	})
+}
+
+// 匹配结算转账接口：在结算方案中查找成员转给本人的款项，与金额一致的收入账单匹配后记为结算
+// 交易对方或备注含成员姓名的收入优先，其次为转账类收入
+func MatchSplitSettlementHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(SplitGroupDetailRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	members, err := splitMembers(userID, req.GroupID)
+	if err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	if len(members) == 0 {
+		response.Fail(c, 100061)
+		return
+	}
+	expenses, settlements, err := splitGroupLedger(userID, req.GroupID)
+	if err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	if len(expenses) == 0 {
+		response.Ok(c, gin.H{"list": []model.SplitSettlement{}})
+		return
+	}
+	memberMap := map[uint]SplitMemberItem{}
+	for _, m := range members {
+		memberMap[m.ID] = m
+	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 分摊开始后、尚未被任何结算关联的收入
+	since := expenses[0].SpentAt
+	for _, e := range expenses {
+		since = min(since, e.SpentAt)
+	}
+	var incomes []model.BillRecord
+	if err := config.DB.Where("ledger_id = ? AND income_type = ? AND trade_time >= ?", ledgerID, model.IncomeTypeIncome, since).
+		Where("id NOT IN (?)", config.DB.Model(&model.SplitSettlement{}).Select("bill_record_id").Where("user_id = ?", userID)).
+		Order("trade_time").
+		Find(&incomes).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	used := map[uint]bool{}
+	created := []model.SplitSettlement{}
+	for _, t := range service.SettleUp(service.ComputeSplitBalances(expenses, settlements)) {
+		if memberMap[t.ToMemberID].PersonID != 0 {
+			continue
+		}
+		name := memberMap[t.FromMemberID].Name
+		best := -1
+		for i, income := range incomes {
+			if used[income.ID] || math.Abs(income.Amount-t.Amount) > 0.005 {
+				continue
+			}
+			if name != "" && strings.Contains(income.Counterparty+income.Remark+income.ProductName, name) {
+				best = i
+				break
+			}
+			if best < 0 && strings.Contains(income.TradeType+income.ProductName, "转账") {
+				best = i
+			}
+		}
+		if best < 0 {
+			continue
+		}
+		used[incomes[best].ID] = true
+		created = append(created, model.SplitSettlement{
+			UserID:       userID,
+			GroupID:      req.GroupID,
+			FromMemberID: t.FromMemberID,
+			ToMemberID:   t.ToMemberID,
+			Amount:       t.Amount,
+			SettledAt:    incomes[best].TradeTime,
+			BillRecordID: incomes[best].ID,
+		})
+	}
+	if len(created) > 0 {
+		if err := config.DB.Create(&created).Error; err != nil {
+			response.Fail(c, 100013)
+			return
+		}
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"list": created,
+	})
+}
+
+// 查询分摊组成员，分摊组不存在时返回空列表
+func splitMembers(userID uint, groupID uint) ([]SplitMemberItem, error) {
+	list := []SplitMemberItem{}
+	if err := config.DB.Model(&model.SplitGroupMember{}).
+		Select("split_group_members.id, split_group_members.person_id, COALESCE(people.name, '') AS name").
+		Joins("JOIN split_groups ON split_groups.id = split_group_members.group_id AND split_groups.deleted_at IS NULL").
+		Joins("LEFT JOIN people ON people.id = split_group_members.person_id").
+		Where("split_groups.id = ? AND split_groups.user_id = ?", groupID, userID).
+		Order("split_group_members.person_id, split_group_members.id").
+		Scan(&list).Error; err != nil {
+		return nil, err
+	}
+	for i := range list {
+		if list[i].PersonID == 0 {
+			list[i].Name = "我"
+		}
+	}
+	return list, nil
+}
+
+// 查询分摊组的账目（含份额）及结算记录，按时间排序
+func splitGroupLedger(userID uint, groupID uint) ([]model.SplitExpense, []model.SplitSettlement, error) {
+	expenses := []model.SplitExpense{}
+	if err := config.DB.Preload("Shares").Where("user_id = ? AND group_id = ?", userID, groupID).Order("spent_at, id").Find(&expenses).Error; err != nil {
+		return nil, nil, err
+	}
+	settlements := []model.SplitSettlement{}
+	if err := config.DB.Where("user_id = ? AND group_id = ?", userID, groupID).Order("settled_at, id").Find(&settlements).Error; err != nil {
+		return nil, nil, err
+	}
+	return expenses, settlements, nil
+}
+
+// 判断成员是否存在账目、份额或结算记录
+func splitMemberUsed(tx *gorm.DB, member model.SplitGroupMember) (bool, error) {
+	var count int64
+	if err := tx.Model(&model.SplitExpense{}).Where("group_id = ? AND payer_member_id = ?", member.GroupID, member.ID).Count(&count).Error; err != nil || count > 0 {
+		return count > 0, err
+	}
+	if err := tx.Model(&model.SplitShare{}).
+		Where("member_id = ? AND expense_id IN (?)", member.ID, tx.Model(&model.SplitExpense{}).Select("id").Where("group_id = ?", member.GroupID)).
+		Count(&count).Error; err != nil || count > 0 {
+		return count > 0, err
+	}
+	if err := tx.Model(&model.SplitSettlement{}).
+		Where("group_id = ? AND (from_member_id = ? OR to_member_id = ?)", member.GroupID, member.ID, member.ID).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
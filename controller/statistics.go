@@ -7,8 +7,10 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/zxc7563598/fintrack-backend/config"
 	"github.com/zxc7563598/fintrack-backend/model"
+	"github.com/zxc7563598/fintrack-backend/service"
 	"github.com/zxc7563598/fintrack-backend/utils/helpers"
 	"github.com/zxc7563598/fintrack-backend/utils/response"
+	"gorm.io/gorm"
 )
 
 // 获取交易列表请求体
@@ -19,6 +21,50 @@ type GetStatisticsRequest struct {
 	PaymentMethod      *[]string `json:"payment_method"`       // 账户
 	Counterpartys      *[]string `json:"counterpartys"`        // 交易平台
 	TradeTypes         *[]string `json:"trade_types"`          // 交易分类
+	Tags               *[]uint   `json:"tags"`                 // 标签
+	Merchants          *[]uint   `json:"merchants"`            // 商户
+	IncludeUnsettled   bool      `json:"include_unsettled"`    // 是否计入处理中、已关闭、失败的交易
+}
+
+// 统计明细行：已拆分的账单按拆分行展开（分类、金额取自拆分行），未拆分的账单保持原样
+// 报销已到账的支出及对应的报销收入不属于个人收支，不计入统计
+// 已关联的退款不计入收入，而是按比例冲减原消费的金额；状态为已全额退款且未关联退款的消费金额记为0
+// 处理中、已关闭、失败的交易未实际结算，includeUnsettled 为 false 时不计入
+func billLines(includeUnsettled bool) *gorm.DB {
+	reimbursedClaims := config.DB.Model(&model.ReimbursementClaim{}).Select("id").Where("status = ?", model.ReimbursementPaid)
+	refunds := config.DB.Model(&model.BillRefund{}).
+		Select("bill_refunds.original_bill_record_id, SUM(bill_refunds.amount) AS amount").
+		Joins("JOIN bill_records AS refund_bills ON refund_bills.id = bill_refunds.refund_bill_record_id AND refund_bills.deleted_at IS NULL").
+		Group("bill_refunds.original_bill_record_id")
+	lines := config.DB.Model(&model.BillRecord{}).
+		Select(`
+		bill_records.id,
+		bill_records.user_id,
+		bill_records.ledger_id,
+		bill_records.trade_no,
+		bill_records.merchant_order_no,
+		bill_records.platform,
+		bill_records.income_type,
+		COALESCE(bill_splits.trade_type, bill_records.trade_type) AS trade_type,
+		bill_records.product_name,
+		bill_records.counterparty,
+		bill_records.merchant_id,
+		bill_records.payment_method,
+		COALESCE(bill_splits.amount, bill_records.amount) * (1 - CASE WHEN bill_records.amount > 0 THEN MIN(COALESCE(refunds.amount, CASE WHEN bill_records.trade_status_type = ? THEN bill_records.amount ELSE 0 END), bill_records.amount) * 1.0 / bill_records.amount ELSE 0 END) AS amount,
+		bill_records.trade_status,
+		bill_records.trade_status_type,
+		bill_records.trade_time,
+		bill_records.remark
+	`, model.TradeStatusRefunded).
+		Joins("LEFT JOIN bill_splits ON bill_splits.bill_record_id = bill_records.id AND bill_splits.deleted_at IS NULL").
+		Joins("LEFT JOIN (?) AS refunds ON refunds.original_bill_record_id = bill_records.id", refunds).
+		Where("bill_records.id NOT IN (?)", config.DB.Model(&model.BillRefund{}).Select("refund_bill_record_id")).
+		Where("bill_records.id NOT IN (?)", config.DB.Model(&model.ReimbursementItem{}).Select("bill_record_id").Where("claim_id IN (?)", reimbursedClaims)).
+		Where("bill_records.id NOT IN (?)", config.DB.Model(&model.ReimbursementClaim{}).Select("income_bill_record_id").Where("status = ?", model.ReimbursementPaid))
+	if !includeUnsettled {
+		lines = lines.Where("bill_records.trade_status_type NOT IN ?", model.UnsettledTradeStatusTypes)
+	}
+	return config.DB.Table("(?) AS bill_records", lines)
 }
 
 type AmountSummary struct {
@@ -29,17 +75,8 @@ type AmountSummary struct {
 // 账户收支（分类图）
 func AccountBalanceCategoryHandler(c *gin.Context) {
 	layout := "2006-01-02"
-	// 获取用户ID
-	userIDAny, exists := c.Get("user_id")
-	if !exists {
-		response.Fail(c, 300001)
-		return
-	}
-	userID, ok := userIDAny.(uint)
-	if !ok {
-		response.Fail(c, 300002)
-		return
-	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
 	// 获取请求参数
 	req, ok := c.MustGet("payload").(GetStatisticsRequest)
 	if !ok {
@@ -47,7 +84,7 @@ func AccountBalanceCategoryHandler(c *gin.Context) {
 		return
 	}
 	// 获取数据
-	db := config.DB.Model(&model.BillRecord{}).Where("user_id = ?", userID)
+	db := billLines(req.IncludeUnsettled).Where("ledger_id = ?", ledgerID)
 	// 搜索条件
 	if req.StartFormattedDate != nil && *req.StartFormattedDate != "" {
 		t, err := time.ParseInLocation(layout, *req.StartFormattedDate, time.Local)
@@ -77,6 +114,12 @@ func AccountBalanceCategoryHandler(c *gin.Context) {
 	if req.TradeTypes != nil && len(*req.TradeTypes) > 0 {
 		db = db.Where("trade_type IN ?", *req.TradeTypes)
 	}
+	if req.Tags != nil && len(*req.Tags) > 0 {
+		db = db.Where("id IN (?)", service.TaggedBillIDs(config.DB, *req.Tags))
+	}
+	if req.Merchants != nil && len(*req.Merchants) > 0 {
+		db = db.Where("merchant_id IN ?", *req.Merchants)
+	}
 	// 查询数据
 	var summary AmountSummary
 	err := db.Select(`
@@ -102,17 +145,8 @@ type TradeTypeIncome struct {
 // 收入分类（分类图）
 func IncomeCategoryHandler(c *gin.Context) {
 	layout := "2006-01-02"
-	// 获取用户ID
-	userIDAny, exists := c.Get("user_id")
-	if !exists {
-		response.Fail(c, 300001)
-		return
-	}
-	userID, ok := userIDAny.(uint)
-	if !ok {
-		response.Fail(c, 300002)
-		return
-	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
 	// 获取请求参数
 	req, ok := c.MustGet("payload").(GetStatisticsRequest)
 	if !ok {
@@ -120,7 +154,7 @@ func IncomeCategoryHandler(c *gin.Context) {
 		return
 	}
 	// 获取数据
-	db := config.DB.Model(&model.BillRecord{}).Where("user_id = ?", userID)
+	db := billLines(req.IncludeUnsettled).Where("ledger_id = ?", ledgerID)
 	// 搜索条件
 	if req.StartFormattedDate != nil && *req.StartFormattedDate != "" {
 		t, err := time.ParseInLocation(layout, *req.StartFormattedDate, time.Local)
@@ -150,6 +184,12 @@ func IncomeCategoryHandler(c *gin.Context) {
 	if req.TradeTypes != nil && len(*req.TradeTypes) > 0 {
 		db = db.Where("trade_type IN ?", *req.TradeTypes)
 	}
+	if req.Tags != nil && len(*req.Tags) > 0 {
+		db = db.Where("id IN (?)", service.TaggedBillIDs(config.DB, *req.Tags))
+	}
+	if req.Merchants != nil && len(*req.Merchants) > 0 {
+		db = db.Where("merchant_id IN ?", *req.Merchants)
+	}
 	// 查询数据
 	var results []TradeTypeIncome
 	err := db.
@@ -175,17 +215,8 @@ type TradeTypeExpense struct {
 // 支出分类（分类图）
 func ExpenseCategoryHandler(c *gin.Context) {
 	layout := "2006-01-02"
-	// 获取用户ID
-	userIDAny, exists := c.Get("user_id")
-	if !exists {
-		response.Fail(c, 300001)
-		return
-	}
-	userID, ok := userIDAny.(uint)
-	if !ok {
-		response.Fail(c, 300002)
-		return
-	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
 	// 获取请求参数
 	req, ok := c.MustGet("payload").(GetStatisticsRequest)
 	if !ok {
@@ -193,7 +224,7 @@ func ExpenseCategoryHandler(c *gin.Context) {
 		return
 	}
 	// 获取数据
-	db := config.DB.Model(&model.BillRecord{}).Where("user_id = ?", userID)
+	db := billLines(req.IncludeUnsettled).Where("ledger_id = ?", ledgerID)
 	// 搜索条件
 	if req.StartFormattedDate != nil && *req.StartFormattedDate != "" {
 		t, err := time.ParseInLocation(layout, *req.StartFormattedDate, time.Local)
@@ -223,6 +254,12 @@ func ExpenseCategoryHandler(c *gin.Context) {
 	if req.TradeTypes != nil && len(*req.TradeTypes) > 0 {
 		db = db.Where("trade_type IN ?", *req.TradeTypes)
 	}
+	if req.Tags != nil && len(*req.Tags) > 0 {
+		db = db.Where("id IN (?)", service.TaggedBillIDs(config.DB, *req.Tags))
+	}
+	if req.Merchants != nil && len(*req.Merchants) > 0 {
+		db = db.Where("merchant_id IN ?", *req.Merchants)
+	}
 	// 查询数据
 	var results []TradeTypeExpense
 	err := db.
@@ -240,25 +277,171 @@ func ExpenseCategoryHandler(c *gin.Context) {
 	})
 }
 
-type PaymentMethodIncome struct {
-	PaymentMethod string        `json:"payment_method"`
-	Amount        helpers.Money `json:"amount"`
+type TagAmount struct {
+	TagID        uint          `json:"tag_id"`
+	TagName      string        `json:"tag_name"`
+	IncomeTotal  helpers.Money `json:"income_total"`
+	ExpenseTotal helpers.Money `json:"expense_total"`
 }
 
-// 收入账户（分类图）
-func IncomeAccountCategoryHandler(c *gin.Context) {
+// 标签收支（分类图）
+func TagCategoryHandler(c *gin.Context) {
 	layout := "2006-01-02"
-	// 获取用户ID
-	userIDAny, exists := c.Get("user_id")
-	if !exists {
-		response.Fail(c, 300001)
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(GetStatisticsRequest)
+	if !ok {
+		response.Fail(c, 100010)
 		return
 	}
-	userID, ok := userIDAny.(uint)
+	// 获取数据（关联标签表后字段需带表名）
+	db := billLines(req.IncludeUnsettled).Where("bill_records.ledger_id = ?", ledgerID)
+	// 搜索条件
+	if req.StartFormattedDate != nil && *req.StartFormattedDate != "" {
+		t, err := time.ParseInLocation(layout, *req.StartFormattedDate, time.Local)
+		if err == nil {
+			startTimestamp := t.Unix() // 当天 00:00:00 的秒级时间戳
+			db = db.Where("bill_records.trade_time >= ?", startTimestamp)
+		}
+	}
+	if req.EndFormattedDate != nil && *req.EndFormattedDate != "" {
+		t, err := time.ParseInLocation(layout, *req.EndFormattedDate, time.Local)
+		if err == nil {
+			// 加上 23:59:59
+			endOfDay := t.Add(23*time.Hour + 59*time.Minute + 59*time.Second)
+			endTimestamp := endOfDay.Unix()
+			db = db.Where("bill_records.trade_time <= ?", endTimestamp)
+		}
+	}
+	if req.IncomeType != nil {
+		db = db.Where("bill_records.income_type = ?", *req.IncomeType)
+	}
+	if req.Counterpartys != nil && len(*req.Counterpartys) > 0 {
+		db = db.Where("bill_records.counterparty IN ?", *req.Counterpartys)
+	}
+	if req.PaymentMethod != nil && len(*req.PaymentMethod) > 0 {
+		db = db.Where("bill_records.payment_method IN ?", *req.PaymentMethod)
+	}
+	if req.TradeTypes != nil && len(*req.TradeTypes) > 0 {
+		db = db.Where("bill_records.trade_type IN ?", *req.TradeTypes)
+	}
+	if req.Merchants != nil && len(*req.Merchants) > 0 {
+		db = db.Where("bill_records.merchant_id IN ?", *req.Merchants)
+	}
+	// 查询数据
+	var tagIDs []uint
+	if req.Tags != nil {
+		tagIDs = *req.Tags
+	}
+	totals, err := service.SumByTag(db, tagIDs)
+	if err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	results := make([]TagAmount, 0, len(totals))
+	for _, t := range totals {
+		results = append(results, TagAmount{
+			TagID:        t.TagID,
+			TagName:      t.TagName,
+			IncomeTotal:  helpers.Money(t.IncomeTotal),
+			ExpenseTotal: helpers.Money(t.ExpenseTotal),
+		})
+	}
+	response.Ok(c, gin.H{
+		"list": results,
+	})
+}
+
+type MerchantAmount struct {
+	MerchantID   uint          `json:"merchant_id"`
+	Name         string        `json:"name"`
+	IncomeTotal  helpers.Money `json:"income_total"`
+	ExpenseTotal helpers.Money `json:"expense_total"`
+}
+
+// 商户收支（分类图），未规范化的记录按原始交易对方统计
+func MerchantCategoryHandler(c *gin.Context) {
+	layout := "2006-01-02"
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(GetStatisticsRequest)
 	if !ok {
-		response.Fail(c, 300002)
+		response.Fail(c, 100010)
+		return
+	}
+	// 获取数据（关联商户表后字段需带表名）
+	db := billLines(req.IncludeUnsettled).
+		Joins("LEFT JOIN merchants ON merchants.id = bill_records.merchant_id AND merchants.deleted_at IS NULL").
+		Where("bill_records.ledger_id = ?", ledgerID)
+	// 搜索条件
+	if req.StartFormattedDate != nil && *req.StartFormattedDate != "" {
+		t, err := time.ParseInLocation(layout, *req.StartFormattedDate, time.Local)
+		if err == nil {
+			startTimestamp := t.Unix() // 当天 00:00:00 的秒级时间戳
+			db = db.Where("bill_records.trade_time >= ?", startTimestamp)
+		}
+	}
+	if req.EndFormattedDate != nil && *req.EndFormattedDate != "" {
+		t, err := time.ParseInLocation(layout, *req.EndFormattedDate, time.Local)
+		if err == nil {
+			// 加上 23:59:59
+			endOfDay := t.Add(23*time.Hour + 59*time.Minute + 59*time.Second)
+			endTimestamp := endOfDay.Unix()
+			db = db.Where("bill_records.trade_time <= ?", endTimestamp)
+		}
+	}
+	if req.IncomeType != nil {
+		db = db.Where("bill_records.income_type = ?", *req.IncomeType)
+	}
+	if req.Counterpartys != nil && len(*req.Counterpartys) > 0 {
+		db = db.Where("bill_records.counterparty IN ?", *req.Counterpartys)
+	}
+	if req.PaymentMethod != nil && len(*req.PaymentMethod) > 0 {
+		db = db.Where("bill_records.payment_method IN ?", *req.PaymentMethod)
+	}
+	if req.TradeTypes != nil && len(*req.TradeTypes) > 0 {
+		db = db.Where("bill_records.trade_type IN ?", *req.TradeTypes)
+	}
+	if req.Tags != nil && len(*req.Tags) > 0 {
+		db = db.Where("bill_records.id IN (?)", service.TaggedBillIDs(config.DB, *req.Tags))
+	}
+	if req.Merchants != nil && len(*req.Merchants) > 0 {
+		db = db.Where("bill_records.merchant_id IN ?", *req.Merchants)
+	}
+	// 查询数据
+	var results []MerchantAmount
+	err := db.
+		Select(`
+		COALESCE(merchants.id, 0) AS merchant_id,
+		COALESCE(merchants.name, bill_records.counterparty) AS name,
+		COALESCE(SUM(CASE WHEN bill_records.income_type = 1 THEN bill_records.amount END),0) AS income_total,
+		COALESCE(SUM(CASE WHEN bill_records.income_type = 2 THEN bill_records.amount END),0) AS expense_total
+	`).
+		Group("COALESCE(merchants.id, 0), COALESCE(merchants.name, bill_records.counterparty)").
+		Having("SUM(bill_records.amount) > 0").
+		Order("expense_total DESC").
+		Scan(&results).Error
+	if err != nil {
+		response.Fail(c, 100001)
 		return
 	}
+	response.Ok(c, gin.H{
+		"list": results,
+	})
+}
+
+type PaymentMethodIncome struct {
+	PaymentMethod string        `json:"payment_method"`
+	Amount        helpers.Money `json:"amount"`
+}
+
+// 收入账户（分类图）
+func IncomeAccountCategoryHandler(c *gin.Context) {
+	layout := "2006-01-02"
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
 	// 获取请求参数
 	req, ok := c.MustGet("payload").(GetStatisticsRequest)
 	if !ok {
@@ -266,7 +449,7 @@ func IncomeAccountCategoryHandler(c *gin.Context) {
 		return
 	}
 	// 获取数据
-	db := config.DB.Model(&model.BillRecord{}).Where("user_id = ?", userID)
+	db := billLines(req.IncludeUnsettled).Where("ledger_id = ?", ledgerID)
 	// 搜索条件
 	if req.StartFormattedDate != nil && *req.StartFormattedDate != "" {
 		t, err := time.ParseInLocation(layout, *req.StartFormattedDate, time.Local)
@@ -296,6 +479,12 @@ func IncomeAccountCategoryHandler(c *gin.Context) {
 	if req.TradeTypes != nil && len(*req.TradeTypes) > 0 {
 		db = db.Where("trade_type IN ?", *req.TradeTypes)
 	}
+	if req.Tags != nil && len(*req.Tags) > 0 {
+		db = db.Where("id IN (?)", service.TaggedBillIDs(config.DB, *req.Tags))
+	}
+	if req.Merchants != nil && len(*req.Merchants) > 0 {
+		db = db.Where("merchant_id IN ?", *req.Merchants)
+	}
 	// 查询数据
 	var results []PaymentMethodIncome
 	err := db.
@@ -321,17 +510,8 @@ type PaymentMethodExpense struct {
 // 支出账户（分类图）
 func ExpenseAccountCategoryHandler(c *gin.Context) {
 	layout := "2006-01-02"
-	// 获取用户ID
-	userIDAny, exists := c.Get("user_id")
-	if !exists {
-		response.Fail(c, 300001)
-		return
-	}
-	userID, ok := userIDAny.(uint)
-	if !ok {
-		response.Fail(c, 300002)
-		return
-	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
 	// 获取请求参数
 	req, ok := c.MustGet("payload").(GetStatisticsRequest)
 	if !ok {
@@ -339,7 +519,7 @@ func ExpenseAccountCategoryHandler(c *gin.Context) {
 		return
 	}
 	// 获取数据
-	db := config.DB.Model(&model.BillRecord{}).Where("user_id = ?", userID)
+	db := billLines(req.IncludeUnsettled).Where("ledger_id = ?", ledgerID)
 	// 搜索条件
 	if req.StartFormattedDate != nil && *req.StartFormattedDate != "" {
 		t, err := time.ParseInLocation(layout, *req.StartFormattedDate, time.Local)
@@ -369,6 +549,12 @@ func ExpenseAccountCategoryHandler(c *gin.Context) {
 	if req.TradeTypes != nil && len(*req.TradeTypes) > 0 {
 		db = db.Where("trade_type IN ?", *req.TradeTypes)
 	}
+	if req.Tags != nil && len(*req.Tags) > 0 {
+		db = db.Where("id IN (?)", service.TaggedBillIDs(config.DB, *req.Tags))
+	}
+	if req.Merchants != nil && len(*req.Merchants) > 0 {
+		db = db.Where("merchant_id IN ?", *req.Merchants)
+	}
 	// 查询数据
 	var results []PaymentMethodExpense
 	err := db.
@@ -389,17 +575,8 @@ func ExpenseAccountCategoryHandler(c *gin.Context) {
 // 账户收支（趋势图）
 func AccountBalanceTrendHandler(c *gin.Context) {
 	layout := "2006-01-02"
-	// 获取用户ID
-	userIDAny, exists := c.Get("user_id")
-	if !exists {
-		response.Fail(c, 300001)
-		return
-	}
-	userID, ok := userIDAny.(uint)
-	if !ok {
-		response.Fail(c, 300002)
-		return
-	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
 	// 获取请求参数
 	req, ok := c.MustGet("payload").(GetStatisticsRequest)
 	if !ok {
@@ -407,7 +584,7 @@ func AccountBalanceTrendHandler(c *gin.Context) {
 		return
 	}
 	// 获取数据
-	db := config.DB.Model(&model.BillRecord{}).Where("user_id = ?", userID)
+	db := billLines(req.IncludeUnsettled).Where("ledger_id = ?", ledgerID)
 	// 搜索条件
 	var startTimestamp, endTimestamp int64
 	now := time.Now()
@@ -449,6 +626,12 @@ func AccountBalanceTrendHandler(c *gin.Context) {
 	if req.TradeTypes != nil && len(*req.TradeTypes) > 0 {
 		db = db.Where("trade_type IN ?", *req.TradeTypes)
 	}
+	if req.Tags != nil && len(*req.Tags) > 0 {
+		db = db.Where("id IN (?)", service.TaggedBillIDs(config.DB, *req.Tags))
+	}
+	if req.Merchants != nil && len(*req.Merchants) > 0 {
+		db = db.Where("merchant_id IN ?", *req.Merchants)
+	}
 	// 查询数据
 	type IncomeTypeMonth struct {
 		IncomeType string        `json:"income_type"`
@@ -525,17 +708,8 @@ func AccountBalanceTrendHandler(c *gin.Context) {
 // 收入分类（趋势图）
 func IncomeCategoryTrendHandler(c *gin.Context) {
 	layout := "2006-01-02"
-	// 获取用户ID
-	userIDAny, exists := c.Get("user_id")
-	if !exists {
-		response.Fail(c, 300001)
-		return
-	}
-	userID, ok := userIDAny.(uint)
-	if !ok {
-		response.Fail(c, 300002)
-		return
-	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
 	// 获取请求参数
 	req, ok := c.MustGet("payload").(GetStatisticsRequest)
 	if !ok {
@@ -543,7 +717,7 @@ func IncomeCategoryTrendHandler(c *gin.Context) {
 		return
 	}
 	// 获取数据
-	db := config.DB.Model(&model.BillRecord{}).Where("user_id = ?", userID)
+	db := billLines(req.IncludeUnsettled).Where("ledger_id = ?", ledgerID)
 	// 搜索条件
 	var startTimestamp, endTimestamp int64
 	now := time.Now()
@@ -585,6 +759,12 @@ func IncomeCategoryTrendHandler(c *gin.Context) {
 	if req.TradeTypes != nil && len(*req.TradeTypes) > 0 {
 		db = db.Where("trade_type IN ?", *req.TradeTypes)
 	}
+	if req.Tags != nil && len(*req.Tags) > 0 {
+		db = db.Where("id IN (?)", service.TaggedBillIDs(config.DB, *req.Tags))
+	}
+	if req.Merchants != nil && len(*req.Merchants) > 0 {
+		db = db.Where("merchant_id IN ?", *req.Merchants)
+	}
 	// 查询数据
 	type TradeTypeMonthIncome struct {
 		TradeType string        `json:"trade_type"`
@@ -662,17 +842,8 @@ func IncomeCategoryTrendHandler(c *gin.Context) {
 // 支出分类（趋势图）
 func ExpenseCategoryTrendHandler(c *gin.Context) {
 	layout := "2006-01-02"
-	// 获取用户ID
-	userIDAny, exists := c.Get("user_id")
-	if !exists {
-		response.Fail(c, 300001)
-		return
-	}
-	userID, ok := userIDAny.(uint)
-	if !ok {
-		response.Fail(c, 300002)
-		return
-	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
 	// 获取请求参数
 	req, ok := c.MustGet("payload").(GetStatisticsRequest)
 	if !ok {
@@ -680,7 +851,7 @@ func ExpenseCategoryTrendHandler(c *gin.Context) {
 		return
 	}
 	// 获取数据
-	db := config.DB.Model(&model.BillRecord{}).Where("user_id = ?", userID)
+	db := billLines(req.IncludeUnsettled).Where("ledger_id = ?", ledgerID)
 	// 搜索条件
 	var startTimestamp, endTimestamp int64
 	now := time.Now()
@@ -722,6 +893,12 @@ func ExpenseCategoryTrendHandler(c *gin.Context) {
 	if req.TradeTypes != nil && len(*req.TradeTypes) > 0 {
 		db = db.Where("trade_type IN ?", *req.TradeTypes)
 	}
+	if req.Tags != nil && len(*req.Tags) > 0 {
+		db = db.Where("id IN (?)", service.TaggedBillIDs(config.DB, *req.Tags))
+	}
+	if req.Merchants != nil && len(*req.Merchants) > 0 {
+		db = db.Where("merchant_id IN ?", *req.Merchants)
+	}
 	// 查询数据
 	type TradeTypeMonthExpense struct {
 		TradeType string        `json:"trade_type"`
@@ -799,17 +976,8 @@ func ExpenseCategoryTrendHandler(c *gin.Context) {
 // 收入账户（趋势图）
 func IncomeAccountTrendHandler(c *gin.Context) {
 	layout := "2006-01-02"
-	// 获取用户ID
-	userIDAny, exists := c.Get("user_id")
-	if !exists {
-		response.Fail(c, 300001)
-		return
-	}
-	userID, ok := userIDAny.(uint)
-	if !ok {
-		response.Fail(c, 300002)
-		return
-	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
 	// 获取请求参数
 	req, ok := c.MustGet("payload").(GetStatisticsRequest)
 	if !ok {
@@ -817,7 +985,7 @@ func IncomeAccountTrendHandler(c *gin.Context) {
 		return
 	}
 	// 获取数据
-	db := config.DB.Model(&model.BillRecord{}).Where("user_id = ?", userID)
+	db := billLines(req.IncludeUnsettled).Where("ledger_id = ?", ledgerID)
 	// 搜索条件
 	var startTimestamp, endTimestamp int64
 	now := time.Now()
@@ -859,6 +1027,12 @@ func IncomeAccountTrendHandler(c *gin.Context) {
 	if req.TradeTypes != nil && len(*req.TradeTypes) > 0 {
 		db = db.Where("trade_type IN ?", *req.TradeTypes)
 	}
+	if req.Tags != nil && len(*req.Tags) > 0 {
+		db = db.Where("id IN (?)", service.TaggedBillIDs(config.DB, *req.Tags))
+	}
+	if req.Merchants != nil && len(*req.Merchants) > 0 {
+		db = db.Where("merchant_id IN ?", *req.Merchants)
+	}
 	// 查询数据
 	type TradeTypeMonthIncome struct {
 		PaymentMethod string        `json:"payment_method"`
@@ -936,17 +1110,8 @@ func IncomeAccountTrendHandler(c *gin.Context) {
 // 支出账户（趋势图）
 func ExpenseAccountTrendHandler(c *gin.Context) {
 	layout := "2006-01-02"
-	// 获取用户ID
-	userIDAny, exists := c.Get("user_id")
-	if !exists {
-		response.Fail(c, 300001)
-		return
-	}
-	userID, ok := userIDAny.(uint)
-	if !ok {
-		response.Fail(c, 300002)
-		return
-	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
 	// 获取请求参数
 	req, ok := c.MustGet("payload").(GetStatisticsRequest)
 	if !ok {
@@ -954,7 +1119,7 @@ func ExpenseAccountTrendHandler(c *gin.Context) {
 		return
 	}
 	// 获取数据
-	db := config.DB.Model(&model.BillRecord{}).Where("user_id = ?", userID)
+	db := billLines(req.IncludeUnsettled).Where("ledger_id = ?", ledgerID)
 	// 搜索条件
 	var startTimestamp, endTimestamp int64
 	now := time.Now()
@@ -996,6 +1161,12 @@ func ExpenseAccountTrendHandler(c *gin.Context) {
 	if req.TradeTypes != nil && len(*req.TradeTypes) > 0 {
 		db = db.Where("trade_type IN ?", *req.TradeTypes)
 	}
+	if req.Tags != nil && len(*req.Tags) > 0 {
+		db = db.Where("id IN (?)", service.TaggedBillIDs(config.DB, *req.Tags))
+	}
+	if req.Merchants != nil && len(*req.Merchants) > 0 {
+		db = db.Where("merchant_id IN ?", *req.Merchants)
+	}
 	// 查询数据
 	type TradeTypeMonthExpense struct {
 		PaymentMethod string        `json:"payment_method"`
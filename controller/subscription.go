@@ -0,0 +1,245 @@
+package controller
+
+import (
+	"math"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zxc7563598/fintrack-backend/config"
+	"github.com/zxc7563598/fintrack-backend/model"
+	"github.com/zxc7563598/fintrack-backend/service"
+	"github.com/zxc7563598/fintrack-backend/utils/helpers"
+	"github.com/zxc7563598/fintrack-backend/utils/response"
+	"gorm.io/gorm"
+)
+
+// 识别周期性扣费接口
+func DetectSubscriptionHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取已结算的支出记录
+	var records []model.BillRecord
+	if err := config.DB.Where("ledger_id = ? AND income_type = ?", ledgerID, model.IncomeTypeExpense).
+		Where("trade_status_type NOT IN ?", model.UnsettledTradeStatusTypes).
+		Find(&records).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	// 排除已全额退款的支出
+	refunded, err := service.RefundedAmounts(config.DB, ledgerID)
+	if err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	charges := make([]model.BillRecord, 0, len(records))
+	for _, r := range records {
+		if !service.FullyRefunded(r, refunded) {
+			charges = append(charges, r)
+		}
+	}
+	detected := service.DetectSubscriptions(charges, time.Now())
+	// 获取已有订阅，用于合并识别结果
+	var existing []model.Subscription
+	if err := config.DB.Where("ledger_id = ?", ledgerID).Find(&existing).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	var merchants []model.Merchant
+	if err := config.DB.Where("ledger_id = ?", ledgerID).Find(&merchants).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	merchantNames := map[uint]string{}
+	for _, m := range merchants {
+		merchantNames[m.ID] = m.Name
+	}
+	created := 0
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		for _, d := range detected {
+			sub := findSubscription(existing, d)
+			if sub == nil {
+				name := d.Counterparty
+				if merchantNames[d.MerchantID] != "" {
+					name = merchantNames[d.MerchantID]
+				}
+				sub = &model.Subscription{
+					UserID:   userID,
+					LedgerID: ledgerID,
+					Name:     name,
+					Status:   uint8(model.SubscriptionStatusDetected),
+				}
+				created++
+			}
+			// 已确认或已忽略的订阅只更新扣费信息，不改变状态
+			sub.MerchantID = d.MerchantID
+			sub.Counterparty = d.Counterparty
+			sub.TradeType = d.TradeType
+			sub.PaymentMethod = d.PaymentMethod
+			sub.Cadence = uint8(d.Cadence)
+			sub.Amount = d.Amount
+			sub.Occurrences = d.Occurrences
+			sub.FirstTradeAt = d.FirstTradeAt
+			sub.LastTradeAt = d.LastTradeAt
+			sub.NextTradeAt = d.NextTradeAt
+			if err := tx.Save(sub).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		response.Fail(c, 100023)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"total":   len(detected),
+		"created": created,
+	})
+}
+
+// 查找与识别结果对应的已有订阅：同一商户（或交易对方）且金额相近
+func findSubscription(existing []model.Subscription, d service.DetectedSubscription) *model.Subscription {
+	for i := range existing {
+		s := &existing[i]
+		if d.MerchantID > 0 {
+			if s.MerchantID != d.MerchantID {
+				continue
+			}
+		} else if s.MerchantID > 0 || s.Counterparty != d.Counterparty {
+			continue
+		}
+		if math.Abs(s.Amount-d.Amount) <= s.Amount*0.2 {
+			return s
+		}
+	}
+	return nil
+}
+
+// 获取订阅列表请求体
+type GetSubscriptionListRequest struct {
+	Status *uint8 `json:"status"` // 状态（1待确认、2已确认、3已忽略），为空时返回全部
+}
+
+// 获取订阅列表接口
+func GetSubscriptionListHandler(c *gin.Context) {
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(GetSubscriptionListRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	// 获取数据
+	var list []model.Subscription
+	db := config.DB.Where("ledger_id = ?", ledgerID)
+	if req.Status != nil {
+		db = db.Where("status = ?", *req.Status)
+	}
+	if err := db.Order("next_trade_at").Find(&list).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	// 返回信息
+	response.Ok(c, gin.H{
+		"list": list,
+	})
+}
+
+// 更新订阅状态请求体
+type UpdateSubscriptionRequest struct {
+	ID uint `json:"id" binding:"required"` // ID
+}
+
+// 确认订阅接口
+func ConfirmSubscriptionHandler(c *gin.Context) {
+	updateSubscriptionStatus(c, model.SubscriptionStatusConfirmed)
+}
+
+// 忽略订阅接口
+func DismissSubscriptionHandler(c *gin.Context) {
+	updateSubscriptionStatus(c, model.SubscriptionStatusDismissed)
+}
+
+func updateSubscriptionStatus(c *gin.Context, status model.SubscriptionStatus) {
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(UpdateSubscriptionRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	// 更新状态
+	result := config.DB.Model(&model.Subscription{}).
+		Where("id = ? AND ledger_id = ?", req.ID, ledgerID).
+		Update("status", uint8(status))
+	if result.Error != nil {
+		response.Fail(c, 100023)
+		return
+	}
+	if result.RowsAffected == 0 {
+		response.Fail(c, 100035)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{})
+}
+
+type SubscriptionCost struct {
+	ID          uint          `json:"id"`
+	Name        string        `json:"name"`
+	TradeType   string        `json:"trade_type"`
+	Cadence     uint8         `json:"cadence"`
+	Amount      helpers.Money `json:"amount"`
+	MonthlyCost helpers.Money `json:"monthly_cost"`
+	NextTradeAt int64         `json:"next_trade_at"`
+}
+
+// 订阅月均费用接口（仅统计已确认的订阅）
+func SubscriptionCostHandler(c *gin.Context) {
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取数据
+	var subs []model.Subscription
+	if err := config.DB.Where("ledger_id = ? AND status = ?", ledgerID, model.SubscriptionStatusConfirmed).
+		Order("next_trade_at").
+		Find(&subs).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	// 折算为月均费用
+	var total float64
+	list := make([]SubscriptionCost, 0, len(subs))
+	for _, s := range subs {
+		monthly := s.Amount * model.Cadence(s.Cadence).MonthlyFactor()
+		total += monthly
+		list = append(list, SubscriptionCost{
+			ID:          s.ID,
+			Name:        s.Name,
+			TradeType:   s.TradeType,
+			Cadence:     s.Cadence,
+			Amount:      helpers.Money(s.Amount),
+			MonthlyCost: helpers.Money(monthly),
+			NextTradeAt: s.NextTradeAt,
+		})
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"monthly_total": helpers.Money(total),
+		"yearly_total":  helpers.Money(total * 12),
+		"list":          list,
+	})
+}
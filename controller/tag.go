@@ -0,0 +1,302 @@
+package controller
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zxc7563598/fintrack-backend/config"
+	"github.com/zxc7563598/fintrack-backend/dto"
+	"github.com/zxc7563598/fintrack-backend/model"
+	"github.com/zxc7563598/fintrack-backend/service"
+	"github.com/zxc7563598/fintrack-backend/utils/response"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 获取标签列表接口
+func GetTagListHandler(c *gin.Context) {
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取数据（附带每个标签关联的账单数量）
+	var list []dto.TagListItem
+	err := config.DB.Model(&model.Tag{}).
+		Select("tags.id, tags.name, COUNT(bill_records.id) AS count").
+		Joins("LEFT JOIN bill_record_tags ON bill_record_tags.tag_id = tags.id").
+		Joins("LEFT JOIN bill_records ON bill_records.id = bill_record_tags.bill_record_id AND bill_records.deleted_at IS NULL").
+		Where("tags.ledger_id = ?", ledgerID).
+		Group("tags.id, tags.name").
+		Order("tags.id").
+		Scan(&list).Error
+	if err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	// 返回信息
+	response.Ok(c, gin.H{
+		"list": list,
+	})
+}
+
+// 存储标签请求体
+type StoreTagRequest struct {
+	ID   uint   `json:"id"`                      // ID，修改透传，添加为0
+	Name string `json:"name" binding:"required"` // 标签名称
+}
+
+// 存储标签接口
+func StoreTagHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(StoreTagRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		response.Fail(c, 300013)
+		return
+	}
+	// 同一账本下标签名称不可重复
+	var count int64
+	if err := config.DB.Model(&model.Tag{}).
+		Where("ledger_id = ? AND name = ? AND id <> ?", ledgerID, name, req.ID).
+		Count(&count).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
+	if count > 0 {
+		response.Fail(c, 100026)
+		return
+	}
+	// 存储数据
+	tag := model.Tag{
+		UserID:   userID,
+		LedgerID: ledgerID,
+		Name:     name,
+	}
+	if req.ID > 0 {
+		// 修改
+		result := config.DB.Model(&model.Tag{}).
+			Where("id = ? AND ledger_id = ?", req.ID, ledgerID).
+			Omit("user_id").
+			Updates(tag)
+		if result.Error != nil {
+			response.Fail(c, 100013)
+			return
+		}
+		if result.RowsAffected == 0 {
+			response.Fail(c, 100025)
+			return
+		}
+		tag.ID = req.ID
+	} else {
+		// 新增
+		if err := config.DB.Create(&tag).Error; err != nil {
+			response.Fail(c, 100013)
+			return
+		}
+	}
+	// 返回成功
+	response.Ok(c, gin.H{
+		"id": tag.ID,
+	})
+}
+
+// 删除标签请求体
+type DeleteTagRequest struct {
+	ID uint `json:"id" binding:"required"` // ID
+}
+
+// 删除标签接口
+func DeleteTagHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(DeleteTagRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	// 删除标签及其与账单的关联，并记录账单的标签变更
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND ledger_id = ?", req.ID, ledgerID).Delete(&model.Tag{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		var billIDs []uint
+		if err := tx.Model(&model.BillRecordTag{}).Where("tag_id = ?", req.ID).Pluck("bill_record_id", &billIDs).Error; err != nil {
+			return err
+		}
+		befores, err := service.SnapshotBillTags(tx, billIDs)
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("tag_id = ?", req.ID).Delete(&model.BillRecordTag{}).Error; err != nil {
+			return err
+		}
+		return service.RecordBillTagChanges(tx, userID, model.BillSourceManual, befores)
+	})
+	if err != nil {
+		response.Fail(c, 100014)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{})
+}
+
+// 批量设置账单标签请求体
+type TagBillRecordsRequest struct {
+	IDs    []uint `json:"ids" binding:"required"`     // 账单ID
+	TagIDs []uint `json:"tag_ids" binding:"required"` // 标签ID
+}
+
+// 批量添加账单标签接口
+func TagBillRecordsHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(TagBillRecordsRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	billIDs, tagIDs, code := ownedBillAndTagIDs(ledgerID, req.IDs, req.TagIDs)
+	if code != 0 {
+		response.Fail(c, code)
+		return
+	}
+	// 构建关联关系，已存在的关联直接忽略
+	links := make([]model.BillRecordTag, 0, len(billIDs)*len(tagIDs))
+	for _, billID := range billIDs {
+		for _, tagID := range tagIDs {
+			links = append(links, model.BillRecordTag{BillRecordID: billID, TagID: tagID})
+		}
+	}
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		befores, err := service.SnapshotBillTags(tx, billIDs)
+		if err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(&links, 500).Error; err != nil {
+			return err
+		}
+		return service.RecordBillTagChanges(tx, userID, model.BillSourceManual, befores)
+	})
+	if err != nil {
+		response.Fail(c, 100023)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{})
+}
+
+// 批量移除账单标签接口
+func UntagBillRecordsHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(TagBillRecordsRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	billIDs, tagIDs, code := ownedBillAndTagIDs(ledgerID, req.IDs, req.TagIDs)
+	if code != 0 {
+		response.Fail(c, code)
+		return
+	}
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		befores, err := service.SnapshotBillTags(tx, billIDs)
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("bill_record_id IN ? AND tag_id IN ?", billIDs, tagIDs).Delete(&model.BillRecordTag{}).Error; err != nil {
+			return err
+		}
+		return service.RecordBillTagChanges(tx, userID, model.BillSourceManual, befores)
+	})
+	if err != nil {
+		response.Fail(c, 100014)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{})
+}
+
+// 过滤出属于当前账本的账单ID与标签ID，失败时返回错误码
+func ownedBillAndTagIDs(ledgerID uint, ids []uint, tagIDs []uint) ([]uint, []uint, int) {
+	if len(ids) == 0 {
+		return nil, nil, 100027
+	}
+	if len(tagIDs) == 0 {
+		return nil, nil, 100025
+	}
+	var billIDs []uint
+	if err := config.DB.Model(&model.BillRecord{}).
+		Where("ledger_id = ? AND id IN ?", ledgerID, ids).
+		Pluck("id", &billIDs).Error; err != nil {
+		return nil, nil, 100001
+	}
+	if len(billIDs) == 0 {
+		return nil, nil, 100027
+	}
+	var ownedTagIDs []uint
+	if err := config.DB.Model(&model.Tag{}).
+		Where("ledger_id = ? AND id IN ?", ledgerID, tagIDs).
+		Pluck("id", &ownedTagIDs).Error; err != nil {
+		return nil, nil, 100001
+	}
+	if len(ownedTagIDs) == 0 {
+		return nil, nil, 100025
+	}
+	return billIDs, ownedTagIDs, 0
+}
@@ -305,29 +305,31 @@ func StoreDeepseekApiKeyHandler(c *gin.Context) {
 
 // 获取用户账户分类接口
 func GetPaymentMethodHandler(c *gin.Context) {
-	// 获取用户ID
-	userIDAny, exists := c.Get("user_id")
-	if !exists {
-		response.Fail(c, 300001)
-		return
-	}
-	userID, ok := userIDAny.(uint)
-	if !ok {
-		response.Fail(c, 300002)
-		return
-	}
-	// 获取用户分类
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取当前账本的账户分类
 	var paymentMethod []string
 	if err := config.DB.Model(&model.BillRecord{}).
 		Distinct("payment_method").
-		Where("user_id = ?", userID).
+		Where("ledger_id = ?", ledgerID).
 		Pluck("payment_method", &paymentMethod).Error; err != nil {
 		response.Fail(c, 100001)
 		return
 	}
+	// 获取已归类的原始账户
+	var aliases []dto.PaymentMethodAliasItem
+	if err := config.DB.Model(&model.PaymentMethodAlias{}).
+		Select("raw_name, name").
+		Where("ledger_id = ?", ledgerID).
+		Order("id").
+		Scan(&aliases).Error; err != nil {
+		response.Fail(c, 100001)
+		return
+	}
 	// 返回成功
 	response.Ok(c, gin.H{
 		"payment_method": paymentMethod,
+		"aliases":        aliases,
 	})
 }
 
@@ -344,12 +346,14 @@ func OrganizePaymentMethodHandler(c *gin.Context) {
 		response.Fail(c, 300002)
 		return
 	}
-	// 获取用户分类
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取账本原始账户名称
 	var paymentMethod []string
 	if err := config.DB.Model(&model.BillRecord{}).
-		Distinct("payment_method").
-		Where("user_id = ?", userID).
-		Pluck("payment_method", &paymentMethod).Error; err != nil {
+		Distinct("raw_payment_method").
+		Where("ledger_id = ?", ledgerID).
+		Pluck("raw_payment_method", &paymentMethod).Error; err != nil {
 		response.Fail(c, 100001)
 		return
 	}
@@ -416,26 +420,98 @@ func StorePaymentMethodHandler(c *gin.Context) {
 		response.Fail(c, 300002)
 		return
 	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
 	// 获取请求参数
 	req, ok := c.MustGet("payload").(StorePaymentMethodRequest)
 	if !ok {
 		response.Fail(c, 100010)
 		return
 	}
-	// 遍历请求里的每个映射关系，批量更新
-	for _, pm := range req.PaymentMethod {
-		oldValue := pm.Key
-		newValue := pm.Value
-		if oldValue == "" || newValue == "" {
-			continue
+	// 遍历请求里的每个映射关系，记录别名并更新账单（原始账户名称保留在 raw_payment_method 中）
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		for _, pm := range req.PaymentMethod {
+			oldValue := pm.Key
+			newValue := pm.Value
+			if oldValue == "" || newValue == "" {
+				continue
+			}
+			// 归类到自身等同于撤销
+			if oldValue == newValue {
+				if err := service.RevertPaymentMethodAlias(tx, userID, ledgerID, oldValue); err != nil {
+					return err
+				}
+				continue
+			}
+			var alias model.PaymentMethodAlias
+			result := tx.Where("ledger_id = ? AND raw_name = ?", ledgerID, oldValue).Limit(1).Find(&alias)
+			if result.Error != nil {
+				return result.Error
+			}
+			alias.UserID = userID
+			alias.LedgerID = ledgerID
+			alias.RawName = oldValue
+			alias.Name = newValue
+			if err := tx.Save(&alias).Error; err != nil {
+				return err
+			}
+			// 执行更新，归类结果来自 AI 整理
+			if _, err := service.UpdateBillRecords(tx, userID, model.BillSourceAI,
+				map[string]any{"payment_method": newValue},
+				"ledger_id = ? AND raw_payment_method = ?", ledgerID, oldValue); err != nil {
+				return err
+			}
 		}
-		// 执行更新
-		if err := config.DB.Model(&model.BillRecord{}).
-			Where("user_id = ? AND payment_method = ?", userID, oldValue).
-			Update("payment_method", newValue).Error; err != nil {
-			response.Fail(c, 100021)
-			return
+		return nil
+	})
+	if err != nil {
+		response.Fail(c, 100021)
+		return
+	}
+	// 返回成功
+	response.Ok(c, gin.H{})
+}
+
+// 撤销用户账户分类请求体
+type RevertPaymentMethodRequest struct {
+	PaymentMethod []string `json:"payment_method"` // 需要撤销归类的原始账户名称
+}
+
+// 撤销用户账户分类接口
+func RevertPaymentMethodHandler(c *gin.Context) {
+	// 获取用户ID
+	userIDAny, exists := c.Get("user_id")
+	if !exists {
+		response.Fail(c, 300001)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		response.Fail(c, 300002)
+		return
+	}
+	// 获取账本ID
+	ledgerID := c.GetUint("ledger_id")
+	// 获取请求参数
+	req, ok := c.MustGet("payload").(RevertPaymentMethodRequest)
+	if !ok {
+		response.Fail(c, 100010)
+		return
+	}
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		for _, raw := range req.PaymentMethod {
+			if raw == "" {
+				continue
+			}
+			if err := service.RevertPaymentMethodAlias(tx, userID, ledgerID, raw); err != nil {
+				return err
+			}
 		}
+		return nil
+	})
+	if err != nil {
+		response.Fail(c, 100021)
+		return
 	}
 	// 返回成功
 	response.Ok(c, gin.H{})
@@ -0,0 +1,22 @@
+package dto
+
+type BillRuleFields struct {
+	TradeType     string `json:"trade_type"`
+	PaymentMethod string `json:"payment_method"`
+	IncomeType    uint8  `json:"income_type"`
+	Remark        string `json:"remark"`
+}
+
+type BillRuleChangeItem struct {
+	ID           uint           `json:"id"`
+	TradeTime    int64          `json:"trade_time"`
+	Counterparty string         `json:"counterparty"`
+	ProductName  string         `json:"product_name"`
+	Amount       float64        `json:"amount"`
+	Before       BillRuleFields `json:"before"`
+	After        BillRuleFields `json:"after"`
+	AddTagIDs    []uint         `json:"add_tag_ids"`
+	Ignored      bool           `json:"ignored"`
+	Delete       bool           `json:"delete"`
+	RuleIDs      []uint         `json:"rule_ids"`
+}
@@ -0,0 +1,18 @@
+package dto
+
+type MerchantAliasItem struct {
+	MatchType uint8  `json:"match_type"`
+	Pattern   string `json:"pattern"`
+}
+
+type MerchantListItem struct {
+	ID      uint                `json:"id"`
+	Name    string              `json:"name"`
+	Count   int64               `json:"count"`
+	Aliases []MerchantAliasItem `json:"aliases" gorm:"-"`
+}
+
+type MerchantOptionItem struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
@@ -0,0 +1,9 @@
+package dto
+
+type RecurringOccurrenceItem struct {
+	ScheduledAt  int64   `json:"scheduled_at"`
+	Amount       float64 `json:"amount"`
+	Remark       string  `json:"remark"`
+	Status       uint8   `json:"status"`
+	BillRecordID uint    `json:"bill_record_id"`
+}
@@ -0,0 +1,12 @@
+package dto
+
+type TagListItem struct {
+	ID    uint   `json:"id"`
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
+
+type TagOptionItem struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
@@ -4,3 +4,8 @@ type UserAccountItem struct {
 	Name  string `json:"name"`
 	Email string `json:"email"`
 }
+
+type PaymentMethodAliasItem struct {
+	RawName string `json:"raw_name"`
+	Name    string `json:"name"`
+}
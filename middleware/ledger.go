@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"strconv"
+
+	"github.com/zxc7563598/fintrack-backend/config"
+	"github.com/zxc7563598/fintrack-backend/model"
+	"github.com/zxc7563598/fintrack-backend/service"
+	"github.com/zxc7563598/fintrack-backend/utils/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 账本中间件：从 X-Ledger-ID 请求头确定当前账本，未传时使用用户的默认账本
+// 校验用户是账本成员且角色满足 required，通过后设置 ledger_id 和 ledger_role
+func LedgerMiddleware(required model.LedgerRole) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetUint("user_id")
+		if userID == 0 {
+			response.Fail(c, 300001)
+			c.Abort()
+			return
+		}
+		var ledgerID uint
+		if header := c.GetHeader("X-Ledger-ID"); header != "" {
+			id, err := strconv.ParseUint(header, 10, 64)
+			if err != nil || id == 0 {
+				response.Fail(c, 300014)
+				c.Abort()
+				return
+			}
+			ledgerID = uint(id)
+		} else {
+			ledger, err := service.DefaultLedger(config.DB, userID)
+			if err != nil {
+				response.Fail(c, 100001)
+				c.Abort()
+				return
+			}
+			ledgerID = ledger.ID
+		}
+		role, err := service.LedgerRoleOf(config.DB, ledgerID, userID)
+		if err != nil {
+			response.Fail(c, 100001)
+			c.Abort()
+			return
+		}
+		if role == 0 {
+			response.Fail(c, 300014)
+			c.Abort()
+			return
+		}
+		if !role.Allows(required) {
+			response.Fail(c, 300015)
+			c.Abort()
+			return
+		}
+		c.Set("ledger_id", ledgerID)
+		c.Set("ledger_role", role)
+		c.Next()
+	}
+}
@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// AttachmentFile 附件文件表，按内容哈希去重，多个账单附件可引用同一文件
+type AttachmentFile struct {
+	ID           uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	Hash         string    `gorm:"size:64;uniqueIndex;not null;comment:文件内容SHA-256" json:"hash"`
+	Size         int64     `gorm:"not null;comment:文件大小（字节）" json:"size"`
+	MimeType     string    `gorm:"size:255;comment:文件类型" json:"mime_type"`
+	HasThumbnail bool      `gorm:"default:false;comment:是否已生成缩略图" json:"has_thumbnail"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// BillAttachment 账单附件表（小票照片、发票等）
+type BillAttachment struct {
+	ID               uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	LedgerID         uint      `gorm:"index;not null;comment:账本ID" json:"ledger_id"`
+	UserID           uint      `gorm:"not null;comment:上传用户ID" json:"user_id"`
+	BillRecordID     uint      `gorm:"index;not null;comment:账单ID" json:"bill_record_id"`
+	AttachmentFileID uint      `gorm:"index;not null;comment:附件文件ID" json:"attachment_file_id"`
+	FileName         string    `gorm:"size:255;comment:原始文件名" json:"file_name"`
+	CreatedAt        time.Time `json:"created_at"`
+}
@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// BillMerge 重复账单合并记录，被合并的账单移入回收站，其字段快照保留在此
+type BillMerge struct {
+	ID                 uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	LedgerID           uint      `gorm:"index;not null;comment:账本ID" json:"ledger_id"`
+	UserID             uint      `gorm:"not null;comment:操作用户ID" json:"user_id"`
+	BillRecordID       uint      `gorm:"index;not null;comment:合并后保留的账单ID" json:"bill_record_id"`
+	MergedBillRecordID uint      `gorm:"uniqueIndex;not null;comment:被合并的账单ID" json:"merged_bill_record_id"`
+	Snapshot           string    `gorm:"type:text;comment:被合并账单的字段快照（JSON）" json:"snapshot"`
+	CreatedAt          time.Time `json:"created_at"`
+}
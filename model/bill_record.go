@@ -1,6 +1,7 @@
 package model
 
 import (
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -8,23 +9,27 @@ import (
 
 // BillRecord 账单记录表
 type BillRecord struct {
-	ID              uint           `gorm:"primaryKey;autoIncrement" json:"id"`
-	UserID          uint           `gorm:"index:user_id_no_deleted_at;not null;comment:用户ID" json:"user_id"`
-	TradeNo         string         `gorm:"size:255;comment:交易单号" json:"trade_no"`
-	MerchantOrderNo string         `gorm:"size:255;comment:商户单号" json:"merchant_order_no"`
-	Platform        uint8          `gorm:"comment:平台（支付宝、微信）" json:"platform"`
-	IncomeType      uint8          `gorm:"comment:收支类型（1收入、2支出、3不记收支）" json:"income_type"`
-	TradeType       string         `gorm:"size:255;comment:交易类型（分类）" json:"trade_type"`
-	ProductName     string         `gorm:"size:255;comment:商品（交易名称）" json:"product_name"`
-	Counterparty    string         `gorm:"size:255;comment:交易对方（商户名称）" json:"counterparty"`
-	PaymentMethod   string         `gorm:"size:255;comment:交易方式（余额、银行卡）" json:"payment_method"`
-	Amount          float64        `gorm:"type:decimal(10,2);comment:金额" json:"amount"`
-	TradeStatus     string         `gorm:"size:255;comment:交易状态（成功、失败、关闭、退款等）" json:"trade_status"`
-	TradeTime       int64          `gorm:"not null;comment:交易时间" json:"trade_time"`
-	Remark          string         `gorm:"size:255;comment:备注" json:"remark"`
-	CreatedAt       time.Time      `json:"created_at"`
-	UpdatedAt       time.Time      `json:"updated_at"`
-	DeletedAt       gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+	ID               uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID           uint           `gorm:"index:user_id_no_deleted_at;not null;comment:用户ID" json:"user_id"`
+	LedgerID         uint           `gorm:"index;default:0;comment:账本ID" json:"ledger_id"`
+	TradeNo          string         `gorm:"size:255;comment:交易单号" json:"trade_no"`
+	MerchantOrderNo  string         `gorm:"size:255;comment:商户单号" json:"merchant_order_no"`
+	Platform         uint8          `gorm:"comment:平台（支付宝、微信）" json:"platform"`
+	IncomeType       uint8          `gorm:"comment:收支类型（1收入、2支出、3不记收支）" json:"income_type"`
+	TradeType        string         `gorm:"size:255;comment:交易类型（分类）" json:"trade_type"`
+	ProductName      string         `gorm:"size:255;comment:商品（交易名称）" json:"product_name"`
+	Counterparty     string         `gorm:"size:255;comment:交易对方（商户名称）" json:"counterparty"`
+	MerchantID       uint           `gorm:"index;default:0;comment:规范化商户ID" json:"merchant_id"`
+	PaymentMethod    string         `gorm:"size:255;comment:交易方式（余额、银行卡）" json:"payment_method"`
+	RawPaymentMethod string         `gorm:"size:255;comment:原始交易方式（导入时的账户名称）" json:"raw_payment_method"`
+	Amount           float64        `gorm:"type:decimal(10,2);comment:金额" json:"amount"`
+	TradeStatus      string         `gorm:"size:255;comment:交易状态（成功、失败、关闭、退款等）" json:"trade_status"`
+	TradeStatusType  uint8          `gorm:"index;default:0;comment:规范化交易状态（1成功、2处理中、3关闭、4失败、5全额退款）" json:"trade_status_type"`
+	TradeTime        int64          `gorm:"not null;comment:交易时间" json:"trade_time"`
+	Remark           string         `gorm:"size:255;comment:备注" json:"remark"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `gorm:"index" json:"deleted_at"`
 }
 
 // Platform 平台枚举
@@ -45,6 +50,43 @@ const (
 	IncomeTypeUnknown IncomeType = 4 // 未知
 )
 
+// TradeStatusType 规范化交易状态枚举
+type TradeStatusType uint8
+
+const (
+	TradeStatusSuccess  TradeStatusType = 1 // 成功
+	TradeStatusPending  TradeStatusType = 2 // 处理中（等待付款、到账中等）
+	TradeStatusClosed   TradeStatusType = 3 // 关闭（交易关闭、已取消、已退回等）
+	TradeStatusFailed   TradeStatusType = 4 // 失败
+	TradeStatusRefunded TradeStatusType = 5 // 成功后已全额退款
+)
+
+// UnsettledTradeStatusTypes 未结算的交易状态，统计时默认不计入
+var UnsettledTradeStatusTypes = []int{int(TradeStatusPending), int(TradeStatusClosed), int(TradeStatusFailed)}
+
+// 交易状态关键字，按顺序匹配
+var tradeStatusKeywords = []struct {
+	Keywords []string
+	Type     TradeStatusType
+}{
+	{[]string{"失败"}, TradeStatusFailed},
+	{[]string{"关闭", "取消", "撤销", "退回", "退还"}, TradeStatusClosed},
+	{[]string{"全额退款"}, TradeStatusRefunded},
+	{[]string{"等待付款", "待付款", "未付款", "处理中", "进行中", "审核中", "待到账", "未到账"}, TradeStatusPending},
+}
+
+// 将平台的交易状态文本转换为 uint8 枚举，未识别的状态（含空值）视为成功
+func TradeStatusTypeFromString(s string) uint8 {
+	for _, k := range tradeStatusKeywords {
+		for _, keyword := range k.Keywords {
+			if strings.Contains(s, keyword) {
+				return uint8(k.Type)
+			}
+		}
+	}
+	return uint8(TradeStatusSuccess)
+}
+
 // 将字符串收入类型转换为 uint8 枚举
 func IncomeTypeFromString(s string) uint8 {
 	switch s {
@@ -0,0 +1,46 @@
+package model
+
+import "time"
+
+// BillRecordHistory 账单变更历史表，只追加不修改
+type BillRecordHistory struct {
+	ID           uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	BillRecordID uint      `gorm:"index;not null;comment:账单ID" json:"bill_record_id"`
+	LedgerID     uint      `gorm:"index;not null;comment:账本ID" json:"ledger_id"`
+	UserID       uint      `gorm:"not null;comment:操作用户ID" json:"user_id"`
+	Action       uint8     `gorm:"not null;comment:操作（1创建、2修改、3删除、4回滚、5从回收站恢复、6彻底删除）" json:"action"`
+	Source       uint8     `gorm:"not null;comment:来源（1手动、2导入、3规则、4AI、5系统）" json:"source"`
+	Changes      string    `gorm:"type:text;comment:字段变更（JSON，field/before/after）" json:"changes"`
+	Snapshot     string    `gorm:"type:text;comment:操作后的账单字段（JSON，删除时为删除前）" json:"snapshot"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// BillFieldChange 账单字段变更
+type BillFieldChange struct {
+	Field  string `json:"field"`
+	Before any    `json:"before"`
+	After  any    `json:"after"`
+}
+
+// BillChangeAction 账单变更操作枚举
+type BillChangeAction uint8
+
+const (
+	BillActionCreate  BillChangeAction = 1 // 创建
+	BillActionUpdate  BillChangeAction = 2 // 修改
+	BillActionDelete  BillChangeAction = 3 // 删除
+	BillActionRevert  BillChangeAction = 4 // 回滚到历史版本
+	BillActionRestore BillChangeAction = 5 // 从回收站恢复
+	BillActionPurge   BillChangeAction = 6 // 彻底删除
+)
+
+// BillChangeSource 账单变更来源枚举
+type BillChangeSource uint8
+
+const (
+	BillSourceManual BillChangeSource = 1 // 手动
+	BillSourceImport BillChangeSource = 2 // 导入
+	BillSourceRule   BillChangeSource = 3 // 规则
+	BillSourceAI     BillChangeSource = 4 // AI
+	BillSourceSystem BillChangeSource = 5 // 系统（定时任务）
+)
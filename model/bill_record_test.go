@@ -0,0 +1,34 @@
+package model
+
+import "testing"
+
+func TestTradeStatusTypeFromString(t *testing.T) {
+	tests := []struct {
+		status string
+		want   TradeStatusType
+	}{
+		{"", TradeStatusSuccess},
+		{"交易成功", TradeStatusSuccess},
+		{"支付成功", TradeStatusSuccess},
+		{"已存入零钱", TradeStatusSuccess},
+		{"退款成功", TradeStatusSuccess},
+		{"已退款(￥5.00)", TradeStatusSuccess},
+		{"等待付款", TradeStatusPending},
+		{"待到账", TradeStatusPending},
+		{"提现处理中", TradeStatusPending},
+		{"交易关闭", TradeStatusClosed},
+		{"已取消", TradeStatusClosed},
+		{"对方已退还", TradeStatusClosed},
+		{"已全额退款", TradeStatusRefunded},
+		{"支付失败", TradeStatusFailed},
+		{"退款失败", TradeStatusFailed},
+		{"交易关闭（全额退款）", TradeStatusClosed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			if got := TradeStatusTypeFromString(tt.status); got != uint8(tt.want) {
+				t.Errorf("TradeStatusTypeFromString(%q) = %d, want %d", tt.status, got, tt.want)
+			}
+		})
+	}
+}
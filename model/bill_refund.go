@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// BillRefund 退款关联表，将退款账单关联到原始消费账单
+type BillRefund struct {
+	ID                   uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	LedgerID             uint      `gorm:"index;not null;comment:账本ID" json:"ledger_id"`
+	RefundBillRecordID   uint      `gorm:"uniqueIndex;not null;comment:退款账单ID" json:"refund_bill_record_id"`
+	OriginalBillRecordID uint      `gorm:"index;not null;comment:原始消费账单ID" json:"original_bill_record_id"`
+	Amount               float64   `gorm:"type:decimal(10,2);not null;comment:退款金额" json:"amount"`
+	MatchType            uint8     `gorm:"not null;comment:关联方式（1单号、2交易对方与金额、3手动）" json:"match_type"`
+	CreatedAt            time.Time `json:"created_at"`
+	UpdatedAt            time.Time `json:"updated_at"`
+}
+
+// RefundMatchType 退款关联方式枚举
+type RefundMatchType uint8
+
+const (
+	RefundMatchOrderNo      RefundMatchType = 1 // 交易单号或商户单号一致
+	RefundMatchCounterparty RefundMatchType = 2 // 交易对方一致且金额不超过原消费
+	RefundMatchManual       RefundMatchType = 3 // 手动关联
+)
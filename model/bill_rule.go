@@ -0,0 +1,44 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// BillRule 账单自动分类规则表，按账本区分
+type BillRule struct {
+	ID         uint              `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID     uint              `gorm:"index;not null;comment:用户ID" json:"user_id"`
+	LedgerID   uint              `gorm:"index;not null;default:0;comment:账本ID" json:"ledger_id"`
+	Name       string            `gorm:"size:100;not null;comment:规则名称" json:"name"`
+	Priority   int               `gorm:"not null;default:0;comment:优先级（数值越小越先执行）" json:"priority"`
+	Enabled    bool              `gorm:"not null;comment:是否启用" json:"enabled"`
+	Conditions BillRuleCondition `gorm:"type:text;serializer:json;comment:匹配条件" json:"conditions"`
+	Actions    BillRuleAction    `gorm:"type:text;serializer:json;comment:执行动作" json:"actions"`
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt    `gorm:"index" json:"deleted_at"`
+}
+
+// BillRuleCondition 规则匹配条件，所有非空条件需同时满足
+type BillRuleCondition struct {
+	Counterparty  string   `json:"counterparty"`   // 交易对方包含
+	ProductName   string   `json:"product_name"`   // 商品名称包含
+	AmountMin     *float64 `json:"amount_min"`     // 最小金额（含）
+	AmountMax     *float64 `json:"amount_max"`     // 最大金额（含）
+	Platform      uint8    `json:"platform"`       // 平台，0为不限
+	PaymentMethod string   `json:"payment_method"` // 交易方式包含
+	TimeStart     string   `json:"time_start"`     // 交易时段开始（HH:MM）
+	TimeEnd       string   `json:"time_end"`       // 交易时段结束（HH:MM），早于开始时间表示跨天
+}
+
+// BillRuleAction 规则执行动作，空值表示不修改
+type BillRuleAction struct {
+	TradeType     string `json:"trade_type"`     // 设置分类
+	TagIDs        []uint `json:"tag_ids"`        // 添加标签
+	PaymentMethod string `json:"payment_method"` // 设置账户
+	IncomeType    uint8  `json:"income_type"`    // 设置收支类型
+	Remark        string `json:"remark"`         // 设置备注
+	Ignore        bool   `json:"ignore"`         // 忽略（导入时跳过，追溯执行时仅在指定删除时移入回收站）
+}
@@ -0,0 +1,20 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// BillSplit 账单拆分明细表
+type BillSplit struct {
+	ID           uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID       uint           `gorm:"index;not null;comment:用户ID" json:"user_id"`
+	BillRecordID uint           `gorm:"index;not null;comment:账单ID" json:"bill_record_id"`
+	TradeType    string         `gorm:"size:255;comment:交易类型（分类）" json:"trade_type"`
+	Amount       float64        `gorm:"type:decimal(10,2);comment:金额" json:"amount"`
+	Remark       string         `gorm:"size:255;comment:备注" json:"remark"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+// BillTradeTombstone 已从回收站彻底删除或已移至其他账本的账单交易单号，导入时据此跳过，避免这些交易重新导入
+type BillTradeTombstone struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	LedgerID  uint      `gorm:"uniqueIndex:idx_ledger_trade_no;not null;comment:账本ID" json:"ledger_id"`
+	TradeNo   string    `gorm:"uniqueIndex:idx_ledger_trade_no;size:255;not null;comment:交易单号" json:"trade_no"`
+	CreatedAt time.Time `json:"created_at"`
+}
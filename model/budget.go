@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Budget 月度预算表，设置后对之后的月份持续生效，直到被新的月份设置覆盖
+type Budget struct {
+	ID        uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    uint           `gorm:"index:budget_user_month;not null;comment:用户ID" json:"user_id"`
+	LedgerID  uint           `gorm:"index:budget_ledger_month;not null;default:0;comment:账本ID" json:"ledger_id"`
+	TradeType string         `gorm:"size:255;comment:交易类型（分类），为空表示总预算" json:"trade_type"`
+	Month     string         `gorm:"index:budget_user_month;index:budget_ledger_month;size:7;not null;comment:生效月份（2006-01）" json:"month"`
+	Amount    float64        `gorm:"type:decimal(10,2);comment:预算金额" json:"amount"`
+	Rollover  bool           `gorm:"not null;comment:未用完的预算是否结转至下月" json:"rollover"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CreditAccount 信用账户表（信用卡、花呗等），按账单周期统计
+type CreditAccount struct {
+	ID               uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID           uint           `gorm:"index;not null;comment:用户ID" json:"user_id"`
+	LedgerID         uint           `gorm:"index;not null;default:0;comment:账本ID" json:"ledger_id"`
+	PaymentMethod    string         `gorm:"size:255;not null;comment:对应的交易方式（账户）" json:"payment_method"`
+	Name             string         `gorm:"size:100;not null;comment:账户名称" json:"name"`
+	CreditLimit      float64        `gorm:"type:decimal(12,2);comment:信用额度" json:"credit_limit"`
+	BillingDay       int            `gorm:"not null;comment:账单日（超出当月天数取月末）" json:"billing_day"`
+	DueDay           int            `gorm:"not null;comment:还款日（不晚于账单日时为次月）" json:"due_day"`
+	RepaymentKeyword string         `gorm:"size:100;comment:还款记录识别关键字（匹配交易对方或商品名称）" json:"repayment_keyword"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
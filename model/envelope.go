@@ -0,0 +1,35 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Envelope 信封预算表，每个信封对应一个或多个分类
+type Envelope struct {
+	ID         uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID     uint           `gorm:"index;not null;comment:用户ID" json:"user_id"`
+	LedgerID   uint           `gorm:"index;not null;default:0;comment:账本ID" json:"ledger_id"`
+	Name       string         `gorm:"size:100;not null;comment:信封名称" json:"name"`
+	TradeTypes []string       `gorm:"type:text;serializer:json;comment:对应的交易类型（分类）" json:"trade_types"`
+	Sort       int            `gorm:"not null;default:0;comment:排序" json:"sort"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
+
+// EnvelopeTransfer 信封资金划拨表（分配待分配资金或在信封之间移动）
+type EnvelopeTransfer struct {
+	ID             uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID         uint           `gorm:"index;not null;comment:用户ID" json:"user_id"`
+	LedgerID       uint           `gorm:"index;not null;default:0;comment:账本ID" json:"ledger_id"`
+	Month          string         `gorm:"index;size:7;not null;comment:所属月份（2006-01）" json:"month"`
+	FromEnvelopeID uint           `gorm:"not null;default:0;comment:转出信封ID，0为待分配" json:"from_envelope_id"`
+	ToEnvelopeID   uint           `gorm:"not null;default:0;comment:转入信封ID，0为待分配" json:"to_envelope_id"`
+	Amount         float64        `gorm:"type:decimal(10,2);comment:金额" json:"amount"`
+	Remark         string         `gorm:"size:255;comment:备注" json:"remark"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
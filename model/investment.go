@@ -0,0 +1,72 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// InvestmentHolding 投资持仓表（基金、余额宝等），份额与成本由交易记录汇总
+type InvestmentHolding struct {
+	ID           uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID       uint           `gorm:"index;not null;comment:用户ID" json:"user_id"`
+	Code         string         `gorm:"size:50;comment:代码" json:"code"`
+	Name         string         `gorm:"size:100;not null;comment:名称" json:"name"`
+	Kind         uint8          `gorm:"not null;default:1;comment:品种（1基金、2货币基金（余额宝）、3股票、4其他）" json:"kind"`
+	Units        float64        `gorm:"type:decimal(18,4);comment:持有份额" json:"units"`
+	CostBasis    float64        `gorm:"type:decimal(14,2);comment:持仓成本（移动加权平均）" json:"cost_basis"`
+	RealizedGain float64        `gorm:"type:decimal(14,2);comment:已实现收益（卖出盈亏及分红收益）" json:"realized_gain"`
+	Invested     float64        `gorm:"type:decimal(14,2);comment:累计投入" json:"invested"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
+
+// InvestmentTransaction 投资交易表，可关联对应的账单
+type InvestmentTransaction struct {
+	ID           uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID       uint           `gorm:"index;not null;comment:用户ID" json:"user_id"`
+	HoldingID    uint           `gorm:"index;not null;comment:持仓ID" json:"holding_id"`
+	Type         uint8          `gorm:"not null;comment:类型（1买入、2卖出、3现金分红、4收益再投资）" json:"type"`
+	TradeAt      int64          `gorm:"index;not null;comment:交易时间" json:"trade_at"`
+	Units        float64        `gorm:"type:decimal(18,4);comment:份额" json:"units"`
+	Price        float64        `gorm:"type:decimal(14,4);comment:成交净值" json:"price"`
+	Amount       float64        `gorm:"type:decimal(14,2);comment:成交金额" json:"amount"`
+	Fee          float64        `gorm:"type:decimal(10,2);comment:手续费" json:"fee"`
+	BillRecordID uint           `gorm:"index;default:0;comment:关联账单ID" json:"bill_record_id"`
+	Remark       string         `gorm:"size:255;comment:备注" json:"remark"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
+
+// InvestmentPrice 投资品种净值（价格）历史表，手动录入
+type InvestmentPrice struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    uint      `gorm:"index;not null;comment:用户ID" json:"user_id"`
+	HoldingID uint      `gorm:"index:holding_date;not null;comment:持仓ID" json:"holding_id"`
+	Date      string    `gorm:"index:holding_date;size:10;not null;comment:日期（2006-01-02）" json:"date"`
+	Price     float64   `gorm:"type:decimal(14,4);not null;comment:净值（价格）" json:"price"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// InvestmentKind 投资品种枚举
+type InvestmentKind uint8
+
+const (
+	InvestmentKindFund        InvestmentKind = 1 // 基金
+	InvestmentKindMoneyMarket InvestmentKind = 2 // 货币基金（余额宝）
+	InvestmentKindStock       InvestmentKind = 3 // 股票
+	InvestmentKindOther       InvestmentKind = 4 // 其他
+)
+
+// InvestmentTransactionType 投资交易类型枚举
+type InvestmentTransactionType uint8
+
+const (
+	InvestmentBuy      InvestmentTransactionType = 1 // 买入
+	InvestmentSell     InvestmentTransactionType = 2 // 卖出
+	InvestmentDividend InvestmentTransactionType = 3 // 现金分红
+	InvestmentReinvest InvestmentTransactionType = 4 // 收益再投资（余额宝收益、红利再投资）
+)
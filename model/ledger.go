@@ -0,0 +1,87 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Ledger 账本表，账单归属于账本，账本可由多个用户共享
+type Ledger struct {
+	ID            uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	OwnerID       uint           `gorm:"index;not null;comment:所有者用户ID" json:"owner_id"`
+	Name          string         `gorm:"size:100;not null;comment:账本名称" json:"name"`
+	IsDefault     bool           `gorm:"not null;default:false;comment:是否为所有者的默认账本" json:"is_default"`
+	Remark        string         `gorm:"size:255;comment:备注" json:"remark"`
+	EnvelopeMode  bool           `gorm:"not null;default:false;comment:是否开启信封预算模式" json:"envelope_mode"`
+	EnvelopeStart string         `gorm:"size:7;default:'';comment:信封预算开始月份（2006-01），此后的收入计入待分配" json:"envelope_start"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
+
+// LedgerMember 账本成员表
+type LedgerMember struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	LedgerID  uint      `gorm:"uniqueIndex:idx_ledger_member;not null;comment:账本ID" json:"ledger_id"`
+	UserID    uint      `gorm:"uniqueIndex:idx_ledger_member;index;not null;comment:用户ID" json:"user_id"`
+	Role      uint8     `gorm:"not null;comment:角色（1所有者、2编辑者、3查看者）" json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// LedgerInvitation 账本邀请表，按邮箱邀请已注册或之后注册的用户
+type LedgerInvitation struct {
+	ID        uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	LedgerID  uint           `gorm:"index;not null;comment:账本ID" json:"ledger_id"`
+	Email     string         `gorm:"size:100;index;not null;comment:被邀请人邮箱" json:"email"`
+	Role      uint8          `gorm:"not null;comment:加入后的角色（2编辑者、3查看者）" json:"role"`
+	InvitedBy uint           `gorm:"not null;comment:邀请人用户ID" json:"invited_by"`
+	Status    uint8          `gorm:"not null;default:1;comment:状态（1待接受、2已接受、3已拒绝）" json:"status"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
+
+// LedgerScopedModels 按账本区分的用户数据表（账单除外），历史数据（ledger_id 为0）归入创建者的默认账本
+func LedgerScopedModels() []any {
+	return []any{
+		&PaymentMethodAlias{},
+		&Tag{},
+		&Merchant{},
+		&MerchantAlias{},
+		&BillRule{},
+		&Budget{},
+		&Envelope{},
+		&EnvelopeTransfer{},
+		&SavingsGoal{},
+		&CreditAccount{},
+		&NetWorthItem{},
+		&NetWorthSnapshot{},
+		&Subscription{},
+		&RecurringBill{},
+	}
+}
+
+// LedgerRole 账本角色枚举，数值越小权限越大
+type LedgerRole uint8
+
+const (
+	LedgerOwner  LedgerRole = 1 // 所有者：管理成员、删除账本
+	LedgerEditor LedgerRole = 2 // 编辑者：增删改账单、导入
+	LedgerViewer LedgerRole = 3 // 查看者：只读
+)
+
+// Allows 判断角色是否具备 required 所需的权限
+func (r LedgerRole) Allows(required LedgerRole) bool {
+	return r >= LedgerOwner && r <= required
+}
+
+// InvitationStatus 账本邀请状态枚举
+type InvitationStatus uint8
+
+const (
+	InvitationPending  InvitationStatus = 1 // 待接受
+	InvitationAccepted InvitationStatus = 2 // 已接受
+	InvitationDeclined InvitationStatus = 3 // 已拒绝
+)
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Person 联系人表（借贷、AA 分摊的对象）
+type Person struct {
+	ID        uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    uint           `gorm:"index;not null;comment:用户ID" json:"user_id"`
+	Name      string         `gorm:"size:100;not null;comment:姓名" json:"name"`
+	Remark    string         `gorm:"size:255;comment:备注" json:"remark"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
+
+// Loan 借贷记录表
+type Loan struct {
+	ID           uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID       uint           `gorm:"index;not null;comment:用户ID" json:"user_id"`
+	PersonID     uint           `gorm:"index;not null;comment:联系人ID" json:"person_id"`
+	Direction    uint8          `gorm:"not null;comment:方向（1借出、2借入）" json:"direction"`
+	Amount       float64        `gorm:"type:decimal(12,2);not null;comment:借贷金额" json:"amount"`
+	LoanedAt     int64          `gorm:"not null;comment:借贷时间" json:"loaned_at"`
+	DueAt        int64          `gorm:"comment:约定还款时间，0为未约定" json:"due_at"`
+	BillRecordID uint           `gorm:"default:0;comment:关联的转账账单ID" json:"bill_record_id"`
+	Remark       string         `gorm:"size:255;comment:备注" json:"remark"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
+
+// LoanRepayment 借贷还款记录表
+type LoanRepayment struct {
+	ID           uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID       uint           `gorm:"index;not null;comment:用户ID" json:"user_id"`
+	LoanID       uint           `gorm:"index;not null;comment:借贷记录ID" json:"loan_id"`
+	Amount       float64        `gorm:"type:decimal(12,2);not null;comment:还款金额" json:"amount"`
+	RepaidAt     int64          `gorm:"not null;comment:还款时间" json:"repaid_at"`
+	BillRecordID uint           `gorm:"default:0;comment:关联的还款账单ID" json:"bill_record_id"`
+	Remark       string         `gorm:"size:255;comment:备注" json:"remark"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
+
+// LoanDirection 借贷方向枚举
+type LoanDirection uint8
+
+const (
+	LoanLent     LoanDirection = 1 // 借出（对方欠我）
+	LoanBorrowed LoanDirection = 2 // 借入（我欠对方）
+)
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Merchant 商户表（规范化后的交易对方），按账本区分
+type Merchant struct {
+	ID        uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    uint           `gorm:"index;not null;comment:用户ID" json:"user_id"`
+	LedgerID  uint           `gorm:"index;not null;default:0;comment:账本ID" json:"ledger_id"`
+	Name      string         `gorm:"size:255;not null;comment:商户名称" json:"name"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
+
+// MerchantAlias 商户别名及匹配规则表
+type MerchantAlias struct {
+	ID         uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID     uint           `gorm:"index;not null;comment:用户ID" json:"user_id"`
+	LedgerID   uint           `gorm:"index;not null;default:0;comment:账本ID" json:"ledger_id"`
+	MerchantID uint           `gorm:"index;not null;comment:商户ID" json:"merchant_id"`
+	MatchType  uint8          `gorm:"not null;comment:匹配方式（1完全匹配、2包含、3正则）" json:"match_type"`
+	Pattern    string         `gorm:"size:255;not null;comment:匹配内容" json:"pattern"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
+
+// MatchType 匹配方式枚举
+type MatchType uint8
+
+const (
+	MatchTypeExact    MatchType = 1 // 完全匹配
+	MatchTypeContains MatchType = 2 // 包含
+	MatchTypeRegexp   MatchType = 3 // 正则
+)
@@ -0,0 +1,55 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// NetWorthItem 资产负债项表（账户、房产、车辆、房贷等）
+type NetWorthItem struct {
+	ID            uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID        uint           `gorm:"index;not null;comment:用户ID" json:"user_id"`
+	LedgerID      uint           `gorm:"index;not null;default:0;comment:账本ID" json:"ledger_id"`
+	Name          string         `gorm:"size:100;not null;comment:名称" json:"name"`
+	Type          uint8          `gorm:"not null;comment:类型（1资产、2负债）" json:"type"`
+	Category      string         `gorm:"size:100;comment:分类（账户、房产、车辆、房贷等）" json:"category"`
+	PaymentMethod string         `gorm:"size:255;comment:关联账户，设置后按估值后的收支自动更新余额（仅资产）" json:"payment_method"`
+	Value         float64        `gorm:"type:decimal(14,2);comment:估值（关联账户时为估值时的余额）" json:"value"`
+	ValuedAt      int64          `gorm:"not null;comment:估值时间" json:"valued_at"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
+
+// NetWorthSnapshot 净资产快照表，每个账本每月一条
+type NetWorthSnapshot struct {
+	ID               uint                   `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID           uint                   `gorm:"index:net_worth_user_month;not null;comment:用户ID" json:"user_id"`
+	LedgerID         uint                   `gorm:"index:net_worth_ledger_month;not null;default:0;comment:账本ID" json:"ledger_id"`
+	Month            string                 `gorm:"index:net_worth_user_month;index:net_worth_ledger_month;size:7;not null;comment:快照月份（2006-01）" json:"month"`
+	TakenAt          int64                  `gorm:"not null;comment:快照时间" json:"taken_at"`
+	TotalAssets      float64                `gorm:"type:decimal(14,2);comment:总资产" json:"total_assets"`
+	TotalLiabilities float64                `gorm:"type:decimal(14,2);comment:总负债" json:"total_liabilities"`
+	NetWorth         float64                `gorm:"type:decimal(14,2);comment:净资产" json:"net_worth"`
+	Items            []NetWorthSnapshotItem `gorm:"type:text;serializer:json;comment:各项明细" json:"items"`
+	CreatedAt        time.Time              `json:"created_at"`
+	UpdatedAt        time.Time              `json:"updated_at"`
+}
+
+// NetWorthSnapshotItem 快照中的单项估值
+type NetWorthSnapshotItem struct {
+	ItemID   uint    `json:"item_id"`
+	Name     string  `json:"name"`
+	Type     uint8   `json:"type"`
+	Category string  `json:"category"`
+	Value    float64 `json:"value"`
+}
+
+// NetWorthItemType 资产负债类型枚举
+type NetWorthItemType uint8
+
+const (
+	NetWorthItemAsset     NetWorthItemType = 1 // 资产
+	NetWorthItemLiability NetWorthItemType = 2 // 负债
+)
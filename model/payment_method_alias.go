@@ -0,0 +1,19 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PaymentMethodAlias 交易方式别名表（原始账户名称 → 归类后的账户名称），按账本区分
+type PaymentMethodAlias struct {
+	ID        uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    uint           `gorm:"index;not null;comment:用户ID" json:"user_id"`
+	LedgerID  uint           `gorm:"index;not null;default:0;comment:账本ID" json:"ledger_id"`
+	RawName   string         `gorm:"size:255;not null;comment:原始账户名称" json:"raw_name"`
+	Name      string         `gorm:"size:255;not null;comment:归类后的账户名称" json:"name"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
@@ -0,0 +1,68 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RecurringBill 周期记账模板表（房租、工资、贷款等）
+type RecurringBill struct {
+	ID            uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID        uint           `gorm:"index;not null;comment:用户ID" json:"user_id"`
+	LedgerID      uint           `gorm:"index;comment:账本ID（生成的账单归入该账本，0为用户默认账本）" json:"ledger_id"`
+	Name          string         `gorm:"size:100;not null;comment:模板名称" json:"name"`
+	Platform      uint8          `gorm:"comment:平台（支付宝、微信）" json:"platform"`
+	IncomeType    uint8          `gorm:"comment:收支类型（1收入、2支出、3不记收支）" json:"income_type"`
+	TradeType     string         `gorm:"size:255;comment:交易类型（分类）" json:"trade_type"`
+	ProductName   string         `gorm:"size:255;comment:商品（交易名称）" json:"product_name"`
+	Counterparty  string         `gorm:"size:255;comment:交易对方（商户名称）" json:"counterparty"`
+	PaymentMethod string         `gorm:"size:255;comment:交易方式（余额、银行卡）" json:"payment_method"`
+	Amount        float64        `gorm:"type:decimal(10,2);comment:金额" json:"amount"`
+	Remark        string         `gorm:"size:255;comment:备注" json:"remark"`
+	Frequency     uint8          `gorm:"not null;comment:重复频率（1每天、2每周、3每月、4每年）" json:"frequency"`
+	Interval      int            `gorm:"not null;default:1;comment:重复间隔（每N个频率单位）" json:"interval"`
+	DayOfMonth    int            `gorm:"comment:每月第几日（按月重复时有效，超出当月天数取月末）" json:"day_of_month"`
+	StartAt       int64          `gorm:"not null;comment:开始时间（首次发生时间）" json:"start_at"`
+	EndAt         int64          `gorm:"comment:结束时间，0为不结束" json:"end_at"`
+	NextIndex     int            `gorm:"comment:下一次待生成的期数" json:"next_index"`
+	NextRunAt     int64          `gorm:"index;comment:下一次生成时间，0为已结束" json:"next_run_at"`
+	Enabled       bool           `gorm:"not null;comment:是否启用" json:"enabled"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
+
+// RecurringBillOccurrence 周期记账单次发生记录表（已生成、已跳过或单独修改的期次）
+type RecurringBillOccurrence struct {
+	ID              uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID          uint           `gorm:"index;not null;comment:用户ID" json:"user_id"`
+	RecurringBillID uint           `gorm:"index:recurring_scheduled;not null;comment:模板ID" json:"recurring_bill_id"`
+	ScheduledAt     int64          `gorm:"index:recurring_scheduled;not null;comment:计划发生时间" json:"scheduled_at"`
+	Status          uint8          `gorm:"not null;comment:状态（1已修改待生成、2已生成、3已跳过）" json:"status"`
+	Amount          float64        `gorm:"type:decimal(10,2);comment:单独修改的金额，0为使用模板金额" json:"amount"`
+	Remark          string         `gorm:"size:255;comment:单独修改的备注，为空使用模板备注" json:"remark"`
+	BillRecordID    uint           `gorm:"comment:生成的账单ID" json:"bill_record_id"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
+
+// Frequency 重复频率枚举
+type Frequency uint8
+
+const (
+	FrequencyDaily   Frequency = 1 // 每天
+	FrequencyWeekly  Frequency = 2 // 每周
+	FrequencyMonthly Frequency = 3 // 每月
+	FrequencyYearly  Frequency = 4 // 每年
+)
+
+// OccurrenceStatus 周期记账期次状态枚举
+type OccurrenceStatus uint8
+
+const (
+	OccurrenceStatusEdited    OccurrenceStatus = 1 // 已修改待生成
+	OccurrenceStatusGenerated OccurrenceStatus = 2 // 已生成
+	OccurrenceStatusSkipped   OccurrenceStatus = 3 // 已跳过
+)
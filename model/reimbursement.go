@@ -0,0 +1,42 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ReimbursementClaim 报销单表
+type ReimbursementClaim struct {
+	ID                 uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID             uint           `gorm:"index;not null;comment:用户ID" json:"user_id"`
+	Title              string         `gorm:"size:100;not null;comment:报销单标题" json:"title"`
+	Status             uint8          `gorm:"not null;default:1;comment:状态（1未提交、2已提交、3已批准、4已到账）" json:"status"`
+	SubmittedAt        int64          `gorm:"comment:提交时间" json:"submitted_at"`
+	ApprovedAt         int64          `gorm:"comment:批准时间" json:"approved_at"`
+	PaidAt             int64          `gorm:"comment:到账时间" json:"paid_at"`
+	IncomeBillRecordID uint           `gorm:"index;default:0;comment:匹配到的报销收入账单ID" json:"income_bill_record_id"`
+	Remark             string         `gorm:"size:255;comment:备注" json:"remark"`
+	CreatedAt          time.Time      `json:"created_at"`
+	UpdatedAt          time.Time      `json:"updated_at"`
+	DeletedAt          gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
+
+// ReimbursementItem 可报销账单表，每条支出账单至多标记一次
+type ReimbursementItem struct {
+	ID           uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID       uint      `gorm:"index;not null;comment:用户ID" json:"user_id"`
+	BillRecordID uint      `gorm:"uniqueIndex;not null;comment:账单ID" json:"bill_record_id"`
+	ClaimID      uint      `gorm:"index;default:0;comment:报销单ID，0为尚未归入报销单" json:"claim_id"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// ReimbursementStatus 报销单状态枚举
+type ReimbursementStatus uint8
+
+const (
+	ReimbursementDraft     ReimbursementStatus = 1 // 未提交
+	ReimbursementSubmitted ReimbursementStatus = 2 // 已提交
+	ReimbursementApproved  ReimbursementStatus = 3 // 已批准
+	ReimbursementPaid      ReimbursementStatus = 4 // 已到账
+)
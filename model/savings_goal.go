@@ -0,0 +1,24 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SavingsGoal 储蓄目标表，进度由关联账户或标签下的账单计算
+type SavingsGoal struct {
+	ID            uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID        uint           `gorm:"index;not null;comment:用户ID" json:"user_id"`
+	LedgerID      uint           `gorm:"index;not null;default:0;comment:账本ID" json:"ledger_id"`
+	Name          string         `gorm:"size:100;not null;comment:目标名称" json:"name"`
+	TargetAmount  float64        `gorm:"type:decimal(12,2);not null;comment:目标金额" json:"target_amount"`
+	InitialAmount float64        `gorm:"type:decimal(12,2);comment:开始前已有金额" json:"initial_amount"`
+	StartAt       int64          `gorm:"not null;comment:开始时间，此后的账单计入进度" json:"start_at"`
+	Deadline      int64          `gorm:"comment:截止时间，0为不限" json:"deadline"`
+	PaymentMethod string         `gorm:"size:255;comment:关联账户（按收入减支出计入）" json:"payment_method"`
+	TagID         uint           `gorm:"default:0;comment:关联标签（标签下账单金额全部计入）" json:"tag_id"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
@@ -0,0 +1,76 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SplitGroup AA 分摊组表（一次旅行、聚餐等）
+type SplitGroup struct {
+	ID        uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    uint           `gorm:"index;not null;comment:用户ID" json:"user_id"`
+	Name      string         `gorm:"size:100;not null;comment:名称" json:"name"`
+	Remark    string         `gorm:"size:255;comment:备注" json:"remark"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
+
+// SplitGroupMember 分摊组成员表，PersonID 为0表示本人
+type SplitGroupMember struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	GroupID   uint      `gorm:"uniqueIndex:idx_split_group_person;not null;comment:分摊组ID" json:"group_id"`
+	PersonID  uint      `gorm:"uniqueIndex:idx_split_group_person;not null;default:0;comment:联系人ID，0为本人" json:"person_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SplitExpense 分摊账目表
+type SplitExpense struct {
+	ID            uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID        uint           `gorm:"index;not null;comment:用户ID" json:"user_id"`
+	GroupID       uint           `gorm:"index;not null;comment:分摊组ID" json:"group_id"`
+	BillRecordID  uint           `gorm:"default:0;comment:关联的账单ID" json:"bill_record_id"`
+	PayerMemberID uint           `gorm:"not null;comment:付款成员ID" json:"payer_member_id"`
+	Title         string         `gorm:"size:255;comment:名称" json:"title"`
+	Amount        float64        `gorm:"type:decimal(12,2);not null;comment:金额" json:"amount"`
+	SpentAt       int64          `gorm:"not null;comment:消费时间" json:"spent_at"`
+	SplitMode     uint8          `gorm:"not null;comment:分摊方式（1均摊、2按比例、3指定金额）" json:"split_mode"`
+	Shares        []SplitShare   `gorm:"foreignKey:ExpenseID" json:"shares"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
+
+// SplitShare 分摊账目的成员份额表
+type SplitShare struct {
+	ID        uint    `gorm:"primaryKey;autoIncrement" json:"id"`
+	ExpenseID uint    `gorm:"index;not null;comment:分摊账目ID" json:"expense_id"`
+	MemberID  uint    `gorm:"not null;comment:成员ID" json:"member_id"`
+	Value     float64 `gorm:"type:decimal(12,2);comment:分摊参数（按比例为百分比，指定金额为金额，均摊为0）" json:"value"`
+	Amount    float64 `gorm:"type:decimal(12,2);not null;comment:应付金额" json:"amount"`
+}
+
+// SplitSettlement 分摊组成员间的结算转账表
+type SplitSettlement struct {
+	ID           uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID       uint           `gorm:"index;not null;comment:用户ID" json:"user_id"`
+	GroupID      uint           `gorm:"index;not null;comment:分摊组ID" json:"group_id"`
+	FromMemberID uint           `gorm:"not null;comment:付款成员ID" json:"from_member_id"`
+	ToMemberID   uint           `gorm:"not null;comment:收款成员ID" json:"to_member_id"`
+	Amount       float64        `gorm:"type:decimal(12,2);not null;comment:金额" json:"amount"`
+	SettledAt    int64          `gorm:"not null;comment:结算时间" json:"settled_at"`
+	BillRecordID uint           `gorm:"default:0;comment:关联的转账账单ID" json:"bill_record_id"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
+
+// SplitMode 分摊方式枚举
+type SplitMode uint8
+
+const (
+	SplitEqual   SplitMode = 1 // 均摊
+	SplitPercent SplitMode = 2 // 按比例
+	SplitExact   SplitMode = 3 // 指定金额
+)
@@ -0,0 +1,81 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Subscription 周期性扣费（订阅）表
+type Subscription struct {
+	ID            uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID        uint           `gorm:"index;not null;comment:用户ID" json:"user_id"`
+	LedgerID      uint           `gorm:"index;not null;default:0;comment:账本ID" json:"ledger_id"`
+	MerchantID    uint           `gorm:"default:0;comment:商户ID" json:"merchant_id"`
+	Counterparty  string         `gorm:"size:255;comment:交易对方" json:"counterparty"`
+	Name          string         `gorm:"size:255;comment:订阅名称" json:"name"`
+	TradeType     string         `gorm:"size:255;comment:交易类型（分类）" json:"trade_type"`
+	PaymentMethod string         `gorm:"size:255;comment:交易方式" json:"payment_method"`
+	Cadence       uint8          `gorm:"not null;comment:扣费周期（1每周、2每两周、3每月、4每季度、5每年）" json:"cadence"`
+	Amount        float64        `gorm:"type:decimal(10,2);comment:每期金额" json:"amount"`
+	Occurrences   int            `gorm:"comment:已扣费次数" json:"occurrences"`
+	FirstTradeAt  int64          `gorm:"comment:首次扣费时间" json:"first_trade_at"`
+	LastTradeAt   int64          `gorm:"comment:最近扣费时间" json:"last_trade_at"`
+	NextTradeAt   int64          `gorm:"comment:预计下次扣费时间" json:"next_trade_at"`
+	Status        uint8          `gorm:"not null;default:1;comment:状态（1待确认、2已确认、3已忽略）" json:"status"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
+
+// Cadence 扣费周期枚举
+type Cadence uint8
+
+const (
+	CadenceWeekly    Cadence = 1 // 每周
+	CadenceBiweekly  Cadence = 2 // 每两周
+	CadenceMonthly   Cadence = 3 // 每月
+	CadenceQuarterly Cadence = 4 // 每季度
+	CadenceYearly    Cadence = 5 // 每年
+)
+
+// Next 返回下一次扣费时间
+func (c Cadence) Next(t time.Time) time.Time {
+	switch c {
+	case CadenceWeekly:
+		return t.AddDate(0, 0, 7)
+	case CadenceBiweekly:
+		return t.AddDate(0, 0, 14)
+	case CadenceMonthly:
+		return t.AddDate(0, 1, 0)
+	case CadenceQuarterly:
+		return t.AddDate(0, 3, 0)
+	default:
+		return t.AddDate(1, 0, 0)
+	}
+}
+
+// MonthlyFactor 返回每期金额折算为月均金额的系数
+func (c Cadence) MonthlyFactor() float64 {
+	switch c {
+	case CadenceWeekly:
+		return 52.0 / 12
+	case CadenceBiweekly:
+		return 26.0 / 12
+	case CadenceMonthly:
+		return 1
+	case CadenceQuarterly:
+		return 1.0 / 3
+	default:
+		return 1.0 / 12
+	}
+}
+
+// SubscriptionStatus 订阅状态枚举
+type SubscriptionStatus uint8
+
+const (
+	SubscriptionStatusDetected  SubscriptionStatus = 1 // 待确认
+	SubscriptionStatusConfirmed SubscriptionStatus = 2 // 已确认
+	SubscriptionStatusDismissed SubscriptionStatus = 3 // 已忽略
+)
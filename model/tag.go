@@ -0,0 +1,25 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Tag 标签表，按账本区分
+type Tag struct {
+	ID        uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    uint           `gorm:"index;not null;comment:用户ID" json:"user_id"`
+	LedgerID  uint           `gorm:"index;not null;default:0;comment:账本ID" json:"ledger_id"`
+	Name      string         `gorm:"size:100;not null;comment:标签名称" json:"name"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
+
+// BillRecordTag 账单标签关联表
+type BillRecordTag struct {
+	BillRecordID uint      `gorm:"primaryKey;comment:账单ID" json:"bill_record_id"`
+	TagID        uint      `gorm:"primaryKey;index;comment:标签ID" json:"tag_id"`
+	CreatedAt    time.Time `json:"created_at"`
+}
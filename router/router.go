@@ -3,6 +3,7 @@ package router
 import (
 	"github.com/zxc7563598/fintrack-backend/controller"
 	"github.com/zxc7563598/fintrack-backend/middleware"
+	"github.com/zxc7563598/fintrack-backend/model"
 
 	"github.com/gin-gonic/gin"
 )
@@ -26,49 +27,193 @@ func SetupRouter() *gin.Engine {
 	// 需要认证的路由
 	authGroup := r.Group("/api", middleware.AuthMiddleware())
 	{
-		authGroup.POST("/asset-overview", controller.AssetOverviewHandler)
+		authGroup.POST("/asset-overview", middleware.LedgerMiddleware(model.LedgerViewer), middleware.DecryptMiddleware[controller.AssetOverviewRequest](), controller.AssetOverviewHandler)
 
 		authGroup.POST("/user/email", controller.GetUserEmailsHandler)
 		authGroup.POST("/user/email/save", middleware.DecryptMiddleware[controller.StoreUserEmailRequest](), controller.StoreUserEmailHandler)
 		authGroup.POST("/user/email/delete", middleware.DecryptMiddleware[controller.DeleteUserEmailRequest](), controller.DeleteUserEmailHandler)
 		authGroup.POST("/user/deepseek/api-key", controller.GetDeepseekApiKeyHandler)
 		authGroup.POST("/user/deepseek/api-key/store", middleware.DecryptMiddleware[controller.StoreDeepseekApiKeyRequest](), controller.StoreDeepseekApiKeyHandler)
-		authGroup.POST("/user/payment-method", controller.GetPaymentMethodHandler)
-		authGroup.POST("/user/payment-method/organize", controller.OrganizePaymentMethodHandler)
-		authGroup.POST("/user/payment-method/store", middleware.DecryptMiddleware[controller.StorePaymentMethodRequest](), controller.StorePaymentMethodHandler)
+		authGroup.POST("/user/payment-method", middleware.LedgerMiddleware(model.LedgerViewer), controller.GetPaymentMethodHandler)
+		authGroup.POST("/user/payment-method/organize", middleware.LedgerMiddleware(model.LedgerEditor), controller.OrganizePaymentMethodHandler)
+		authGroup.POST("/user/payment-method/store", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.StorePaymentMethodRequest](), controller.StorePaymentMethodHandler)
+		authGroup.POST("/user/payment-method/revert", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.RevertPaymentMethodRequest](), controller.RevertPaymentMethodHandler)
 		authGroup.POST("/user/info", controller.GetUserInfoHandler)
 		authGroup.POST("/user/info/store", middleware.DecryptMiddleware[controller.StoreUserInfoRequest](), controller.StoreUserInfoHandler)
 
-		authGroup.POST("/bills", middleware.DecryptMiddleware[controller.GetBillListRequest](), controller.GetBillListHandler)
-		authGroup.POST("/bills/calendar", middleware.DecryptMiddleware[controller.GetBillCalendarRequest](), controller.GetBillCalendarHandler)
-		authGroup.POST("/bills/info", middleware.DecryptMiddleware[controller.GetBillInfoRequest](), controller.GetBillInfoHandler)
-		authGroup.POST("/bills/save", middleware.DecryptMiddleware[controller.StoreBillRecordRequest](), controller.StoreBillRecordHandler)
-		authGroup.POST("/bills/delete", middleware.DecryptMiddleware[controller.DeleteBillRecordRequest](), controller.DeleteBillRecordHandler)
-		authGroup.POST("/bills/export", middleware.DecryptMiddleware[controller.ExportBillRequest](), controller.ExportBillHandler)
-		authGroup.POST("/bills/analysis", middleware.DecryptMiddleware[controller.AnalysisBillRequest](), controller.AnalysisBillHandler)
-
-		authGroup.POST("/file/alipay/upload/csv", controller.UploadAlipayCSVHandler)
-		authGroup.POST("/file/alipay/upload/zip", controller.UploadAlipayZIPHandler)
-		authGroup.POST("/file/wechat/upload/xlsx", controller.UploadWeChatXLSXHandler)
-		authGroup.POST("/file/wechat/upload/zip", controller.UploadWeChatZIPHandler)
-
-		authGroup.POST("/file/alipay/overview", middleware.DecryptMiddleware[controller.GetAlipayCSVOverviewRequest](), controller.GetAlipayCSVOverviewHandler)
-		authGroup.POST("/file/wechat/overview", middleware.DecryptMiddleware[controller.GetWeChatXLSXOverviewRequest](), controller.GetWeChatXLSXOverviewHandler)
-		authGroup.POST("/file/alipay/store", middleware.DecryptMiddleware[controller.StoreAlipayCSVInfoRequest](), controller.StoreAlipayCSVInfoHandler)
-		authGroup.POST("/file/wechat/store", middleware.DecryptMiddleware[controller.StoreWechatXLSXInfoRequest](), controller.StoreWechatXLSXInfoHandler)
-
-		authGroup.POST("/file/alipay/email", middleware.DecryptMiddleware[controller.GetAlipayBillMailRequest](), controller.GetAlipayBillMailHandler)
-
-		authGroup.POST("/statistics/account/category", middleware.DecryptMiddleware[controller.GetStatisticsRequest](), controller.AccountBalanceCategoryHandler)
-		authGroup.POST("/statistics/income/category", middleware.DecryptMiddleware[controller.GetStatisticsRequest](), controller.IncomeCategoryHandler)
-		authGroup.POST("/statistics/expense/category", middleware.DecryptMiddleware[controller.GetStatisticsRequest](), controller.ExpenseCategoryHandler)
-		authGroup.POST("/statistics/income/account/category", middleware.DecryptMiddleware[controller.GetStatisticsRequest](), controller.IncomeAccountCategoryHandler)
-		authGroup.POST("/statistics/expense/account/category", middleware.DecryptMiddleware[controller.GetStatisticsRequest](), controller.ExpenseAccountCategoryHandler)
-		authGroup.POST("/statistics/account-balance/trend", middleware.DecryptMiddleware[controller.GetStatisticsRequest](), controller.AccountBalanceTrendHandler)
-		authGroup.POST("/statistics/income/trend", middleware.DecryptMiddleware[controller.GetStatisticsRequest](), controller.IncomeCategoryTrendHandler)
-		authGroup.POST("/statistics/expense/trend", middleware.DecryptMiddleware[controller.GetStatisticsRequest](), controller.ExpenseCategoryTrendHandler)
-		authGroup.POST("/statistics/income/account/trend", middleware.DecryptMiddleware[controller.GetStatisticsRequest](), controller.IncomeAccountTrendHandler)
-		authGroup.POST("/statistics/expense/account/trend", middleware.DecryptMiddleware[controller.GetStatisticsRequest](), controller.ExpenseAccountTrendHandler)
+		authGroup.POST("/bills", middleware.LedgerMiddleware(model.LedgerViewer), middleware.DecryptMiddleware[controller.GetBillListRequest](), controller.GetBillListHandler)
+		authGroup.POST("/bills/calendar", middleware.LedgerMiddleware(model.LedgerViewer), middleware.DecryptMiddleware[controller.GetBillCalendarRequest](), controller.GetBillCalendarHandler)
+		authGroup.POST("/bills/info", middleware.LedgerMiddleware(model.LedgerViewer), middleware.DecryptMiddleware[controller.GetBillInfoRequest](), controller.GetBillInfoHandler)
+		authGroup.POST("/bills/save", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.StoreBillRecordRequest](), controller.StoreBillRecordHandler)
+		authGroup.POST("/bills/splits/save", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.StoreBillSplitsRequest](), controller.StoreBillSplitsHandler)
+		authGroup.POST("/bills/delete", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.DeleteBillRecordRequest](), controller.DeleteBillRecordHandler)
+		authGroup.POST("/bills/move", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.MoveBillRecordsRequest](), controller.MoveBillRecordsHandler)
+		authGroup.POST("/bills/bulk/update", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.BulkUpdateBillRecordsRequest](), controller.BulkUpdateBillRecordsHandler)
+		authGroup.POST("/bills/bulk/delete", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.BillBulkScope](), controller.BulkDeleteBillRecordsHandler)
+		authGroup.POST("/bills/duplicates", middleware.LedgerMiddleware(model.LedgerViewer), middleware.DecryptMiddleware[controller.FindDuplicateBillsRequest](), controller.FindDuplicateBillsHandler)
+		authGroup.POST("/bills/duplicates/merge", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.MergeBillRecordsRequest](), controller.MergeBillRecordsHandler)
+		authGroup.POST("/bills/merges", middleware.LedgerMiddleware(model.LedgerViewer), middleware.DecryptMiddleware[controller.GetBillMergeListRequest](), controller.GetBillMergeListHandler)
+		authGroup.POST("/bills/history", middleware.LedgerMiddleware(model.LedgerViewer), middleware.DecryptMiddleware[controller.GetBillHistoryRequest](), controller.GetBillHistoryHandler)
+		authGroup.POST("/bills/history/revert", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.RevertBillRecordRequest](), controller.RevertBillRecordHandler)
+		authGroup.POST("/bills/trash", middleware.LedgerMiddleware(model.LedgerViewer), middleware.DecryptMiddleware[controller.GetBillTrashListRequest](), controller.GetBillTrashListHandler)
+		authGroup.POST("/bills/trash/restore", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.BillTrashRequest](), controller.RestoreBillRecordsHandler)
+		authGroup.POST("/bills/trash/purge", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.BillTrashRequest](), controller.PurgeBillRecordsHandler)
+		authGroup.POST("/bills/attachments", middleware.LedgerMiddleware(model.LedgerViewer), middleware.DecryptMiddleware[controller.GetBillAttachmentListRequest](), controller.GetBillAttachmentListHandler)
+		authGroup.POST("/bills/attachments/upload", middleware.LedgerMiddleware(model.LedgerEditor), controller.UploadBillAttachmentHandler)
+		authGroup.POST("/bills/attachments/download", middleware.LedgerMiddleware(model.LedgerViewer), middleware.DecryptMiddleware[controller.DownloadBillAttachmentRequest](), controller.DownloadBillAttachmentHandler)
+		authGroup.POST("/bills/attachments/delete", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.DeleteBillAttachmentRequest](), controller.DeleteBillAttachmentHandler)
+		authGroup.POST("/bills/export", middleware.LedgerMiddleware(model.LedgerViewer), middleware.DecryptMiddleware[controller.ExportBillRequest](), controller.ExportBillHandler)
+		authGroup.POST("/bills/analysis", middleware.LedgerMiddleware(model.LedgerViewer), middleware.DecryptMiddleware[controller.AnalysisBillRequest](), controller.AnalysisBillHandler)
+
+		authGroup.POST("/tags", middleware.LedgerMiddleware(model.LedgerViewer), controller.GetTagListHandler)
+		authGroup.POST("/tags/save", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.StoreTagRequest](), controller.StoreTagHandler)
+		authGroup.POST("/tags/delete", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.DeleteTagRequest](), controller.DeleteTagHandler)
+		authGroup.POST("/bills/tags/add", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.TagBillRecordsRequest](), controller.TagBillRecordsHandler)
+		authGroup.POST("/bills/tags/remove", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.TagBillRecordsRequest](), controller.UntagBillRecordsHandler)
+
+		authGroup.POST("/merchants", middleware.LedgerMiddleware(model.LedgerViewer), controller.GetMerchantListHandler)
+		authGroup.POST("/merchants/save", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.StoreMerchantRequest](), controller.StoreMerchantHandler)
+		authGroup.POST("/merchants/delete", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.DeleteMerchantRequest](), controller.DeleteMerchantHandler)
+		authGroup.POST("/merchants/apply", middleware.LedgerMiddleware(model.LedgerEditor), controller.ApplyMerchantHandler)
+
+		authGroup.POST("/rules", middleware.LedgerMiddleware(model.LedgerViewer), controller.GetBillRuleListHandler)
+		authGroup.POST("/rules/save", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.StoreBillRuleRequest](), controller.StoreBillRuleHandler)
+		authGroup.POST("/rules/delete", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.DeleteBillRuleRequest](), controller.DeleteBillRuleHandler)
+		authGroup.POST("/rules/run", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.RunBillRuleRequest](), controller.RunBillRuleHandler)
+
+		authGroup.POST("/subscriptions", middleware.LedgerMiddleware(model.LedgerViewer), middleware.DecryptMiddleware[controller.GetSubscriptionListRequest](), controller.GetSubscriptionListHandler)
+		authGroup.POST("/subscriptions/detect", middleware.LedgerMiddleware(model.LedgerEditor), controller.DetectSubscriptionHandler)
+		authGroup.POST("/subscriptions/confirm", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.UpdateSubscriptionRequest](), controller.ConfirmSubscriptionHandler)
+		authGroup.POST("/subscriptions/dismiss", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.UpdateSubscriptionRequest](), controller.DismissSubscriptionHandler)
+		authGroup.POST("/subscriptions/cost", middleware.LedgerMiddleware(model.LedgerViewer), controller.SubscriptionCostHandler)
+
+		// 周期记账模板按账本区分：读取需要查看权限，修改需要编辑权限
+		authGroup.POST("/recurring", middleware.LedgerMiddleware(model.LedgerViewer), controller.GetRecurringBillListHandler)
+		authGroup.POST("/recurring/save", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.StoreRecurringBillRequest](), controller.StoreRecurringBillHandler)
+		authGroup.POST("/recurring/delete", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.DeleteRecurringBillRequest](), controller.DeleteRecurringBillHandler)
+		authGroup.POST("/recurring/occurrences", middleware.LedgerMiddleware(model.LedgerViewer), middleware.DecryptMiddleware[controller.GetRecurringOccurrenceRequest](), controller.GetRecurringOccurrenceHandler)
+		authGroup.POST("/recurring/occurrence/skip", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.RecurringOccurrenceRequest](), controller.SkipRecurringOccurrenceHandler)
+		authGroup.POST("/recurring/occurrence/save", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.RecurringOccurrenceRequest](), controller.StoreRecurringOccurrenceHandler)
+
+		authGroup.POST("/budgets", middleware.LedgerMiddleware(model.LedgerViewer), middleware.DecryptMiddleware[controller.GetBudgetListRequest](), controller.GetBudgetListHandler)
+		authGroup.POST("/budgets/save", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.StoreBudgetRequest](), controller.StoreBudgetHandler)
+		authGroup.POST("/budgets/delete", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.DeleteBudgetRequest](), controller.DeleteBudgetHandler)
+		authGroup.POST("/budgets/progress", middleware.LedgerMiddleware(model.LedgerViewer), middleware.DecryptMiddleware[controller.BudgetProgressRequest](), controller.BudgetProgressHandler)
+		authGroup.POST("/budgets/history", middleware.LedgerMiddleware(model.LedgerViewer), middleware.DecryptMiddleware[controller.BudgetHistoryRequest](), controller.BudgetHistoryHandler)
+
+		authGroup.POST("/envelopes/mode", middleware.LedgerMiddleware(model.LedgerViewer), controller.GetEnvelopeModeHandler)
+		authGroup.POST("/envelopes/mode/store", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.StoreEnvelopeModeRequest](), controller.StoreEnvelopeModeHandler)
+		authGroup.POST("/envelopes", middleware.LedgerMiddleware(model.LedgerViewer), controller.GetEnvelopeListHandler)
+		authGroup.POST("/envelopes/save", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.StoreEnvelopeRequest](), controller.StoreEnvelopeHandler)
+		authGroup.POST("/envelopes/delete", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.DeleteEnvelopeRequest](), controller.DeleteEnvelopeHandler)
+		authGroup.POST("/envelopes/assign", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.AssignEnvelopeRequest](), controller.AssignEnvelopeHandler)
+		authGroup.POST("/envelopes/transfers/delete", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.DeleteEnvelopeTransferRequest](), controller.DeleteEnvelopeTransferHandler)
+		authGroup.POST("/envelopes/report", middleware.LedgerMiddleware(model.LedgerViewer), middleware.DecryptMiddleware[controller.EnvelopeReportRequest](), controller.EnvelopeReportHandler)
+
+		authGroup.POST("/goals", middleware.LedgerMiddleware(model.LedgerViewer), controller.GetSavingsGoalListHandler)
+		authGroup.POST("/goals/save", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.StoreSavingsGoalRequest](), controller.StoreSavingsGoalHandler)
+		authGroup.POST("/goals/delete", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.DeleteSavingsGoalRequest](), controller.DeleteSavingsGoalHandler)
+		authGroup.POST("/goals/progress", middleware.LedgerMiddleware(model.LedgerViewer), middleware.DecryptMiddleware[controller.SavingsGoalProgressRequest](), controller.SavingsGoalProgressHandler)
+
+		authGroup.POST("/credit/accounts", middleware.LedgerMiddleware(model.LedgerViewer), controller.GetCreditAccountListHandler)
+		authGroup.POST("/credit/accounts/save", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.StoreCreditAccountRequest](), controller.StoreCreditAccountHandler)
+		authGroup.POST("/credit/accounts/delete", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.DeleteCreditAccountRequest](), controller.DeleteCreditAccountHandler)
+		authGroup.POST("/credit/statements", middleware.LedgerMiddleware(model.LedgerViewer), middleware.DecryptMiddleware[controller.CreditStatementRequest](), controller.CreditStatementHandler)
+		authGroup.POST("/credit/upcoming", middleware.LedgerMiddleware(model.LedgerViewer), controller.CreditUpcomingDueHandler)
+		authGroup.POST("/credit/installments", middleware.LedgerMiddleware(model.LedgerViewer), middleware.DecryptMiddleware[controller.CreditInstallmentRequest](), controller.CreditInstallmentHandler)
+
+		authGroup.POST("/net-worth", middleware.LedgerMiddleware(model.LedgerViewer), controller.NetWorthHandler)
+		authGroup.POST("/net-worth/items", middleware.LedgerMiddleware(model.LedgerViewer), controller.GetNetWorthItemListHandler)
+		authGroup.POST("/net-worth/items/save", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.StoreNetWorthItemRequest](), controller.StoreNetWorthItemHandler)
+		authGroup.POST("/net-worth/items/delete", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.DeleteNetWorthItemRequest](), controller.DeleteNetWorthItemHandler)
+		authGroup.POST("/net-worth/snapshot", middleware.LedgerMiddleware(model.LedgerEditor), controller.TakeNetWorthSnapshotHandler)
+		authGroup.POST("/net-worth/trend", middleware.LedgerMiddleware(model.LedgerViewer), middleware.DecryptMiddleware[controller.NetWorthTrendRequest](), controller.NetWorthTrendHandler)
+
+		// 投资、借贷、报销、分摊组为用户个人数据，不区分账本；仅关联账单的接口校验当前账本的编辑权限
+		authGroup.POST("/investments", controller.GetInvestmentListHandler)
+		authGroup.POST("/investments/save", middleware.DecryptMiddleware[controller.StoreInvestmentRequest](), controller.StoreInvestmentHandler)
+		authGroup.POST("/investments/delete", middleware.DecryptMiddleware[controller.DeleteInvestmentRequest](), controller.DeleteInvestmentHandler)
+		authGroup.POST("/investments/transactions", middleware.DecryptMiddleware[controller.GetInvestmentTransactionRequest](), controller.GetInvestmentTransactionListHandler)
+		authGroup.POST("/investments/transactions/save", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.StoreInvestmentTransactionRequest](), controller.StoreInvestmentTransactionHandler)
+		authGroup.POST("/investments/transactions/delete", middleware.DecryptMiddleware[controller.DeleteInvestmentTransactionRequest](), controller.DeleteInvestmentTransactionHandler)
+		authGroup.POST("/investments/prices", middleware.DecryptMiddleware[controller.GetInvestmentPriceRequest](), controller.GetInvestmentPriceListHandler)
+		authGroup.POST("/investments/prices/save", middleware.DecryptMiddleware[controller.StoreInvestmentPriceRequest](), controller.StoreInvestmentPriceHandler)
+		authGroup.POST("/investments/prices/delete", middleware.DecryptMiddleware[controller.DeleteInvestmentPriceRequest](), controller.DeleteInvestmentPriceHandler)
+
+		authGroup.POST("/people", controller.GetPersonListHandler)
+		authGroup.POST("/people/save", middleware.DecryptMiddleware[controller.StorePersonRequest](), controller.StorePersonHandler)
+		authGroup.POST("/people/delete", middleware.DecryptMiddleware[controller.DeletePersonRequest](), controller.DeletePersonHandler)
+		authGroup.POST("/loans", middleware.DecryptMiddleware[controller.GetLoanListRequest](), controller.GetLoanListHandler)
+		authGroup.POST("/loans/save", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.StoreLoanRequest](), controller.StoreLoanHandler)
+		authGroup.POST("/loans/delete", middleware.DecryptMiddleware[controller.DeleteLoanRequest](), controller.DeleteLoanHandler)
+		authGroup.POST("/loans/repayments", middleware.DecryptMiddleware[controller.GetLoanRepaymentRequest](), controller.GetLoanRepaymentListHandler)
+		authGroup.POST("/loans/repayments/save", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.StoreLoanRepaymentRequest](), controller.StoreLoanRepaymentHandler)
+		authGroup.POST("/loans/repayments/delete", middleware.DecryptMiddleware[controller.DeleteLoanRepaymentRequest](), controller.DeleteLoanRepaymentHandler)
+		authGroup.POST("/loans/balances", controller.PersonBalanceHandler)
+		authGroup.POST("/loans/overdue", controller.OverdueLoanHandler)
+
+		authGroup.POST("/reimbursements/claims", controller.GetReimbursementClaimListHandler)
+		authGroup.POST("/reimbursements/claims/save", middleware.DecryptMiddleware[controller.StoreReimbursementClaimRequest](), controller.StoreReimbursementClaimHandler)
+		authGroup.POST("/reimbursements/claims/delete", middleware.DecryptMiddleware[controller.DeleteReimbursementClaimRequest](), controller.DeleteReimbursementClaimHandler)
+		authGroup.POST("/reimbursements/claims/status", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.UpdateReimbursementStatusRequest](), controller.UpdateReimbursementStatusHandler)
+		authGroup.POST("/reimbursements/claims/export", middleware.DecryptMiddleware[controller.ExportReimbursementClaimRequest](), controller.ExportReimbursementClaimHandler)
+		authGroup.POST("/reimbursements/match", middleware.LedgerMiddleware(model.LedgerEditor), controller.MatchReimbursementHandler)
+		authGroup.POST("/reimbursements/items", middleware.DecryptMiddleware[controller.GetReimbursableListRequest](), controller.GetReimbursableListHandler)
+		authGroup.POST("/reimbursements/items/mark", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.MarkReimbursableRequest](), controller.MarkReimbursableHandler)
+		authGroup.POST("/reimbursements/items/unmark", middleware.DecryptMiddleware[controller.UnmarkReimbursableRequest](), controller.UnmarkReimbursableHandler)
+
+		authGroup.POST("/split-groups", controller.GetSplitGroupListHandler)
+		authGroup.POST("/split-groups/save", middleware.DecryptMiddleware[controller.StoreSplitGroupRequest](), controller.StoreSplitGroupHandler)
+		authGroup.POST("/split-groups/delete", middleware.DecryptMiddleware[controller.DeleteSplitGroupRequest](), controller.DeleteSplitGroupHandler)
+		authGroup.POST("/split-groups/expenses", middleware.DecryptMiddleware[controller.SplitGroupDetailRequest](), controller.GetSplitExpenseListHandler)
+		authGroup.POST("/split-groups/expenses/save", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.StoreSplitExpenseRequest](), controller.StoreSplitExpenseHandler)
+		authGroup.POST("/split-groups/expenses/delete", middleware.DecryptMiddleware[controller.DeleteSplitExpenseRequest](), controller.DeleteSplitExpenseHandler)
+		authGroup.POST("/split-groups/settlements/save", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.StoreSplitSettlementRequest](), controller.StoreSplitSettlementHandler)
+		authGroup.POST("/split-groups/settlements/delete", middleware.DecryptMiddleware[controller.DeleteSplitSettlementRequest](), controller.DeleteSplitSettlementHandler)
+		authGroup.POST("/split-groups/settlements/match", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.SplitGroupDetailRequest](), controller.MatchSplitSettlementHandler)
+		authGroup.POST("/split-groups/balances", middleware.DecryptMiddleware[controller.SplitGroupDetailRequest](), controller.SplitBalanceHandler)
+
+		authGroup.POST("/ledgers", controller.GetLedgerListHandler)
+		authGroup.POST("/ledgers/save", middleware.DecryptMiddleware[controller.StoreLedgerRequest](), controller.StoreLedgerHandler)
+		authGroup.POST("/ledgers/delete", middleware.DecryptMiddleware[controller.LedgerRequest](), controller.DeleteLedgerHandler)
+		authGroup.POST("/ledgers/members", middleware.DecryptMiddleware[controller.LedgerRequest](), controller.GetLedgerMemberListHandler)
+		authGroup.POST("/ledgers/members/role", middleware.DecryptMiddleware[controller.UpdateLedgerMemberRequest](), controller.UpdateLedgerMemberHandler)
+		authGroup.POST("/ledgers/members/remove", middleware.DecryptMiddleware[controller.RemoveLedgerMemberRequest](), controller.RemoveLedgerMemberHandler)
+		authGroup.POST("/ledgers/invite", middleware.DecryptMiddleware[controller.InviteLedgerMemberRequest](), controller.InviteLedgerMemberHandler)
+		authGroup.POST("/ledgers/invite/revoke", middleware.DecryptMiddleware[controller.RevokeLedgerInvitationRequest](), controller.RevokeLedgerInvitationHandler)
+		authGroup.POST("/ledgers/invitations", controller.GetMyLedgerInvitationListHandler)
+		authGroup.POST("/ledgers/invitations/respond", middleware.DecryptMiddleware[controller.RespondLedgerInvitationRequest](), controller.RespondLedgerInvitationHandler)
+		authGroup.POST("/ledgers/backup", middleware.LedgerMiddleware(model.LedgerViewer), controller.BackupLedgerHandler)
+
+		authGroup.POST("/refunds", middleware.LedgerMiddleware(model.LedgerViewer), middleware.DecryptMiddleware[controller.GetRefundReportRequest](), controller.GetRefundReportHandler)
+		authGroup.POST("/refunds/match", middleware.LedgerMiddleware(model.LedgerEditor), controller.MatchRefundsHandler)
+		authGroup.POST("/refunds/link", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.LinkRefundRequest](), controller.LinkRefundHandler)
+		authGroup.POST("/refunds/unlink", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.UnlinkRefundRequest](), controller.UnlinkRefundHandler)
+
+		authGroup.POST("/file/alipay/upload/csv", middleware.LedgerMiddleware(model.LedgerEditor), controller.UploadAlipayCSVHandler)
+		authGroup.POST("/file/alipay/upload/zip", middleware.LedgerMiddleware(model.LedgerEditor), controller.UploadAlipayZIPHandler)
+		authGroup.POST("/file/wechat/upload/xlsx", middleware.LedgerMiddleware(model.LedgerEditor), controller.UploadWeChatXLSXHandler)
+		authGroup.POST("/file/wechat/upload/zip", middleware.LedgerMiddleware(model.LedgerEditor), controller.UploadWeChatZIPHandler)
+
+		authGroup.POST("/file/alipay/overview", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.GetAlipayCSVOverviewRequest](), controller.GetAlipayCSVOverviewHandler)
+		authGroup.POST("/file/wechat/overview", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.GetWeChatXLSXOverviewRequest](), controller.GetWeChatXLSXOverviewHandler)
+		authGroup.POST("/file/alipay/store", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.StoreAlipayCSVInfoRequest](), controller.StoreAlipayCSVInfoHandler)
+		authGroup.POST("/file/wechat/store", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.StoreWechatXLSXInfoRequest](), controller.StoreWechatXLSXInfoHandler)
+
+		authGroup.POST("/file/alipay/email", middleware.LedgerMiddleware(model.LedgerEditor), middleware.DecryptMiddleware[controller.GetAlipayBillMailRequest](), controller.GetAlipayBillMailHandler)
+
+		authGroup.POST("/statistics/account/category", middleware.LedgerMiddleware(model.LedgerViewer), middleware.DecryptMiddleware[controller.GetStatisticsRequest](), controller.AccountBalanceCategoryHandler)
+		authGroup.POST("/statistics/income/category", middleware.LedgerMiddleware(model.LedgerViewer), middleware.DecryptMiddleware[controller.GetStatisticsRequest](), controller.IncomeCategoryHandler)
+		authGroup.POST("/statistics/expense/category", middleware.LedgerMiddleware(model.LedgerViewer), middleware.DecryptMiddleware[controller.GetStatisticsRequest](), controller.ExpenseCategoryHandler)
+		authGroup.POST("/statistics/tag/category", middleware.LedgerMiddleware(model.LedgerViewer), middleware.DecryptMiddleware[controller.GetStatisticsRequest](), controller.TagCategoryHandler)
+		authGroup.POST("/statistics/merchant/category", middleware.LedgerMiddleware(model.LedgerViewer), middleware.DecryptMiddleware[controller.GetStatisticsRequest](), controller.MerchantCategoryHandler)
+		authGroup.POST("/statistics/income/account/category", middleware.LedgerMiddleware(model.LedgerViewer), middleware.DecryptMiddleware[controller.GetStatisticsRequest](), controller.IncomeAccountCategoryHandler)
+		authGroup.POST("/statistics/expense/account/category", middleware.LedgerMiddleware(model.LedgerViewer), middleware.DecryptMiddleware[controller.GetStatisticsRequest](), controller.ExpenseAccountCategoryHandler)
+		authGroup.POST("/statistics/account-balance/trend", middleware.LedgerMiddleware(model.LedgerViewer), middleware.DecryptMiddleware[controller.GetStatisticsRequest](), controller.AccountBalanceTrendHandler)
+		authGroup.POST("/statistics/income/trend", middleware.LedgerMiddleware(model.LedgerViewer), middleware.DecryptMiddleware[controller.GetStatisticsRequest](), controller.IncomeCategoryTrendHandler)
+		authGroup.POST("/statistics/expense/trend", middleware.LedgerMiddleware(model.LedgerViewer), middleware.DecryptMiddleware[controller.GetStatisticsRequest](), controller.ExpenseCategoryTrendHandler)
+		authGroup.POST("/statistics/income/account/trend", middleware.LedgerMiddleware(model.LedgerViewer), middleware.DecryptMiddleware[controller.GetStatisticsRequest](), controller.IncomeAccountTrendHandler)
+		authGroup.POST("/statistics/expense/account/trend", middleware.LedgerMiddleware(model.LedgerViewer), middleware.DecryptMiddleware[controller.GetStatisticsRequest](), controller.ExpenseAccountTrendHandler)
 	}
 	return r
 }
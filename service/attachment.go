@@ -0,0 +1,259 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/zxc7563598/fintrack-backend/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrAttachmentTooLarge = errors.New("附件大小超出限制")
+	ErrAttachmentEmpty    = errors.New("附件内容为空")
+)
+
+// 缩略图最长边（像素）
+const attachmentThumbnailSize = 320
+
+// 生成缩略图的图片最大像素数，超过的不生成，避免解码占用过多内存
+const attachmentThumbnailMaxPixels = 50_000_000
+
+// AttachmentStore 附件存储：文件按内容哈希保存在存储目录下，相同内容只保存一份
+type AttachmentStore struct {
+	db      *gorm.DB
+	dir     string
+	maxSize int64
+}
+
+// NewAttachmentStore 创建附件存储，maxSize 为单个附件大小上限（字节）
+func NewAttachmentStore(db *gorm.DB, dir string, maxSize int64) *AttachmentStore {
+	return &AttachmentStore{db: db, dir: dir, maxSize: maxSize}
+}
+
+// MaxSize 单个附件大小上限（字节）
+func (s *AttachmentStore) MaxSize() int64 {
+	return s.maxSize
+}
+
+// Path 附件文件的保存路径
+func (s *AttachmentStore) Path(f model.AttachmentFile) string {
+	return filepath.Join(s.dir, f.Hash[:2], f.Hash)
+}
+
+// ThumbnailPath 附件缩略图的保存路径
+func (s *AttachmentStore) ThumbnailPath(f model.AttachmentFile) string {
+	return filepath.Join(s.dir, f.Hash[:2], f.Hash+"_thumb.jpg")
+}
+
+// Attach 保存附件内容并创建账单附件记录，计算哈希后去重，图片同时生成缩略图
+// 文件记录与附件记录在同一事务中写入，事务以写入开始，与清理文件的事务互斥，避免文件在关联前被删除
+func (s *AttachmentStore) Attach(r io.Reader, attachment *model.BillAttachment) (model.AttachmentFile, error) {
+	var file model.AttachmentFile
+	if err := os.MkdirAll(s.dir, os.ModePerm); err != nil {
+		return file, err
+	}
+	tmp, err := os.CreateTemp(s.dir, "upload-*")
+	if err != nil {
+		return file, err
+	}
+	defer os.Remove(tmp.Name())
+	// 写入临时文件的同时计算哈希，多读一个字节用于判断是否超出大小限制
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), io.LimitReader(r, s.maxSize+1))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return file, err
+	}
+	if size > s.maxSize {
+		return file, ErrAttachmentTooLarge
+	}
+	if size == 0 {
+		return file, ErrAttachmentEmpty
+	}
+	candidate := model.AttachmentFile{Hash: hex.EncodeToString(hash.Sum(nil)), Size: size, MimeType: detectMimeType(tmp.Name())}
+	thumbTmp := tmp.Name() + "_thumb.jpg"
+	defer os.Remove(thumbTmp)
+	if strings.HasPrefix(candidate.MimeType, "image/") {
+		candidate.HasThumbnail = writeThumbnail(tmp.Name(), thumbTmp) == nil
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// 相同内容的文件已存在时直接复用，并发上传相同内容时以先写入的记录为准
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&candidate).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("hash = ?", candidate.Hash).First(&file).Error; err != nil {
+			return err
+		}
+		if err := placeAttachmentFile(tmp.Name(), s.Path(file)); err != nil {
+			return err
+		}
+		if file.HasThumbnail && candidate.HasThumbnail {
+			if err := placeAttachmentFile(thumbTmp, s.ThumbnailPath(file)); err != nil {
+				return err
+			}
+		}
+		attachment.AttachmentFileID = file.ID
+		return tx.Create(attachment).Error
+	})
+	return file, err
+}
+
+// 将临时文件移动到保存路径，已存在时保留原文件
+func placeAttachmentFile(tmp, path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Release 删除不再被任何账单附件引用的文件
+func (s *AttachmentStore) Release(fileIDs []uint) error {
+	if len(fileIDs) == 0 {
+		return nil
+	}
+	_, err := s.removeUnreferenced(s.db.Where("id IN ?", fileIDs))
+	return err
+}
+
+// RemoveOrphans 删除 before 之前保存且不再被引用的文件，返回删除的数量
+func (s *AttachmentStore) RemoveOrphans(before time.Time) (int, error) {
+	return s.removeUnreferenced(s.db.Where("created_at < ?", before))
+}
+
+// 删除查询范围内未被引用的文件及其记录，返回删除的数量
+// 每个文件在事务中重新确认未被引用后再删除记录和文件，与 Attach 互斥
+func (s *AttachmentStore) removeUnreferenced(scope *gorm.DB) (int, error) {
+	var files []model.AttachmentFile
+	if err := scope.Where("id NOT IN (?)", s.db.Model(&model.BillAttachment{}).Select("attachment_file_id")).Find(&files).Error; err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, f := range files {
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			result := tx.Where("id = ? AND id NOT IN (?)", f.ID, tx.Model(&model.BillAttachment{}).Select("attachment_file_id")).Delete(&model.AttachmentFile{})
+			if result.Error != nil || result.RowsAffected == 0 {
+				return result.Error
+			}
+			for _, path := range []string{s.Path(f), s.ThumbnailPath(f)} {
+				if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+					return err
+				}
+			}
+			removed++
+			return nil
+		})
+		if err != nil {
+			return removed, err
+		}
+	}
+	return removed, nil
+}
+
+// StartAttachmentCleanupJob 启动附件清理任务：按间隔删除不再被引用的文件（如账单彻底删除后遗留的）
+func StartAttachmentCleanupJob(store *AttachmentStore, interval time.Duration) {
+	go func() {
+		for {
+			if _, err := store.RemoveOrphans(time.Now().Add(-interval)); err != nil {
+				log.Printf("附件清理失败: %v", err)
+			}
+			time.Sleep(interval)
+		}
+	}()
+}
+
+// 根据文件开头的内容识别文件类型
+func detectMimeType(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return "application/octet-stream"
+	}
+	defer f.Close()
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(f, head)
+	return http.DetectContentType(head[:n])
+}
+
+// 生成图片缩略图（JPEG），透明部分以白色填充
+func writeThumbnail(src, dst string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	cfg, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return err
+	}
+	if cfg.Width*cfg.Height > attachmentThumbnailMaxPixels {
+		return errors.New("图片尺寸过大")
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	return jpeg.Encode(out, thumbnailImage(img, attachmentThumbnailSize), &jpeg.Options{Quality: 80})
+}
+
+// 将图片按比例缩小到最长边不超过 maxSide，每个目标像素取对应区域的平均色
+func thumbnailImage(src image.Image, maxSide int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	tw, th := w, h
+	if w > maxSide || h > maxSide {
+		if w >= h {
+			tw, th = maxSide, max(1, h*maxSide/w)
+		} else {
+			tw, th = max(1, w*maxSide/h), maxSide
+		}
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, tw, th))
+	for y := 0; y < th; y++ {
+		y0, y1 := b.Min.Y+y*h/th, b.Min.Y+(y+1)*h/th
+		for x := 0; x < tw; x++ {
+			x0, x1 := b.Min.X+x*w/tw, b.Min.X+(x+1)*w/tw
+			var r, g, bl, n uint64
+			for sy := y0; sy < max(y1, y0+1); sy++ {
+				for sx := x0; sx < max(x1, x0+1); sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					// 颜色为预乘透明度的值，叠加白色背景
+					r += uint64(pr + 0xffff - pa)
+					g += uint64(pg + 0xffff - pa)
+					bl += uint64(pb + 0xffff - pa)
+					n++
+				}
+			}
+			dst.Set(x, y, color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(bl / n), A: 0xffff})
+		}
+	}
+	return dst
+}
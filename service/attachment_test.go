@@ -0,0 +1,106 @@
+package service
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestThumbnailImage(t *testing.T) {
+	// 生成 w x h 的图片，每个像素的颜色由 fill 决定
+	newImage := func(w, h int, fill func(x, y int) color.Color) image.Image {
+		img := image.NewNRGBA(image.Rect(0, 0, w, h))
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				img.Set(x, y, fill(x, y))
+			}
+		}
+		return img
+	}
+	solid := func(c color.Color) func(x, y int) color.Color {
+		return func(x, y int) color.Color { return c }
+	}
+	red := color.NRGBA{R: 0xff, A: 0xff}
+	white := color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	tests := []struct {
+		name    string
+		src     image.Image
+		maxSide int
+		wantW   int
+		wantH   int
+		pixels  map[image.Point]color.RGBA
+	}{
+		{
+			name:    "横向图片按宽缩小",
+			src:     newImage(1000, 600, solid(red)),
+			maxSide: 320,
+			wantW:   320,
+			wantH:   192,
+			pixels:  map[image.Point]color.RGBA{{0, 0}: {R: 0xff, A: 0xff}, {319, 191}: {R: 0xff, A: 0xff}},
+		},
+		{
+			name:    "纵向图片按高缩小",
+			src:     newImage(300, 900, solid(red)),
+			maxSide: 320,
+			wantW:   106,
+			wantH:   320,
+		},
+		{
+			name:    "细长图片至少保留1像素",
+			src:     newImage(2000, 2, solid(red)),
+			maxSide: 320,
+			wantW:   320,
+			wantH:   1,
+		},
+		{
+			name:    "小图片保持原尺寸",
+			src:     newImage(100, 80, solid(red)),
+			maxSide: 320,
+			wantW:   100,
+			wantH:   80,
+		},
+		{
+			name:    "透明像素叠加白色背景",
+			src:     newImage(10, 10, solid(color.NRGBA{})),
+			maxSide: 320,
+			wantW:   10,
+			wantH:   10,
+			pixels:  map[image.Point]color.RGBA{{5, 5}: white},
+		},
+		{
+			name: "取对应区域的平均色",
+			src: newImage(4, 2, func(x, y int) color.Color {
+				if x%2 == 0 {
+					return color.NRGBA{A: 0xff}
+				}
+				return color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+			}),
+			maxSide: 2,
+			wantW:   2,
+			wantH:   1,
+			pixels:  map[image.Point]color.RGBA{{0, 0}: {R: 0x7f, G: 0x7f, B: 0x7f, A: 0xff}, {1, 0}: {R: 0x7f, G: 0x7f, B: 0x7f, A: 0xff}},
+		},
+		{
+			name:    "起点不为原点的图片",
+			src:     newImage(20, 20, solid(red)).(*image.NRGBA).SubImage(image.Rect(5, 5, 15, 15)),
+			maxSide: 5,
+			wantW:   5,
+			wantH:   5,
+			pixels:  map[image.Point]color.RGBA{{4, 4}: {R: 0xff, A: 0xff}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := thumbnailImage(tt.src, tt.maxSide)
+			b := got.Bounds()
+			if b.Min != (image.Point{}) || b.Dx() != tt.wantW || b.Dy() != tt.wantH {
+				t.Fatalf("thumbnailImage() bounds = %v, want %dx%d", b, tt.wantW, tt.wantH)
+			}
+			for p, want := range tt.pixels {
+				if c := color.RGBAModel.Convert(got.At(p.X, p.Y)).(color.RGBA); c != want {
+					t.Errorf("thumbnailImage() pixel %v = %v, want %v", p, c, want)
+				}
+			}
+		})
+	}
+}
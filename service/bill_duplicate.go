@@ -0,0 +1,262 @@
+package service
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/zxc7563598/fintrack-backend/model"
+)
+
+// 疑似重复账单的评分权重：时间接近程度、交易对方相似度、账户一致、来源不同
+const (
+	duplicateTimeWeight         = 35
+	duplicateCounterpartyWeight = 40
+	duplicateAccountWeight      = 15
+	duplicateSourceWeight       = 10
+)
+
+// 重复账单识别参数
+type DuplicateOptions struct {
+	Window   time.Duration // 两笔账单交易时间的最大间隔
+	MinScore float64       // 最低得分（0-100），低于该分数的不视为重复
+}
+
+// 疑似重复的两笔账单
+type DuplicatePair struct {
+	A, B  uint
+	Score float64
+}
+
+// 疑似重复的账单分组
+type DuplicateGroup struct {
+	BillIDs []uint
+	Score   float64 // 组内最高的两两得分
+	Pairs   []DuplicatePair
+}
+
+// FindDuplicateBills 识别疑似重复的账单：收支类型与金额一致、交易时间在窗口内，再按时间、交易对方、账户、来源评分
+// 同一平台导入的两笔账单交易单号不同，视为两笔真实交易，不参与比较
+// 得分达到阈值的账单按相互关联合并为分组，分组按得分从高到低返回
+func FindDuplicateBills(bills []model.BillRecord, opts DuplicateOptions) []DuplicateGroup {
+	if opts.Window <= 0 {
+		return nil
+	}
+	// 按收支类型与金额分桶，只在桶内两两比较
+	buckets := map[[2]int64][]model.BillRecord{}
+	for _, b := range bills {
+		key := [2]int64{int64(b.IncomeType), toCents(b.Amount)}
+		buckets[key] = append(buckets[key], b)
+	}
+	window := int64(opts.Window / time.Second)
+	var pairs []DuplicatePair
+	for _, bucket := range buckets {
+		sort.Slice(bucket, func(i, j int) bool {
+			return bucket[i].TradeTime < bucket[j].TradeTime
+		})
+		for i := 0; i < len(bucket); i++ {
+			for j := i + 1; j < len(bucket); j++ {
+				a, b := bucket[i], bucket[j]
+				if b.TradeTime-a.TradeTime > window {
+					break
+				}
+				if a.Platform == b.Platform && !IsManualTradeNo(a.TradeNo) && !IsManualTradeNo(b.TradeNo) {
+					continue
+				}
+				score := duplicateScore(a, b, window)
+				if score >= opts.MinScore {
+					pairs = append(pairs, DuplicatePair{A: a.ID, B: b.ID, Score: score})
+				}
+			}
+		}
+	}
+	return groupDuplicatePairs(pairs)
+}
+
+// IsManualTradeNo 判断交易单号是否为手动记账时生成的
+func IsManualTradeNo(tradeNo string) bool {
+	_, err := uuid.Parse(tradeNo)
+	return err == nil
+}
+
+// 计算两笔账单的重复得分（0-100）
+func duplicateScore(a, b model.BillRecord, window int64) float64 {
+	score := 0.0
+	if window > 0 {
+		diff := math.Abs(float64(b.TradeTime - a.TradeTime))
+		score += duplicateTimeWeight * (1 - diff/float64(window))
+	}
+	if a.MerchantID > 0 && a.MerchantID == b.MerchantID {
+		score += duplicateCounterpartyWeight
+	} else {
+		score += duplicateCounterpartyWeight * CounterpartySimilarity(a.Counterparty, b.Counterparty)
+	}
+	if a.PaymentMethod != "" && a.PaymentMethod == b.PaymentMethod {
+		score += duplicateAccountWeight
+	}
+	if a.Platform != b.Platform || IsManualTradeNo(a.TradeNo) != IsManualTradeNo(b.TradeNo) {
+		score += duplicateSourceWeight
+	}
+	return math.Round(score*100) / 100
+}
+
+// CounterpartySimilarity 交易对方相似度（0-1）：规范化后一方包含另一方视为1，否则按字符二元组计算 Dice 系数
+func CounterpartySimilarity(a, b string) float64 {
+	ra, rb := normalizeCounterparty(a), normalizeCounterparty(b)
+	if len(ra) == 0 || len(rb) == 0 {
+		return 0
+	}
+	sa, sb := string(ra), string(rb)
+	if strings.Contains(sa, sb) || strings.Contains(sb, sa) {
+		return 1
+	}
+	if len(ra) < 2 || len(rb) < 2 {
+		return 0
+	}
+	bigrams := map[string]int{}
+	for i := 0; i+1 < len(ra); i++ {
+		bigrams[string(ra[i:i+2])]++
+	}
+	common := 0
+	for i := 0; i+1 < len(rb); i++ {
+		key := string(rb[i : i+2])
+		if bigrams[key] > 0 {
+			bigrams[key]--
+			common++
+		}
+	}
+	return float64(2*common) / float64(len(ra)-1+len(rb)-1)
+}
+
+// 去除空白与标点并转为小写
+func normalizeCounterparty(s string) []rune {
+	runes := []rune{}
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			runes = append(runes, r)
+		}
+	}
+	return runes
+}
+
+// 将两两关联的账单合并为分组
+func groupDuplicatePairs(pairs []DuplicatePair) []DuplicateGroup {
+	parent := map[uint]uint{}
+	var find func(uint) uint
+	find = func(x uint) uint {
+		if parent[x] != x {
+			parent[x] = find(parent[x])
+		}
+		return parent[x]
+	}
+	for _, p := range pairs {
+		for _, id := range []uint{p.A, p.B} {
+			if _, ok := parent[id]; !ok {
+				parent[id] = id
+			}
+		}
+		ra, rb := find(p.A), find(p.B)
+		if ra != rb {
+			parent[rb] = ra
+		}
+	}
+	groups := map[uint]*DuplicateGroup{}
+	for _, p := range pairs {
+		root := find(p.A)
+		g, ok := groups[root]
+		if !ok {
+			g = &DuplicateGroup{}
+			groups[root] = g
+		}
+		g.Pairs = append(g.Pairs, p)
+		if p.Score > g.Score {
+			g.Score = p.Score
+		}
+	}
+	result := make([]DuplicateGroup, 0, len(groups))
+	for _, g := range groups {
+		seen := map[uint]bool{}
+		for _, p := range g.Pairs {
+			for _, id := range []uint{p.A, p.B} {
+				if !seen[id] {
+					seen[id] = true
+					g.BillIDs = append(g.BillIDs, id)
+				}
+			}
+		}
+		sort.Slice(g.BillIDs, func(i, j int) bool { return g.BillIDs[i] < g.BillIDs[j] })
+		result = append(result, *g)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Score != result[j].Score {
+			return result[i].Score > result[j].Score
+		}
+		return result[i].BillIDs[0] < result[j].BillIDs[0]
+	})
+	return result
+}
+
+// MergeBillFields 合并重复账单的字段：以 primary 为基础，空字段取其他账单的值，商品名称与交易对方取信息更完整（更长）的，备注合并去重
+func MergeBillFields(primary model.BillRecord, others []model.BillRecord) model.BillRecord {
+	merged := primary
+	remarks := []string{}
+	if strings.TrimSpace(primary.Remark) != "" {
+		remarks = append(remarks, strings.TrimSpace(primary.Remark))
+	}
+	for _, o := range others {
+		if len([]rune(o.ProductName)) > len([]rune(merged.ProductName)) {
+			merged.ProductName = o.ProductName
+		}
+		if len([]rune(o.Counterparty)) > len([]rune(merged.Counterparty)) {
+			merged.Counterparty = o.Counterparty
+			merged.MerchantID = o.MerchantID
+		}
+		if merged.MerchantID == 0 && o.MerchantID > 0 {
+			merged.MerchantID = o.MerchantID
+		}
+		if merged.TradeType == "" {
+			merged.TradeType = o.TradeType
+		}
+		if merged.PaymentMethod == "" {
+			merged.PaymentMethod = o.PaymentMethod
+			merged.RawPaymentMethod = o.RawPaymentMethod
+		}
+		if merged.MerchantOrderNo == "" || IsManualTradeNo(merged.MerchantOrderNo) {
+			if o.MerchantOrderNo != "" && !IsManualTradeNo(o.MerchantOrderNo) {
+				merged.MerchantOrderNo = o.MerchantOrderNo
+			}
+		}
+		if merged.TradeStatus == "" {
+			merged.TradeStatus = o.TradeStatus
+		}
+		if r := strings.TrimSpace(o.Remark); r != "" {
+			exists := false
+			for _, e := range remarks {
+				if e == r {
+					exists = true
+					break
+				}
+			}
+			if !exists {
+				remarks = append(remarks, r)
+			}
+		}
+	}
+	merged.Remark = strings.Join(remarks, "；")
+	return merged
+}
+
+// PickMergePrimary 选择合并后保留的账单：优先导入的账单（交易单号可用于导入去重、时间更准确），其次ID最小的
+func PickMergePrimary(bills []model.BillRecord) int {
+	best := 0
+	for i, b := range bills {
+		bm, cm := IsManualTradeNo(b.TradeNo), IsManualTradeNo(bills[best].TradeNo)
+		if (!bm && cm) || (bm == cm && b.ID < bills[best].ID) {
+			best = i
+		}
+	}
+	return best
+}
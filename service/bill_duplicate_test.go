@@ -0,0 +1,137 @@
+package service
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/zxc7563598/fintrack-backend/model"
+)
+
+func TestCounterpartySimilarity(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want float64
+	}{
+		{"为空", "", "星巴克", 0},
+		{"只有标点", "--", "星巴克", 0},
+		{"完全一致", "星巴克", "星巴克", 1},
+		{"一方包含另一方", "星巴克", "星巴克咖啡", 1},
+		{"忽略大小写、空白与标点", "Star Bucks", "starbucks.", 1},
+		{"部分相似", "美团外卖", "美团优选", 1.0 / 3},
+		{"完全不同", "abc", "xyz", 0},
+		{"单字不同", "A", "B", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CounterpartySimilarity(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("CounterpartySimilarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindDuplicateBills(t *testing.T) {
+	const manualTradeNo = "123e4567-e89b-12d3-a456-426614174000"
+	base := time.Date(2025, 3, 1, 12, 0, 0, 0, time.Local).Unix()
+	bill := func(id uint, platform uint8, tradeNo string, offset int64, counterparty, paymentMethod string, amount float64) model.BillRecord {
+		return model.BillRecord{
+			ID:            id,
+			Platform:      platform,
+			TradeNo:       tradeNo,
+			TradeTime:     base + offset,
+			Counterparty:  counterparty,
+			PaymentMethod: paymentMethod,
+			IncomeType:    uint8(model.IncomeTypeExpense),
+			Amount:        amount,
+		}
+	}
+	opts := DuplicateOptions{Window: 10 * time.Minute, MinScore: 60}
+	type want struct {
+		billIDs []uint
+		score   float64
+	}
+	tests := []struct {
+		name  string
+		bills []model.BillRecord
+		opts  DuplicateOptions
+		want  []want
+	}{
+		{
+			name: "导入账单与手动记账重复",
+			bills: []model.BillRecord{
+				bill(1, 1, "2025030100001", 0, "星巴克咖啡", "招商银行信用卡", 36),
+				bill(2, 1, manualTradeNo, 120, "星巴克", "招商银行信用卡", 36),
+			},
+			opts: opts,
+			want: []want{{[]uint{1, 2}, 93}},
+		},
+		{
+			name: "同一平台交易单号不同视为两笔交易",
+			bills: []model.BillRecord{
+				bill(1, 1, "2025030100001", 0, "星巴克", "招商银行信用卡", 36),
+				bill(2, 1, "2025030100002", 60, "星巴克", "招商银行信用卡", 36),
+			},
+			opts: opts,
+		},
+		{
+			name: "超出时间窗口",
+			bills: []model.BillRecord{
+				bill(1, 1, "2025030100001", 0, "星巴克", "招商银行信用卡", 36),
+				bill(2, 2, "4200000001", 601, "星巴克", "招商银行信用卡", 36),
+			},
+			opts: opts,
+		},
+		{
+			name: "金额不同",
+			bills: []model.BillRecord{
+				bill(1, 1, "2025030100001", 0, "星巴克", "招商银行信用卡", 36),
+				bill(2, 2, "4200000001", 60, "星巴克", "招商银行信用卡", 36.5),
+			},
+			opts: opts,
+		},
+		{
+			name: "得分低于阈值",
+			bills: []model.BillRecord{
+				bill(1, 1, "2025030100001", 0, "星巴克", "招商银行信用卡", 36),
+				bill(2, 2, "4200000001", 540, "瑞幸", "零钱", 36),
+			},
+			opts: opts,
+		},
+		{
+			name: "相互关联的账单合并为一组",
+			bills: []model.BillRecord{
+				bill(3, 2, "4200000001", 60, "星巴克", "招商银行信用卡", 36),
+				bill(1, 1, "2025030100001", 0, "星巴克", "招商银行信用卡", 36),
+				bill(2, 1, manualTradeNo, 120, "星巴克", "招商银行信用卡", 36),
+				bill(4, 1, "2025030100002", 0, "美团外卖", "零钱", 25),
+				bill(5, 2, "4200000002", 300, "美团", "零钱", 25),
+			},
+			opts: opts,
+			want: []want{{[]uint{1, 2, 3}, 96.5}, {[]uint{4, 5}, 82.5}},
+		},
+		{
+			name: "时间窗口为0",
+			bills: []model.BillRecord{
+				bill(1, 1, "2025030100001", 0, "星巴克", "招商银行信用卡", 36),
+				bill(2, 2, "4200000001", 0, "星巴克", "招商银行信用卡", 36),
+			},
+			opts: DuplicateOptions{MinScore: 60},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FindDuplicateBills(tt.bills, tt.opts)
+			if len(got) != len(tt.want) {
+				t.Fatalf("FindDuplicateBills() returned %d groups, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i, w := range tt.want {
+				if !reflect.DeepEqual(got[i].BillIDs, w.billIDs) || got[i].Score != w.score {
+					t.Errorf("FindDuplicateBills()[%d] = %v (%v), want %v (%v)", i, got[i].BillIDs, got[i].Score, w.billIDs, w.score)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,351 @@
+package service
+
+import (
+	"encoding/json"
+	"slices"
+
+	"github.com/zxc7563598/fintrack-backend/model"
+	"gorm.io/gorm"
+)
+
+// 记录变更历史的账单字段，顺序即变更列表中的顺序
+// 标签与拆分明细的变更另行记录（字段为 tag_ids、splits），借贷、附件等其他数据对账单的关联不属于账单数据，不记录
+var billHistoryFields = []string{
+	"ledger_id",
+	"trade_no",
+	"merchant_order_no",
+	"platform",
+	"income_type",
+	"trade_type",
+	"product_name",
+	"counterparty",
+	"merchant_id",
+	"payment_method",
+	"raw_payment_method",
+	"amount",
+	"trade_status",
+	"trade_time",
+	"remark",
+}
+
+// 回滚时不恢复的字段：账单所在账本由移动操作决定，单号用于导入去重，
+// 商户属于账本，历史版本中的商户可能属于移动前的账本，由调用方按当前账本重新匹配
+var billRevertSkipFields = map[string]bool{
+	"ledger_id":         true,
+	"trade_no":          true,
+	"merchant_order_no": true,
+	"merchant_id":       true,
+}
+
+// BillFieldValues 账单记录变更历史的字段值
+func BillFieldValues(b model.BillRecord) map[string]any {
+	return map[string]any{
+		"ledger_id":          b.LedgerID,
+		"trade_no":           b.TradeNo,
+		"merchant_order_no":  b.MerchantOrderNo,
+		"platform":           b.Platform,
+		"income_type":        b.IncomeType,
+		"trade_type":         b.TradeType,
+		"product_name":       b.ProductName,
+		"counterparty":       b.Counterparty,
+		"merchant_id":        b.MerchantID,
+		"payment_method":     b.PaymentMethod,
+		"raw_payment_method": b.RawPaymentMethod,
+		"amount":             b.Amount,
+		"trade_status":       b.TradeStatus,
+		"trade_time":         b.TradeTime,
+		"remark":             b.Remark,
+	}
+}
+
+// DiffBillRecord 对比账单前后的字段，返回发生变化的字段
+func DiffBillRecord(before, after model.BillRecord) []model.BillFieldChange {
+	b, a := BillFieldValues(before), BillFieldValues(after)
+	changes := []model.BillFieldChange{}
+	for _, field := range billHistoryFields {
+		if b[field] != a[field] {
+			changes = append(changes, model.BillFieldChange{Field: field, Before: b[field], After: a[field]})
+		}
+	}
+	return changes
+}
+
+// BillRevertValues 将历史快照转换为回滚时需要更新的字段
+func BillRevertValues(snapshot string) (map[string]any, error) {
+	var values map[string]any
+	if err := json.Unmarshal([]byte(snapshot), &values); err != nil {
+		return nil, err
+	}
+	updates := map[string]any{}
+	for _, field := range billHistoryFields {
+		if v, ok := values[field]; ok && !billRevertSkipFields[field] {
+			updates[field] = v
+		}
+	}
+	// 规范化交易状态随交易状态一并恢复
+	if status, ok := updates["trade_status"].(string); ok {
+		updates["trade_status_type"] = model.TradeStatusTypeFromString(status)
+	}
+	return updates, nil
+}
+
+// SnapshotBillRecords 读取账单当前状态（含已删除的），修改前留存用于记录变更
+func SnapshotBillRecords(db *gorm.DB, ids []uint) (map[uint]model.BillRecord, error) {
+	snapshots := map[uint]model.BillRecord{}
+	if len(ids) == 0 {
+		return snapshots, nil
+	}
+	var bills []model.BillRecord
+	if err := db.Unscoped().Where("id IN ?", ids).Find(&bills).Error; err != nil {
+		return nil, err
+	}
+	for _, b := range bills {
+		snapshots[b.ID] = b
+	}
+	return snapshots, nil
+}
+
+// RecordBillCreated 记录新建账单的变更历史
+func RecordBillCreated(db *gorm.DB, userID uint, source model.BillChangeSource, bills []model.BillRecord) error {
+	histories := make([]model.BillRecordHistory, 0, len(bills))
+	for _, b := range bills {
+		history, err := newBillHistory(userID, source, model.BillActionCreate, model.BillRecord{}, b)
+		if err != nil {
+			return err
+		}
+		histories = append(histories, history)
+	}
+	if len(histories) == 0 {
+		return nil
+	}
+	return db.CreateInBatches(&histories, 100).Error
+}
+
+// RecordBillChanges 对比修改前的快照与当前状态，为有变化的账单记录变更历史
+// 删除操作记录删除前的全部字段，修改和回滚只记录变化的字段，没有变化的账单不记录
+func RecordBillChanges(db *gorm.DB, userID uint, source model.BillChangeSource, action model.BillChangeAction, befores map[uint]model.BillRecord) error {
+	if len(befores) == 0 {
+		return nil
+	}
+	ids := make([]uint, 0, len(befores))
+	for id := range befores {
+		ids = append(ids, id)
+	}
+	afters, err := SnapshotBillRecords(db, ids)
+	if err != nil {
+		return err
+	}
+	histories := make([]model.BillRecordHistory, 0, len(befores))
+	for _, id := range ids {
+		before, after := befores[id], afters[id]
+		var history model.BillRecordHistory
+		if action == model.BillActionDelete {
+			if !after.DeletedAt.Valid {
+				continue
+			}
+			history, err = newBillHistory(userID, source, action, before, model.BillRecord{})
+		} else {
+			if len(DiffBillRecord(before, after)) == 0 && before.DeletedAt == after.DeletedAt {
+				continue
+			}
+			history, err = newBillHistory(userID, source, action, before, after)
+		}
+		if err != nil {
+			return err
+		}
+		histories = append(histories, history)
+	}
+	if len(histories) == 0 {
+		return nil
+	}
+	return db.CreateInBatches(&histories, 100).Error
+}
+
+// UpdateBillRecords 按条件批量修改账单并记录变更历史，返回修改的记录数
+func UpdateBillRecords(db *gorm.DB, userID uint, source model.BillChangeSource, updates map[string]any, query any, args ...any) (int64, error) {
+	var ids []uint
+	if err := db.Model(&model.BillRecord{}).Where(query, args...).Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	befores, err := SnapshotBillRecords(db, ids)
+	if err != nil {
+		return 0, err
+	}
+	result := db.Model(&model.BillRecord{}).Where("id IN ?", ids).Updates(updates)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return result.RowsAffected, RecordBillChanges(db, userID, source, model.BillActionUpdate, befores)
+}
+
+// 变更历史中记录的拆分明细
+type BillSplitValue struct {
+	TradeType string  `json:"trade_type"`
+	Amount    float64 `json:"amount"`
+	Remark    string  `json:"remark"`
+}
+
+// SnapshotBillTags 读取账单当前的标签ID（升序），修改标签前留存用于记录变更
+func SnapshotBillTags(db *gorm.DB, ids []uint) (map[uint][]uint, error) {
+	snapshots := make(map[uint][]uint, len(ids))
+	for _, id := range ids {
+		snapshots[id] = []uint{}
+	}
+	if len(ids) == 0 {
+		return snapshots, nil
+	}
+	var links []model.BillRecordTag
+	if err := db.Where("bill_record_id IN ?", ids).Order("bill_record_id, tag_id").Find(&links).Error; err != nil {
+		return nil, err
+	}
+	for _, l := range links {
+		snapshots[l.BillRecordID] = append(snapshots[l.BillRecordID], l.TagID)
+	}
+	return snapshots, nil
+}
+
+// SnapshotBillSplits 读取账单当前的拆分明细，修改拆分前留存用于记录变更
+func SnapshotBillSplits(db *gorm.DB, ids []uint) (map[uint][]BillSplitValue, error) {
+	snapshots := make(map[uint][]BillSplitValue, len(ids))
+	for _, id := range ids {
+		snapshots[id] = []BillSplitValue{}
+	}
+	if len(ids) == 0 {
+		return snapshots, nil
+	}
+	var splits []model.BillSplit
+	if err := db.Where("bill_record_id IN ?", ids).Order("id").Find(&splits).Error; err != nil {
+		return nil, err
+	}
+	for _, sp := range splits {
+		snapshots[sp.BillRecordID] = append(snapshots[sp.BillRecordID], BillSplitValue{TradeType: sp.TradeType, Amount: sp.Amount, Remark: sp.Remark})
+	}
+	return snapshots, nil
+}
+
+// RecordBillTagChanges 对比修改前的标签与当前标签，为标签有变化的账单记录变更历史（字段为 tag_ids）
+func RecordBillTagChanges(db *gorm.DB, userID uint, source model.BillChangeSource, befores map[uint][]uint) error {
+	ids := make([]uint, 0, len(befores))
+	for id := range befores {
+		ids = append(ids, id)
+	}
+	afters, err := SnapshotBillTags(db, ids)
+	if err != nil {
+		return err
+	}
+	changes := map[uint]model.BillFieldChange{}
+	for _, id := range ids {
+		if !slices.Equal(befores[id], afters[id]) {
+			changes[id] = model.BillFieldChange{Field: "tag_ids", Before: befores[id], After: afters[id]}
+		}
+	}
+	return recordBillRelationChanges(db, userID, source, changes)
+}
+
+// RecordBillSplitChanges 对比修改前的拆分明细与当前拆分明细，为拆分有变化的账单记录变更历史（字段为 splits）
+func RecordBillSplitChanges(db *gorm.DB, userID uint, source model.BillChangeSource, befores map[uint][]BillSplitValue) error {
+	ids := make([]uint, 0, len(befores))
+	for id := range befores {
+		ids = append(ids, id)
+	}
+	afters, err := SnapshotBillSplits(db, ids)
+	if err != nil {
+		return err
+	}
+	changes := map[uint]model.BillFieldChange{}
+	for _, id := range ids {
+		if !slices.Equal(befores[id], afters[id]) {
+			changes[id] = model.BillFieldChange{Field: "splits", Before: befores[id], After: afters[id]}
+		}
+	}
+	return recordBillRelationChanges(db, userID, source, changes)
+}
+
+// 为标签、拆分等关联数据的变更记录修改历史，快照为账单当前字段，回滚到该版本时不恢复关联数据
+func recordBillRelationChanges(db *gorm.DB, userID uint, source model.BillChangeSource, changes map[uint]model.BillFieldChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	ids := make([]uint, 0, len(changes))
+	for id := range changes {
+		ids = append(ids, id)
+	}
+	bills, err := SnapshotBillRecords(db, ids)
+	if err != nil {
+		return err
+	}
+	histories := make([]model.BillRecordHistory, 0, len(changes))
+	for _, id := range ids {
+		bill, ok := bills[id]
+		if !ok {
+			continue
+		}
+		changesJSON, err := json.Marshal([]model.BillFieldChange{changes[id]})
+		if err != nil {
+			return err
+		}
+		snapshotJSON, err := json.Marshal(BillFieldValues(bill))
+		if err != nil {
+			return err
+		}
+		histories = append(histories, model.BillRecordHistory{
+			BillRecordID: bill.ID,
+			LedgerID:     bill.LedgerID,
+			UserID:       userID,
+			Action:       uint8(model.BillActionUpdate),
+			Source:       uint8(source),
+			Changes:      string(changesJSON),
+			Snapshot:     string(snapshotJSON),
+		})
+	}
+	if len(histories) == 0 {
+		return nil
+	}
+	return db.CreateInBatches(&histories, 100).Error
+}
+
+// 生成一条变更历史，创建时 before 为空，删除时 after 为空
+func newBillHistory(userID uint, source model.BillChangeSource, action model.BillChangeAction, before, after model.BillRecord) (model.BillRecordHistory, error) {
+	current := after
+	if action == model.BillActionDelete || action == model.BillActionPurge {
+		current = before
+	}
+	var changes []model.BillFieldChange
+	switch action {
+	case model.BillActionCreate:
+		changes = fieldChanges(nil, BillFieldValues(after))
+	case model.BillActionDelete, model.BillActionPurge:
+		changes = fieldChanges(BillFieldValues(before), nil)
+	default:
+		changes = DiffBillRecord(before, after)
+	}
+	changesJSON, err := json.Marshal(changes)
+	if err != nil {
+		return model.BillRecordHistory{}, err
+	}
+	snapshotJSON, err := json.Marshal(BillFieldValues(current))
+	if err != nil {
+		return model.BillRecordHistory{}, err
+	}
+	return model.BillRecordHistory{
+		BillRecordID: current.ID,
+		LedgerID:     current.LedgerID,
+		UserID:       userID,
+		Action:       uint8(action),
+		Source:       uint8(source),
+		Changes:      string(changesJSON),
+		Snapshot:     string(snapshotJSON),
+	}, nil
+}
+
+// 创建或删除时的字段变更，一侧为空
+func fieldChanges(before, after map[string]any) []model.BillFieldChange {
+	changes := make([]model.BillFieldChange, 0, len(billHistoryFields))
+	for _, field := range billHistoryFields {
+		changes = append(changes, model.BillFieldChange{Field: field, Before: before[field], After: after[field]})
+	}
+	return changes
+}
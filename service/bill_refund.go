@@ -0,0 +1,166 @@
+package service
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zxc7563598/fintrack-backend/model"
+	"gorm.io/gorm"
+)
+
+// 退款匹配时原消费与退款的最长间隔
+const refundMatchWindow = 180 * 24 * time.Hour
+
+// 退款匹配结果
+type RefundMatch struct {
+	OriginalID uint
+	Amount     float64
+	MatchType  model.RefundMatchType
+}
+
+// IsRefundBill 判断账单是否为退款：非支出，且交易状态、分类或商品名称含“退款”
+func IsRefundBill(b model.BillRecord) bool {
+	if b.IncomeType == uint8(model.IncomeTypeExpense) || b.Amount <= 0 {
+		return false
+	}
+	return strings.Contains(b.TradeStatus+b.TradeType+b.ProductName, "退款")
+}
+
+// MatchRefundBills 为退款账单匹配原始消费，返回退款账单ID到匹配结果的映射
+// 优先按单号匹配（退款的交易单号或商户单号以原消费的单号开头），其次按交易对方（或商户）一致匹配，优先金额相同、时间最近的
+// 原消费需早于退款且在匹配窗口内，累计退款不超过原消费金额；refunded 为原消费已关联的退款金额
+func MatchRefundBills(refunds, expenses []model.BillRecord, refunded map[uint]float64) map[uint]RefundMatch {
+	sort.SliceStable(refunds, func(i, j int) bool {
+		return refunds[i].TradeTime < refunds[j].TradeTime
+	})
+	used := map[uint]int64{}
+	for id, amount := range refunded {
+		used[id] = toCents(amount)
+	}
+	window := int64(refundMatchWindow / time.Second)
+	matched := map[uint]RefundMatch{}
+	for _, r := range refunds {
+		amount := toCents(r.Amount)
+		best, bestType := -1, model.RefundMatchType(0)
+		for i, e := range expenses {
+			if e.TradeTime > r.TradeTime || r.TradeTime-e.TradeTime > window || toCents(e.Amount)-used[e.ID] < amount {
+				continue
+			}
+			if refundOrderNoMatched(r, e) {
+				best, bestType = i, model.RefundMatchOrderNo
+				break
+			}
+			if !refundCounterpartyMatched(r, e) {
+				continue
+			}
+			if best < 0 || refundCandidateBetter(r, e, expenses[best]) {
+				best, bestType = i, model.RefundMatchCounterparty
+			}
+		}
+		if best >= 0 {
+			e := expenses[best]
+			used[e.ID] += amount
+			matched[r.ID] = RefundMatch{OriginalID: e.ID, Amount: r.Amount, MatchType: bestType}
+		}
+	}
+	return matched
+}
+
+// 退款的交易单号或商户单号以原消费的单号开头
+func refundOrderNoMatched(r, e model.BillRecord) bool {
+	if e.TradeNo != "" && !IsManualTradeNo(e.TradeNo) && strings.HasPrefix(r.TradeNo, e.TradeNo) {
+		return true
+	}
+	return e.MerchantOrderNo != "" && !IsManualTradeNo(e.MerchantOrderNo) && strings.HasPrefix(r.MerchantOrderNo, e.MerchantOrderNo)
+}
+
+// 退款与原消费的商户或交易对方一致
+func refundCounterpartyMatched(r, e model.BillRecord) bool {
+	if r.MerchantID > 0 && r.MerchantID == e.MerchantID {
+		return true
+	}
+	return r.Counterparty != "" && r.Counterparty == e.Counterparty
+}
+
+// 候选原消费 a 是否优于 b：金额与退款相同的优先，其次时间更接近退款的
+func refundCandidateBetter(r, a, b model.BillRecord) bool {
+	ae, be := toCents(a.Amount) == toCents(r.Amount), toCents(b.Amount) == toCents(r.Amount)
+	if ae != be {
+		return ae
+	}
+	return a.TradeTime > b.TradeTime
+}
+
+// MatchRefunds 为账本中尚未关联的退款账单自动匹配原始消费，返回本次关联的数量
+func MatchRefunds(db *gorm.DB, ledgerID uint) (int, error) {
+	linked := db.Model(&model.BillRefund{}).Select("refund_bill_record_id").Where("ledger_id = ?", ledgerID)
+	var candidates []model.BillRecord
+	if err := db.Where("ledger_id = ? AND income_type <> ?", ledgerID, model.IncomeTypeExpense).
+		Where("trade_status LIKE ? OR trade_type LIKE ? OR product_name LIKE ?", "%退款%", "%退款%", "%退款%").
+		Where("id NOT IN (?)", linked).
+		Find(&candidates).Error; err != nil {
+		return 0, err
+	}
+	refunds := make([]model.BillRecord, 0, len(candidates))
+	for _, c := range candidates {
+		if IsRefundBill(c) {
+			refunds = append(refunds, c)
+		}
+	}
+	if len(refunds) == 0 {
+		return 0, nil
+	}
+	var expenses []model.BillRecord
+	if err := db.Where("ledger_id = ? AND income_type = ?", ledgerID, model.IncomeTypeExpense).Find(&expenses).Error; err != nil {
+		return 0, err
+	}
+	refunded, err := RefundedAmounts(db, ledgerID)
+	if err != nil {
+		return 0, err
+	}
+	matched := MatchRefundBills(refunds, expenses, refunded)
+	if len(matched) == 0 {
+		return 0, nil
+	}
+	links := make([]model.BillRefund, 0, len(matched))
+	for refundID, m := range matched {
+		links = append(links, model.BillRefund{
+			LedgerID:             ledgerID,
+			RefundBillRecordID:   refundID,
+			OriginalBillRecordID: m.OriginalID,
+			Amount:               m.Amount,
+			MatchType:            uint8(m.MatchType),
+		})
+	}
+	if err := db.Create(&links).Error; err != nil {
+		return 0, err
+	}
+	return len(links), nil
+}
+
+// RefundedAmounts 统计账本中各原始消费已关联的退款金额
+func RefundedAmounts(db *gorm.DB, ledgerID uint) (map[uint]float64, error) {
+	type refundedRow struct {
+		OriginalBillRecordID uint
+		Amount               float64
+	}
+	var rows []refundedRow
+	if err := db.Model(&model.BillRefund{}).
+		Select("original_bill_record_id, SUM(amount) AS amount").
+		Where("ledger_id = ?", ledgerID).
+		Group("original_bill_record_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	refunded := map[uint]float64{}
+	for _, r := range rows {
+		refunded[r.OriginalBillRecordID] = r.Amount
+	}
+	return refunded, nil
+}
+
+// FullyRefunded 判断消费是否已全额退款；refunded 为 RefundedAmounts 的统计结果
+func FullyRefunded(bill model.BillRecord, refunded map[uint]float64) bool {
+	return toCents(refunded[bill.ID]) >= toCents(bill.Amount)
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/zxc7563598/fintrack-backend/model"
+)
+
+func TestMatchRefundBills(t *testing.T) {
+	day := func(d int) int64 {
+		return time.Date(2025, 3, 1, 12, 0, 0, 0, time.Local).AddDate(0, 0, d).Unix()
+	}
+	expense := func(id uint, d int, tradeNo, counterparty string, amount float64) model.BillRecord {
+		return model.BillRecord{ID: id, TradeTime: day(d), TradeNo: tradeNo, Counterparty: counterparty, Amount: amount, IncomeType: uint8(model.IncomeTypeExpense)}
+	}
+	refund := func(id uint, d int, tradeNo, counterparty string, amount float64) model.BillRecord {
+		return model.BillRecord{ID: id, TradeTime: day(d), TradeNo: tradeNo, Counterparty: counterparty, Amount: amount, IncomeType: uint8(model.IncomeTypeIncome), TradeStatus: "退款成功"}
+	}
+	const manualTradeNo = "123e4567-e89b-12d3-a456-426614174000"
+	tests := []struct {
+		name     string
+		refunds  []model.BillRecord
+		expenses []model.BillRecord
+		refunded map[uint]float64
+		want     map[uint]RefundMatch
+	}{
+		{
+			name:     "交易单号优先",
+			refunds:  []model.BillRecord{refund(10, 5, "T100_R1", "某旗舰店", 30)},
+			expenses: []model.BillRecord{expense(2, 4, "T200", "某旗舰店", 30), expense(1, 0, "T100", "某某公司", 99)},
+			want:     map[uint]RefundMatch{10: {OriginalID: 1, Amount: 30, MatchType: model.RefundMatchOrderNo}},
+		},
+		{
+			name: "商户单号匹配",
+			refunds: []model.BillRecord{func() model.BillRecord {
+				r := refund(10, 5, "R900", "某旗舰店", 30)
+				r.MerchantOrderNo = "M100-1"
+				return r
+			}()},
+			expenses: []model.BillRecord{func() model.BillRecord {
+				e := expense(1, 0, "T100", "某某公司", 99)
+				e.MerchantOrderNo = "M100"
+				return e
+			}()},
+			want: map[uint]RefundMatch{10: {OriginalID: 1, Amount: 30, MatchType: model.RefundMatchOrderNo}},
+		},
+		{
+			name:     "手动记账的单号不参与单号匹配",
+			refunds:  []model.BillRecord{refund(10, 5, manualTradeNo, "某旗舰店", 30)},
+			expenses: []model.BillRecord{expense(1, 0, manualTradeNo, "某某公司", 99)},
+			want:     map[uint]RefundMatch{},
+		},
+		{
+			name:     "交易对方一致时金额相同优先",
+			refunds:  []model.BillRecord{refund(10, 5, "R1", "某旗舰店", 30)},
+			expenses: []model.BillRecord{expense(1, 1, "T1", "某旗舰店", 30), expense(2, 4, "T2", "某旗舰店", 50)},
+			want:     map[uint]RefundMatch{10: {OriginalID: 1, Amount: 30, MatchType: model.RefundMatchCounterparty}},
+		},
+		{
+			name:     "金额同样不等时时间最近优先",
+			refunds:  []model.BillRecord{refund(10, 5, "R1", "某旗舰店", 30)},
+			expenses: []model.BillRecord{expense(1, 1, "T1", "某旗舰店", 80), expense(2, 4, "T2", "某旗舰店", 50)},
+			want:     map[uint]RefundMatch{10: {OriginalID: 2, Amount: 30, MatchType: model.RefundMatchCounterparty}},
+		},
+		{
+			name: "商户一致",
+			refunds: []model.BillRecord{func() model.BillRecord {
+				r := refund(10, 5, "R1", "某旗舰店退款", 30)
+				r.MerchantID = 7
+				return r
+			}()},
+			expenses: []model.BillRecord{func() model.BillRecord {
+				e := expense(1, 1, "T1", "某旗舰店", 30)
+				e.MerchantID = 7
+				return e
+			}()},
+			want: map[uint]RefundMatch{10: {OriginalID: 1, Amount: 30, MatchType: model.RefundMatchCounterparty}},
+		},
+		{
+			name:     "原消费晚于退款",
+			refunds:  []model.BillRecord{refund(10, 5, "T1_R", "某旗舰店", 30)},
+			expenses: []model.BillRecord{expense(1, 6, "T1", "某旗舰店", 30)},
+			want:     map[uint]RefundMatch{},
+		},
+		{
+			name:     "超出匹配窗口",
+			refunds:  []model.BillRecord{refund(10, 181, "T1_R", "某旗舰店", 30)},
+			expenses: []model.BillRecord{expense(1, 0, "T1", "某旗舰店", 30)},
+			want:     map[uint]RefundMatch{},
+		},
+		{
+			name:     "已关联的退款占用原消费金额",
+			refunds:  []model.BillRecord{refund(10, 5, "T1_R2", "某旗舰店", 30)},
+			expenses: []model.BillRecord{expense(1, 0, "T1", "某旗舰店", 50)},
+			refunded: map[uint]float64{1: 20.01},
+			want:     map[uint]RefundMatch{},
+		},
+		{
+			name:     "累计退款不超过原消费金额",
+			refunds:  []model.BillRecord{refund(11, 3, "R2", "某旗舰店", 60), refund(10, 2, "R1", "某旗舰店", 60)},
+			expenses: []model.BillRecord{expense(1, 0, "T1", "某旗舰店", 100)},
+			want:     map[uint]RefundMatch{10: {OriginalID: 1, Amount: 60, MatchType: model.RefundMatchCounterparty}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MatchRefundBills(tt.refunds, tt.expenses, tt.refunded); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MatchRefundBills() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFullyRefunded(t *testing.T) {
+	refunded := map[uint]float64{1: 30, 2: 99.99, 3: 0.1 + 0.2}
+	tests := []struct {
+		name string
+		bill model.BillRecord
+		want bool
+	}{
+		{"全额退款", model.BillRecord{ID: 1, Amount: 30}, true},
+		{"部分退款", model.BillRecord{ID: 2, Amount: 100}, false},
+		{"浮点误差按分计算", model.BillRecord{ID: 3, Amount: 0.3}, true},
+		{"没有退款", model.BillRecord{ID: 4, Amount: 10}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FullyRefunded(tt.bill, refunded); got != tt.want {
+				t.Errorf("FullyRefunded() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
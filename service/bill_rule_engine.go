@@ -0,0 +1,129 @@
+package service
+
+import (
+	"strings"
+	"time"
+
+	"github.com/zxc7563598/fintrack-backend/model"
+	"gorm.io/gorm"
+)
+
+type BillRuleEngine struct {
+	rules []model.BillRule
+}
+
+// 规则执行结果
+type BillRuleResult struct {
+	Matched bool   // 是否命中任意规则
+	Ignore  bool   // 是否忽略该账单
+	TagIDs  []uint // 需要添加的标签
+	RuleIDs []uint // 命中的规则
+}
+
+// NewBillRuleEngine 加载账本启用的规则，ruleIDs 不为空时只加载指定规则
+func NewBillRuleEngine(db *gorm.DB, ledgerID uint, ruleIDs ...uint) (*BillRuleEngine, error) {
+	query := db.Where("ledger_id = ? AND enabled = ?", ledgerID, true)
+	if len(ruleIDs) > 0 {
+		query = query.Where("id IN ?", ruleIDs)
+	}
+	var rules []model.BillRule
+	if err := query.Order("priority, id").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return &BillRuleEngine{rules: rules}, nil
+}
+
+// Apply 按优先级执行规则并直接修改账单，同一字段以先命中的规则为准
+func (e *BillRuleEngine) Apply(bill *model.BillRecord) BillRuleResult {
+	var result BillRuleResult
+	var tradeTypeSet, paymentMethodSet, incomeTypeSet, remarkSet bool
+	for _, rule := range e.rules {
+		if !MatchBillRule(rule.Conditions, bill) {
+			continue
+		}
+		result.Matched = true
+		result.RuleIDs = append(result.RuleIDs, rule.ID)
+		action := rule.Actions
+		if action.Ignore {
+			result.Ignore = true
+			break
+		}
+		if action.TradeType != "" && !tradeTypeSet {
+			bill.TradeType = action.TradeType
+			tradeTypeSet = true
+		}
+		if action.PaymentMethod != "" && !paymentMethodSet {
+			bill.PaymentMethod = action.PaymentMethod
+			paymentMethodSet = true
+		}
+		if action.IncomeType != 0 && !incomeTypeSet {
+			bill.IncomeType = action.IncomeType
+			incomeTypeSet = true
+		}
+		if action.Remark != "" && !remarkSet {
+			bill.Remark = action.Remark
+			remarkSet = true
+		}
+		result.TagIDs = append(result.TagIDs, action.TagIDs...)
+	}
+	return result
+}
+
+// MatchBillRule 判断账单是否满足规则条件
+func MatchBillRule(cond model.BillRuleCondition, bill *model.BillRecord) bool {
+	if cond.Counterparty != "" && !strings.Contains(bill.Counterparty, cond.Counterparty) {
+		return false
+	}
+	if cond.ProductName != "" && !strings.Contains(bill.ProductName, cond.ProductName) {
+		return false
+	}
+	if cond.AmountMin != nil && bill.Amount < *cond.AmountMin {
+		return false
+	}
+	if cond.AmountMax != nil && bill.Amount > *cond.AmountMax {
+		return false
+	}
+	if cond.Platform != 0 && bill.Platform != cond.Platform {
+		return false
+	}
+	if cond.PaymentMethod != "" && !strings.Contains(bill.PaymentMethod, cond.PaymentMethod) {
+		return false
+	}
+	if cond.TimeStart != "" && cond.TimeEnd != "" {
+		clock := time.Unix(bill.TradeTime, 0).Format("15:04")
+		if cond.TimeStart <= cond.TimeEnd {
+			if clock < cond.TimeStart || clock > cond.TimeEnd {
+				return false
+			}
+		} else if clock < cond.TimeStart && clock > cond.TimeEnd {
+			// 跨天时段，如 22:00 - 06:00
+			return false
+		}
+	}
+	return true
+}
+
+// ValidBillRuleCondition 判断条件是否有效（至少包含一个条件，时段格式正确）
+func ValidBillRuleCondition(cond model.BillRuleCondition) bool {
+	if (cond.TimeStart == "") != (cond.TimeEnd == "") {
+		return false
+	}
+	for _, s := range []string{cond.TimeStart, cond.TimeEnd} {
+		if s == "" {
+			continue
+		}
+		if _, err := time.Parse("15:04", s); err != nil || len(s) != 5 {
+			return false
+		}
+	}
+	if cond.AmountMin != nil && cond.AmountMax != nil && *cond.AmountMin > *cond.AmountMax {
+		return false
+	}
+	return cond.Counterparty != "" ||
+		cond.ProductName != "" ||
+		cond.AmountMin != nil ||
+		cond.AmountMax != nil ||
+		cond.Platform != 0 ||
+		cond.PaymentMethod != "" ||
+		cond.TimeStart != ""
+}
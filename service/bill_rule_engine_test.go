@@ -0,0 +1,54 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zxc7563598/fintrack-backend/model"
+)
+
+func TestMatchBillRule(t *testing.T) {
+	amount := func(v float64) *float64 { return &v }
+	at := func(hour, minute int) int64 {
+		return time.Date(2025, 3, 1, hour, minute, 0, 0, time.Local).Unix()
+	}
+	bill := model.BillRecord{
+		Counterparty:  "星巴克咖啡",
+		ProductName:   "拿铁 大杯",
+		Amount:        36,
+		Platform:      1,
+		PaymentMethod: "招商银行信用卡",
+		TradeTime:     at(23, 30),
+	}
+	tests := []struct {
+		name string
+		cond model.BillRuleCondition
+		want bool
+	}{
+		{"空条件", model.BillRuleCondition{}, true},
+		{"交易对方包含", model.BillRuleCondition{Counterparty: "星巴克"}, true},
+		{"交易对方不包含", model.BillRuleCondition{Counterparty: "瑞幸"}, false},
+		{"商品名称包含", model.BillRuleCondition{ProductName: "拿铁"}, true},
+		{"金额下限含边界", model.BillRuleCondition{AmountMin: amount(36)}, true},
+		{"金额低于下限", model.BillRuleCondition{AmountMin: amount(36.01)}, false},
+		{"金额上限含边界", model.BillRuleCondition{AmountMax: amount(36)}, true},
+		{"金额高于上限", model.BillRuleCondition{AmountMax: amount(35.99)}, false},
+		{"平台一致", model.BillRuleCondition{Platform: 1}, true},
+		{"平台不一致", model.BillRuleCondition{Platform: 2}, false},
+		{"账户包含", model.BillRuleCondition{PaymentMethod: "信用卡"}, true},
+		{"同日时段内", model.BillRuleCondition{TimeStart: "20:00", TimeEnd: "23:59"}, true},
+		{"同日时段外", model.BillRuleCondition{TimeStart: "08:00", TimeEnd: "12:00"}, false},
+		{"跨天时段内", model.BillRuleCondition{TimeStart: "22:00", TimeEnd: "06:00"}, true},
+		{"跨天时段外", model.BillRuleCondition{TimeStart: "23:45", TimeEnd: "06:00"}, false},
+		{"只填开始时间不限制时段", model.BillRuleCondition{TimeStart: "08:00"}, true},
+		{"多个条件同时满足", model.BillRuleCondition{Counterparty: "星巴克", AmountMin: amount(30), Platform: 1}, true},
+		{"多个条件部分满足", model.BillRuleCondition{Counterparty: "星巴克", AmountMax: amount(30)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MatchBillRule(tt.cond, &bill); got != tt.want {
+				t.Errorf("MatchBillRule() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/zxc7563598/fintrack-backend/model"
+)
+
+var (
+	ErrInvalidBillSplits  = errors.New("拆分明细无效")
+	ErrBillSplitsMismatch = errors.New("拆分金额合计与账单金额不一致")
+)
+
+// CheckBillSplits 校验账单的拆分明细：至少两行，每行需有分类且金额大于0，合计等于账单金额（精确到分）
+// 没有拆分明细时视为未拆分，不做校验
+func CheckBillSplits(splits []model.BillSplit, amount float64) error {
+	if len(splits) == 0 {
+		return nil
+	}
+	if len(splits) == 1 {
+		return ErrInvalidBillSplits
+	}
+	var total int64
+	for _, s := range splits {
+		if strings.TrimSpace(s.TradeType) == "" || s.Amount <= 0 {
+			return ErrInvalidBillSplits
+		}
+		total += toCents(s.Amount)
+	}
+	if total != toCents(amount) {
+		return ErrBillSplitsMismatch
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/zxc7563598/fintrack-backend/model"
+)
+
+func TestCheckBillSplits(t *testing.T) {
+	split := func(tradeType string, amount float64) model.BillSplit {
+		return model.BillSplit{TradeType: tradeType, Amount: amount}
+	}
+	tests := []struct {
+		name    string
+		splits  []model.BillSplit
+		amount  float64
+		wantErr error
+	}{
+		{"未拆分", nil, 100, nil},
+		{"合计等于账单金额", []model.BillSplit{split("餐饮", 60), split("日用", 40)}, 100, nil},
+		{"浮点误差按分计算", []model.BillSplit{split("餐饮", 0.1), split("日用", 0.2)}, 0.3, nil},
+		{"只有一行", []model.BillSplit{split("餐饮", 100)}, 100, ErrInvalidBillSplits},
+		{"分类为空", []model.BillSplit{split("餐饮", 60), split(" ", 40)}, 100, ErrInvalidBillSplits},
+		{"金额为0", []model.BillSplit{split("餐饮", 100), split("日用", 0)}, 100, ErrInvalidBillSplits},
+		{"金额为负数", []model.BillSplit{split("餐饮", 110), split("日用", -10)}, 100, ErrInvalidBillSplits},
+		{"合计小于账单金额", []model.BillSplit{split("餐饮", 60), split("日用", 39.99)}, 100, ErrBillSplitsMismatch},
+		{"合计大于账单金额", []model.BillSplit{split("餐饮", 60), split("日用", 40.01)}, 100, ErrBillSplitsMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CheckBillSplits(tt.splits, tt.amount); !errors.Is(err, tt.wantErr) {
+				t.Errorf("CheckBillSplits() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
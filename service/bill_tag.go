@@ -0,0 +1,42 @@
+package service
+
+import (
+	"github.com/zxc7563598/fintrack-backend/model"
+	"gorm.io/gorm"
+)
+
+// TagTotal 标签的收入、支出合计
+type TagTotal struct {
+	TagID        uint
+	TagName      string
+	IncomeTotal  float64
+	ExpenseTotal float64
+}
+
+// TaggedBillIDs 返回带有任一指定标签的账单ID子查询，用于按标签筛选账单
+func TaggedBillIDs(db *gorm.DB, tagIDs []uint) *gorm.DB {
+	return db.Model(&model.BillRecordTag{}).Select("bill_record_id").Where("tag_id IN ?", tagIDs)
+}
+
+// SumByTag 按标签汇总账单明细的收支，按标签ID排序
+// lines 为以 bill_records 为别名的账单明细查询，带有多个标签的账单分别计入每个标签；tagIDs 为空时统计全部标签
+func SumByTag(lines *gorm.DB, tagIDs []uint) ([]TagTotal, error) {
+	db := lines.
+		Joins("JOIN bill_record_tags ON bill_record_tags.bill_record_id = bill_records.id").
+		Joins("JOIN tags ON tags.id = bill_record_tags.tag_id AND tags.deleted_at IS NULL")
+	if len(tagIDs) > 0 {
+		db = db.Where("tags.id IN ?", tagIDs)
+	}
+	var totals []TagTotal
+	err := db.
+		Select(`
+		tags.id AS tag_id,
+		tags.name AS tag_name,
+		COALESCE(SUM(CASE WHEN bill_records.income_type = 1 THEN bill_records.amount END),0) AS income_total,
+		COALESCE(SUM(CASE WHEN bill_records.income_type = 2 THEN bill_records.amount END),0) AS expense_total
+	`).
+		Group("tags.id, tags.name").
+		Order("tags.id").
+		Scan(&totals).Error
+	return totals, err
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/zxc7563598/fintrack-backend/model"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newBillTagTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&model.BillRecord{}, &model.Tag{}, &model.BillRecordTag{}); err != nil {
+		t.Fatal(err)
+	}
+	tags := []model.Tag{
+		{ID: 1, UserID: 1, LedgerID: 1, Name: "旅行"},
+		{ID: 2, UserID: 1, LedgerID: 1, Name: "报销"},
+		{ID: 3, UserID: 1, LedgerID: 1, Name: "已删除"},
+	}
+	bill := func(id, ledgerID uint, incomeType model.IncomeType, amount float64) model.BillRecord {
+		return model.BillRecord{ID: id, UserID: 1, LedgerID: ledgerID, IncomeType: uint8(incomeType), Amount: amount, TradeTime: 1}
+	}
+	bills := []model.BillRecord{
+		bill(1, 1, model.IncomeTypeIncome, 100),
+		bill(2, 1, model.IncomeTypeExpense, 30),
+		bill(3, 1, model.IncomeTypeExpense, 20),
+		// 没有标签
+		bill(4, 1, model.IncomeTypeExpense, 50),
+		// 其他账本
+		bill(5, 2, model.IncomeTypeExpense, 10),
+	}
+	links := []model.BillRecordTag{
+		{BillRecordID: 1, TagID: 1},
+		{BillRecordID: 1, TagID: 2},
+		{BillRecordID: 2, TagID: 1},
+		{BillRecordID: 3, TagID: 3},
+		{BillRecordID: 5, TagID: 2},
+	}
+	for _, v := range []any{&tags, &bills, &links} {
+		if err := db.Create(v).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.Delete(&model.Tag{}, 3).Error; err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestTaggedBillIDs(t *testing.T) {
+	db := newBillTagTestDB(t)
+	tests := []struct {
+		name   string
+		tagIDs []uint
+		want   []uint
+	}{
+		{"单个标签", []uint{1}, []uint{1, 2}},
+		{"带有任一标签", []uint{1, 2}, []uint{1, 2, 5}},
+		{"没有账单的标签", []uint{9}, []uint{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []uint{}
+			if err := db.Model(&model.BillRecord{}).Where("id IN (?)", TaggedBillIDs(db, tt.tagIDs)).Order("id").Pluck("id", &got).Error; err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("bill ids = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSumByTag(t *testing.T) {
+	db := newBillTagTestDB(t)
+	tests := []struct {
+		name   string
+		tagIDs []uint
+		want   []TagTotal
+	}{
+		{
+			"全部标签，多个标签的账单分别计入",
+			nil,
+			[]TagTotal{{TagID: 1, TagName: "旅行", IncomeTotal: 100, ExpenseTotal: 30}, {TagID: 2, TagName: "报销", IncomeTotal: 100}},
+		},
+		{"指定标签", []uint{2}, []TagTotal{{TagID: 2, TagName: "报销", IncomeTotal: 100}}},
+		{"已删除的标签不统计", []uint{3}, []TagTotal{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := db.Table("(?) AS bill_records", db.Model(&model.BillRecord{})).Where("bill_records.ledger_id = ?", 1)
+			got, err := SumByTag(lines, tt.tagIDs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SumByTag() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/zxc7563598/fintrack-backend/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 回收站自动清理时每批彻底删除的账单数
+const purgeBillChunkSize = 500
+
+// BillTradeNoExists 判断账本中是否已有该交易单号，回收站中的账单及已彻底删除、已移至其他账本的单号同样视为存在
+func BillTradeNoExists(db *gorm.DB, ledgerID uint, tradeNo string) (bool, error) {
+	if tradeNo == "" {
+		return false, nil
+	}
+	var bill model.BillRecord
+	err := db.Unscoped().Select("id").Where("ledger_id = ? AND trade_no = ?", ledgerID, tradeNo).First(&bill).Error
+	if err == nil {
+		return true, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, err
+	}
+	var count int64
+	if err := db.Model(&model.BillTradeTombstone{}).Where("ledger_id = ? AND trade_no = ?", ledgerID, tradeNo).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// PurgeBillRecords 彻底删除回收站中的账单，未在回收站中的账单不受影响
+// 交易单号写入墓碑表以免重新导入，拆分、标签、报销明细、附件、退款关联随之删除，其他功能中的关联置为0
+// 不再被引用的附件文件由附件清理任务删除，每笔账单记录一条彻底删除的变更历史
+// 返回删除的记录数
+func PurgeBillRecords(db *gorm.DB, userID uint, source model.BillChangeSource, ids []uint) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	var affected int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var bills []model.BillRecord
+		if err := tx.Unscoped().
+			Where("id IN ? AND deleted_at IS NOT NULL", ids).
+			Find(&bills).Error; err != nil {
+			return err
+		}
+		if len(bills) == 0 {
+			return nil
+		}
+		billIDs := make([]uint, 0, len(bills))
+		tombstones := []model.BillTradeTombstone{}
+		histories := make([]model.BillRecordHistory, 0, len(bills))
+		for _, b := range bills {
+			billIDs = append(billIDs, b.ID)
+			if b.TradeNo != "" {
+				tombstones = append(tombstones, model.BillTradeTombstone{LedgerID: b.LedgerID, TradeNo: b.TradeNo})
+			}
+			history, err := newBillHistory(userID, source, model.BillActionPurge, b, model.BillRecord{})
+			if err != nil {
+				return err
+			}
+			histories = append(histories, history)
+		}
+		if err := tx.CreateInBatches(&histories, 100).Error; err != nil {
+			return err
+		}
+		if len(tombstones) > 0 {
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(&tombstones, 100).Error; err != nil {
+				return err
+			}
+		}
+		// 删除从属于账单的数据
+		for _, m := range []any{&model.BillSplit{}, &model.BillRecordTag{}, &model.ReimbursementItem{}, &model.BillAttachment{}} {
+			if err := tx.Unscoped().Where("bill_record_id IN ?", billIDs).Delete(m).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("refund_bill_record_id IN ? OR original_bill_record_id IN ?", billIDs, billIDs).Delete(&model.BillRefund{}).Error; err != nil {
+			return err
+		}
+		// 解除其他功能对账单的关联
+		if err := RelinkBillRecords(tx, billIDs, 0); err != nil {
+			return err
+		}
+		result := tx.Unscoped().Where("id IN ?", billIDs).Delete(&model.BillRecord{})
+		if result.Error != nil {
+			return result.Error
+		}
+		affected = result.RowsAffected
+		return nil
+	})
+	return affected, err
+}
+
+// RelinkBillRecords 将借贷、分摊、投资、周期记账、附件、报销到账、退款中对账单的关联改为 toID，toID 为0时解除关联
+// 只修改其他数据对账单的引用，账单本身不变，因此不记录账单变更历史
+func RelinkBillRecords(db *gorm.DB, fromIDs []uint, toID uint) error {
+	for _, m := range []any{&model.Loan{}, &model.LoanRepayment{}, &model.SplitExpense{}, &model.SplitSettlement{}, &model.InvestmentTransaction{}, &model.RecurringBillOccurrence{}, &model.BillAttachment{}} {
+		if err := db.Unscoped().Model(m).Where("bill_record_id IN ?", fromIDs).Update("bill_record_id", toID).Error; err != nil {
+			return err
+		}
+	}
+	if err := db.Model(&model.BillRefund{}).Where("original_bill_record_id IN ?", fromIDs).Update("original_bill_record_id", toID).Error; err != nil {
+		return err
+	}
+	// 每笔退款账单只能有一条关联：目标账单尚未关联时保留第一条改为目标账单，其余重复的关联删除
+	var refundLinks []model.BillRefund
+	if err := db.Where("refund_bill_record_id IN ?", fromIDs).Order("id").Find(&refundLinks).Error; err != nil {
+		return err
+	}
+	if len(refundLinks) > 0 && toID > 0 {
+		var linked int64
+		if err := db.Model(&model.BillRefund{}).Where("refund_bill_record_id = ?", toID).Count(&linked).Error; err != nil {
+			return err
+		}
+		if linked == 0 {
+			if err := db.Model(&model.BillRefund{}).Where("id = ?", refundLinks[0].ID).Update("refund_bill_record_id", toID).Error; err != nil {
+				return err
+			}
+		}
+	}
+	if len(refundLinks) > 0 {
+		if err := db.Where("refund_bill_record_id IN ?", fromIDs).Delete(&model.BillRefund{}).Error; err != nil {
+			return err
+		}
+	}
+	return db.Unscoped().Model(&model.ReimbursementClaim{}).
+		Where("income_bill_record_id IN ?", fromIDs).
+		Update("income_bill_record_id", toID).Error
+}
+
+// PurgeExpiredBillRecords 彻底删除在 before 之前进入回收站的账单，返回删除的记录数
+func PurgeExpiredBillRecords(db *gorm.DB, before time.Time) (int64, error) {
+	var ids []uint
+	if err := db.Unscoped().Model(&model.BillRecord{}).
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+		Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+	// 分批删除，避免单个事务及 IN 条件过大
+	var affected int64
+	for chunk := range slices.Chunk(ids, purgeBillChunkSize) {
+		rows, err := PurgeBillRecords(db, 0, model.BillSourceSystem, chunk)
+		affected += rows
+		if err != nil {
+			return affected, err
+		}
+	}
+	return affected, nil
+}
+
+// StartBillTrashJob 启动回收站自动清理任务：按间隔彻底删除超过保留时长的账单，保留时长为0时不启动
+func StartBillTrashJob(db *gorm.DB, retention time.Duration, interval time.Duration) {
+	if retention <= 0 {
+		return
+	}
+	go func() {
+		for {
+			if _, err := PurgeExpiredBillRecords(db, time.Now().Add(-retention)); err != nil {
+				log.Printf("回收站清理失败: %v", err)
+			}
+			time.Sleep(interval)
+		}
+	}()
+}
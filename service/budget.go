@@ -0,0 +1,83 @@
+package service
+
+import (
+	"sort"
+	"time"
+
+	"github.com/zxc7563598/fintrack-backend/model"
+)
+
+// 预算月份格式
+const BudgetMonthLayout = "2006-01"
+
+// 单月预算执行情况
+type BudgetPeriod struct {
+	Month     string  // 月份
+	Base      float64 // 当月设置的预算
+	Rollover  float64 // 上月结转的金额
+	Available float64 // 当月可用预算（设置 + 结转）
+	Actual    float64 // 当月实际支出
+}
+
+// BudgetTimeline 计算同一分类的预算从首次设置的月份到 until 的逐月执行情况
+// budgets 为同一分类的预算设置，actual 为按月份汇总的实际支出
+// 每个月使用不晚于该月的最近一次设置；开启结转时，上月未用完的部分计入当月
+func BudgetTimeline(budgets []model.Budget, actual map[string]float64, until string) []BudgetPeriod {
+	if len(budgets) == 0 {
+		return nil
+	}
+	sorted := append([]model.Budget(nil), budgets...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Month < sorted[j].Month
+	})
+	start, err := time.Parse(BudgetMonthLayout, sorted[0].Month)
+	if err != nil {
+		return nil
+	}
+	end, err := time.Parse(BudgetMonthLayout, until)
+	if err != nil {
+		return nil
+	}
+	var periods []BudgetPeriod
+	var carry float64
+	idx := 0
+	for t := start; !t.After(end); t = t.AddDate(0, 1, 0) {
+		month := t.Format(BudgetMonthLayout)
+		for idx+1 < len(sorted) && sorted[idx+1].Month <= month {
+			idx++
+		}
+		current := sorted[idx]
+		p := BudgetPeriod{
+			Month:  month,
+			Base:   current.Amount,
+			Actual: actual[month],
+		}
+		if current.Rollover {
+			p.Rollover = carry
+		}
+		p.Available = p.Base + p.Rollover
+		periods = append(periods, p)
+		carry = 0
+		if p.Available > p.Actual {
+			carry = p.Available - p.Actual
+		}
+	}
+	return periods
+}
+
+// ProjectMonthEnd 按当月已过天数的日均支出预测月末支出，已结束的月份返回实际支出
+func ProjectMonthEnd(actual float64, month time.Time, now time.Time) float64 {
+	next := month.AddDate(0, 1, 0)
+	if !now.Before(next) {
+		return actual
+	}
+	if now.Before(month) {
+		return actual
+	}
+	total := next.Sub(month).Hours() / 24
+	elapsed := now.Sub(month).Hours() / 24
+	if elapsed < 1 {
+		elapsed = 1
+	}
+	return actual / elapsed * total
+}
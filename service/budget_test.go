@@ -0,0 +1,90 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/zxc7563598/fintrack-backend/model"
+)
+
+func TestBudgetTimeline(t *testing.T) {
+	tests := []struct {
+		name    string
+		budgets []model.Budget
+		actual  map[string]float64
+		until   string
+		want    []BudgetPeriod
+	}{
+		{
+			name:    "没有预算设置",
+			budgets: nil,
+			until:   "2025-03",
+			want:    nil,
+		},
+		{
+			name:    "不结转",
+			budgets: []model.Budget{{Month: "2025-01", Amount: 1000}},
+			actual:  map[string]float64{"2025-01": 600, "2025-02": 1200},
+			until:   "2025-03",
+			want: []BudgetPeriod{
+				{Month: "2025-01", Base: 1000, Available: 1000, Actual: 600},
+				{Month: "2025-02", Base: 1000, Available: 1000, Actual: 1200},
+				{Month: "2025-03", Base: 1000, Available: 1000, Actual: 0},
+			},
+		},
+		{
+			name:    "结转未用完的部分，超支不结转",
+			budgets: []model.Budget{{Month: "2025-01", Amount: 1000, Rollover: true}},
+			actual:  map[string]float64{"2025-01": 600, "2025-02": 1600, "2025-03": 200},
+			until:   "2025-04",
+			want: []BudgetPeriod{
+				{Month: "2025-01", Base: 1000, Available: 1000, Actual: 600},
+				{Month: "2025-02", Base: 1000, Rollover: 400, Available: 1400, Actual: 1600},
+				{Month: "2025-03", Base: 1000, Available: 1000, Actual: 200},
+				{Month: "2025-04", Base: 1000, Rollover: 800, Available: 1800, Actual: 0},
+			},
+		},
+		{
+			name: "按月使用最近一次设置（乱序传入）",
+			budgets: []model.Budget{
+				{Month: "2025-03", Amount: 500},
+				{Month: "2025-01", Amount: 1000, Rollover: true},
+			},
+			actual: map[string]float64{"2025-01": 900, "2025-02": 900},
+			until:  "2025-03",
+			want: []BudgetPeriod{
+				{Month: "2025-01", Base: 1000, Available: 1000, Actual: 900},
+				{Month: "2025-02", Base: 1000, Rollover: 100, Available: 1100, Actual: 900},
+				{Month: "2025-03", Base: 500, Available: 500, Actual: 0},
+			},
+		},
+		{
+			name:    "跨年",
+			budgets: []model.Budget{{Month: "2024-12", Amount: 300}},
+			until:   "2025-01",
+			want: []BudgetPeriod{
+				{Month: "2024-12", Base: 300, Available: 300},
+				{Month: "2025-01", Base: 300, Available: 300},
+			},
+		},
+		{
+			name:    "截止月份早于首次设置",
+			budgets: []model.Budget{{Month: "2025-05", Amount: 300}},
+			until:   "2025-04",
+			want:    nil,
+		},
+		{
+			name:    "月份格式错误",
+			budgets: []model.Budget{{Month: "2025/05", Amount: 300}},
+			until:   "2025-06",
+			want:    nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BudgetTimeline(tt.budgets, tt.actual, tt.until); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BudgetTimeline() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package service
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zxc7563598/fintrack-backend/model"
+)
+
+// 信用账户单期账单
+type CreditStatement struct {
+	StartAt     int64   // 账单周期开始时间
+	StatementAt int64   // 账单日（周期结束时间）
+	DueAt       int64   // 最后还款日
+	Charges     float64 // 本期消费
+	Refunds     float64 // 本期退款
+	Amount      float64 // 本期应还（消费 - 退款）
+	Repaid      float64 // 已还金额
+	Outstanding float64 // 未还金额
+}
+
+// 指定月份的账单日，超出当月天数取月末
+func creditDay(year int, month time.Month, day int, loc *time.Location) time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, loc)
+	lastDay := first.AddDate(0, 1, -1).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+// CreditStatementPeriod 返回时间 t 所在账单周期的开始时间、账单日（周期最后一秒）及最后还款日
+// 周期为上一账单日次日 00:00 至本账单日 23:59:59
+func CreditStatementPeriod(acc model.CreditAccount, t time.Time) (time.Time, time.Time, time.Time) {
+	loc := t.Location()
+	billing := creditDay(t.Year(), t.Month(), acc.BillingDay, loc)
+	if !t.Before(billing.AddDate(0, 0, 1)) {
+		billing = creditDay(t.Year(), t.Month()+1, acc.BillingDay, loc)
+	}
+	prev := creditDay(billing.Year(), billing.Month()-1, acc.BillingDay, loc)
+	start := prev.AddDate(0, 0, 1)
+	end := billing.AddDate(0, 0, 1).Add(-time.Second)
+	// 还款日不晚于账单日时为次月
+	due := creditDay(billing.Year(), billing.Month(), acc.DueDay, loc)
+	if acc.DueDay <= acc.BillingDay {
+		due = creditDay(billing.Year(), billing.Month()+1, acc.DueDay, loc)
+	}
+	due = due.AddDate(0, 0, 1).Add(-time.Second)
+	return start, end, due
+}
+
+// IsCreditRepayment 判断账单是否为该信用账户的还款记录：不计收支、包含“还款”且匹配关键字，且不是用该账户本身支付
+func IsCreditRepayment(acc model.CreditAccount, r model.BillRecord) bool {
+	if r.IncomeType != uint8(model.IncomeTypeNone) || r.PaymentMethod == acc.PaymentMethod {
+		return false
+	}
+	text := r.Counterparty + " " + r.ProductName
+	if !strings.Contains(text, "还款") {
+		return false
+	}
+	keyword := acc.RepaymentKeyword
+	if keyword == "" {
+		keyword = acc.Name
+	}
+	return strings.Contains(text, keyword)
+}
+
+// BuildCreditStatements 按账单周期汇总信用账户的消费、退款，并将还款按时间先后冲抵最早的未还账单
+// records 为该账户支付的账单，repayments 为还款记录；返回已出账单（按时间正序）、当前未出账单及未冲抵的多还金额
+func BuildCreditStatements(acc model.CreditAccount, records []model.BillRecord, repayments []model.BillRecord, now time.Time) ([]CreditStatement, CreditStatement, float64) {
+	byPeriod := map[int64]*CreditStatement{}
+	for _, r := range records {
+		start, end, due := CreditStatementPeriod(acc, time.Unix(r.TradeTime, 0).In(now.Location()))
+		s := byPeriod[end.Unix()]
+		if s == nil {
+			s = &CreditStatement{StartAt: start.Unix(), StatementAt: end.Unix(), DueAt: due.Unix()}
+			byPeriod[end.Unix()] = s
+		}
+		switch r.IncomeType {
+		case uint8(model.IncomeTypeExpense):
+			s.Charges += r.Amount
+		case uint8(model.IncomeTypeIncome):
+			s.Refunds += r.Amount
+		}
+	}
+	_, currentEnd, _ := CreditStatementPeriod(acc, now)
+	var statements []CreditStatement
+	var current CreditStatement
+	for end, s := range byPeriod {
+		s.Amount = s.Charges - s.Refunds
+		s.Outstanding = s.Amount
+		if end >= currentEnd.Unix() {
+			if end == currentEnd.Unix() {
+				current = *s
+			}
+			continue
+		}
+		statements = append(statements, *s)
+	}
+	if current.StatementAt == 0 {
+		start, end, due := CreditStatementPeriod(acc, now)
+		current = CreditStatement{StartAt: start.Unix(), StatementAt: end.Unix(), DueAt: due.Unix()}
+	}
+	sort.Slice(statements, func(i, j int) bool {
+		return statements[i].StatementAt < statements[j].StatementAt
+	})
+	// 还款按时间先后冲抵账单日早于还款时间的最早未还账单
+	sorted := append([]model.BillRecord(nil), repayments...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].TradeTime < sorted[j].TradeTime
+	})
+	var credit float64
+	for _, r := range sorted {
+		left := r.Amount
+		for i := range statements {
+			s := &statements[i]
+			if left <= 0 || s.StatementAt > r.TradeTime {
+				break
+			}
+			if s.Outstanding <= 0 {
+				continue
+			}
+			pay := left
+			if pay > s.Outstanding {
+				pay = s.Outstanding
+			}
+			s.Repaid += pay
+			s.Outstanding -= pay
+			left -= pay
+		}
+		credit += left
+	}
+	return statements, current, credit
+}
+
+// 分期付款
+type Installment struct {
+	Counterparty    string
+	ProductName     string
+	PaymentMethod   string
+	PeriodAmount    float64 // 每期金额
+	CurrentPeriod   int     // 已还到第几期
+	TotalPeriods    int     // 总期数
+	RemainingPeriod int     // 剩余期数
+	RemainingAmount float64 // 剩余金额（按每期金额估算）
+	LastTradeAt     int64   // 最近一期时间
+	NextTradeAt     int64   // 预计下一期时间，已还完为0
+}
+
+var (
+	// 第3期/共12期、第3/12期
+	installmentPeriodPattern = regexp.MustCompile(`第\s*(\d+)\s*(?:期\s*[/／,，]?\s*共|/|／)\s*(\d+)\s*期`)
+	// (3/12)、3/12期
+	installmentRatioPattern = regexp.MustCompile(`[(（]?\s*(\d+)\s*[/／]\s*(\d+)\s*[)）期]`)
+	// 分12期
+	installmentTotalPattern = regexp.MustCompile(`分\s*(\d+)\s*期`)
+)
+
+// ParseInstallment 从商品名称中解析分期信息，返回当前期数、总期数及去掉期数后的名称
+// 仅包含“分N期”的记录视为分期购买的第1期
+func ParseInstallment(productName string) (int, int, string, bool) {
+	for _, re := range []*regexp.Regexp{installmentPeriodPattern, installmentRatioPattern} {
+		m := re.FindStringSubmatchIndex(productName)
+		if m == nil {
+			continue
+		}
+		current, _ := strconv.Atoi(productName[m[2]:m[3]])
+		total, _ := strconv.Atoi(productName[m[4]:m[5]])
+		if current < 1 || total < 2 || current > total {
+			continue
+		}
+		base := strings.TrimSpace(productName[:m[0]] + productName[m[1]:])
+		return current, total, base, true
+	}
+	if m := installmentTotalPattern.FindStringSubmatchIndex(productName); m != nil {
+		total, _ := strconv.Atoi(productName[m[2]:m[3]])
+		if total >= 2 {
+			base := strings.TrimSpace(productName[:m[0]] + productName[m[1]:])
+			return 1, total, base, true
+		}
+	}
+	return 0, 0, "", false
+}
+
+// DetectInstallments 识别分期付款，同一交易对方、同一商品、同一总期数的记录视为同一笔分期
+func DetectInstallments(records []model.BillRecord) []Installment {
+	groups := map[string]*Installment{}
+	var keys []string
+	for _, r := range records {
+		if r.IncomeType != uint8(model.IncomeTypeExpense) && r.IncomeType != uint8(model.IncomeTypeNone) {
+			continue
+		}
+		current, total, base, ok := ParseInstallment(r.ProductName)
+		if !ok {
+			continue
+		}
+		key := r.Counterparty + "\x00" + base + "\x00" + strconv.Itoa(total)
+		inst := groups[key]
+		if inst == nil {
+			inst = &Installment{Counterparty: r.Counterparty, ProductName: base, TotalPeriods: total}
+			groups[key] = inst
+			keys = append(keys, key)
+		}
+		if current >= inst.CurrentPeriod {
+			inst.CurrentPeriod = current
+			inst.PaymentMethod = r.PaymentMethod
+			inst.PeriodAmount = r.Amount
+			// 仅有分期购买记录时，按总额平摊每期金额
+			if !installmentPeriodPattern.MatchString(r.ProductName) && !installmentRatioPattern.MatchString(r.ProductName) {
+				inst.PeriodAmount = r.Amount / float64(total)
+			}
+		}
+		if r.TradeTime > inst.LastTradeAt {
+			inst.LastTradeAt = r.TradeTime
+		}
+	}
+	result := make([]Installment, 0, len(keys))
+	for _, key := range keys {
+		inst := groups[key]
+		inst.RemainingPeriod = inst.TotalPeriods - inst.CurrentPeriod
+		inst.RemainingAmount = inst.PeriodAmount * float64(inst.RemainingPeriod)
+		if inst.RemainingPeriod > 0 {
+			inst.NextTradeAt = time.Unix(inst.LastTradeAt, 0).AddDate(0, 1, 0).Unix()
+		}
+		result = append(result, *inst)
+	}
+	// 未还完的在前，按下一期时间排序
+	sort.Slice(result, func(i, j int) bool {
+		if (result[i].NextTradeAt == 0) != (result[j].NextTradeAt == 0) {
+			return result[j].NextTradeAt == 0
+		}
+		return result[i].NextTradeAt < result[j].NextTradeAt
+	})
+	return result
+}
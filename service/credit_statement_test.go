@@ -0,0 +1,145 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/zxc7563598/fintrack-backend/model"
+)
+
+func TestCreditStatementPeriod(t *testing.T) {
+	date := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+	endOf := func(y int, m time.Month, d int) time.Time {
+		return date(y, m, d).AddDate(0, 0, 1).Add(-time.Second)
+	}
+	tests := []struct {
+		name        string
+		billingDay  int
+		dueDay      int
+		at          time.Time
+		wantStart   time.Time
+		wantEnd     time.Time
+		wantDueDate time.Time
+	}{
+		{"账单日前", 10, 28, date(2025, 3, 5), date(2025, 2, 11), endOf(2025, 3, 10), endOf(2025, 3, 28)},
+		{"账单日当天计入本期", 10, 28, time.Date(2025, 3, 10, 23, 0, 0, 0, time.UTC), date(2025, 2, 11), endOf(2025, 3, 10), endOf(2025, 3, 28)},
+		{"账单日次日进入下一期", 10, 28, date(2025, 3, 11), date(2025, 3, 11), endOf(2025, 4, 10), endOf(2025, 4, 28)},
+		{"还款日不晚于账单日为次月", 20, 8, date(2025, 3, 5), date(2025, 2, 21), endOf(2025, 3, 20), endOf(2025, 4, 8)},
+		{"账单日31日遇到二月取月末", 31, 20, date(2025, 2, 15), date(2025, 2, 1), endOf(2025, 2, 28), endOf(2025, 3, 20)},
+		{"二月月末后的周期从3月1日开始", 31, 20, date(2025, 3, 1), date(2025, 3, 1), endOf(2025, 3, 31), endOf(2025, 4, 20)},
+		{"闰年二月月末", 31, 20, date(2024, 2, 29), date(2024, 2, 1), endOf(2024, 2, 29), endOf(2024, 3, 20)},
+		{"跨年", 31, 20, date(2025, 1, 15), date(2025, 1, 1), endOf(2025, 1, 31), endOf(2025, 2, 20)},
+		{"还款日31日遇到小月取月末", 5, 31, date(2025, 4, 3), date(2025, 3, 6), endOf(2025, 4, 5), endOf(2025, 4, 30)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			acc := model.CreditAccount{BillingDay: tt.billingDay, DueDay: tt.dueDay}
+			start, end, due := CreditStatementPeriod(acc, tt.at)
+			if !start.Equal(tt.wantStart) || !end.Equal(tt.wantEnd) || !due.Equal(tt.wantDueDate) {
+				t.Errorf("CreditStatementPeriod(%v) = %v, %v, %v, want %v, %v, %v", tt.at, start, end, due, tt.wantStart, tt.wantEnd, tt.wantDueDate)
+			}
+		})
+	}
+}
+
+func TestBuildCreditStatements(t *testing.T) {
+	unix := func(y int, m time.Month, d int) int64 {
+		return time.Date(y, m, d, 12, 0, 0, 0, time.UTC).Unix()
+	}
+	endOf := func(y int, m time.Month, d int) int64 {
+		return time.Date(y, m, d, 23, 59, 59, 0, time.UTC).Unix()
+	}
+	startOf := func(y int, m time.Month, d int) int64 {
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix()
+	}
+	acc := model.CreditAccount{Name: "招商信用卡", PaymentMethod: "招商银行信用卡(1234)", BillingDay: 10, DueDay: 28}
+	monthEnd := model.CreditAccount{Name: "招商信用卡", PaymentMethod: "招商银行信用卡(1234)", BillingDay: 31, DueDay: 20}
+	expense := func(tradeTime int64, amount float64) model.BillRecord {
+		return model.BillRecord{IncomeType: uint8(model.IncomeTypeExpense), Amount: amount, TradeTime: tradeTime}
+	}
+	refund := func(tradeTime int64, amount float64) model.BillRecord {
+		return model.BillRecord{IncomeType: uint8(model.IncomeTypeIncome), Amount: amount, TradeTime: tradeTime}
+	}
+	repay := func(tradeTime int64, amount float64) model.BillRecord {
+		return model.BillRecord{IncomeType: uint8(model.IncomeTypeNone), Amount: amount, TradeTime: tradeTime}
+	}
+	jan := CreditStatement{StartAt: startOf(2024, 12, 11), StatementAt: endOf(2025, 1, 10), DueAt: endOf(2025, 1, 28)}
+	feb := CreditStatement{StartAt: startOf(2025, 1, 11), StatementAt: endOf(2025, 2, 10), DueAt: endOf(2025, 2, 28)}
+	mar := CreditStatement{StartAt: startOf(2025, 2, 11), StatementAt: endOf(2025, 3, 10), DueAt: endOf(2025, 3, 28)}
+	with := func(s CreditStatement, charges, refunds, repaid float64) CreditStatement {
+		s.Charges, s.Refunds, s.Repaid = charges, refunds, repaid
+		s.Amount = charges - refunds
+		s.Outstanding = s.Amount - repaid
+		return s
+	}
+	tests := []struct {
+		name           string
+		acc            model.CreditAccount
+		records        []model.BillRecord
+		repayments     []model.BillRecord
+		now            time.Time
+		wantStatements []CreditStatement
+		wantCurrent    CreditStatement
+		wantCredit     float64
+	}{
+		{
+			name:        "没有消费",
+			acc:         acc,
+			now:         time.Date(2025, 3, 5, 0, 0, 0, 0, time.UTC),
+			wantCurrent: mar,
+		},
+		{
+			name:           "按周期汇总消费和退款",
+			acc:            acc,
+			records:        []model.BillRecord{expense(unix(2025, 1, 5), 100), expense(unix(2025, 1, 20), 200), refund(unix(2025, 1, 25), 50), expense(unix(2025, 3, 1), 30)},
+			now:            time.Date(2025, 3, 5, 0, 0, 0, 0, time.UTC),
+			wantStatements: []CreditStatement{with(jan, 100, 0, 0), with(feb, 200, 50, 0)},
+			wantCurrent:    with(mar, 30, 0, 0),
+		},
+		{
+			name:           "还款冲抵最早的未还账单，多还部分不冲抵之后出账的账单",
+			acc:            acc,
+			records:        []model.BillRecord{expense(unix(2025, 1, 5), 100), expense(unix(2025, 1, 20), 200), refund(unix(2025, 1, 25), 50)},
+			repayments:     []model.BillRecord{repay(unix(2025, 2, 20), 100), repay(unix(2025, 1, 15), 120)},
+			now:            time.Date(2025, 3, 5, 0, 0, 0, 0, time.UTC),
+			wantStatements: []CreditStatement{with(jan, 100, 0, 100), with(feb, 200, 50, 100)},
+			wantCurrent:    mar,
+			wantCredit:     20,
+		},
+		{
+			name:           "一笔还款冲抵多期账单",
+			acc:            acc,
+			records:        []model.BillRecord{expense(unix(2025, 1, 5), 100), expense(unix(2025, 1, 20), 150)},
+			repayments:     []model.BillRecord{repay(unix(2025, 2, 20), 300)},
+			now:            time.Date(2025, 3, 5, 0, 0, 0, 0, time.UTC),
+			wantStatements: []CreditStatement{with(jan, 100, 0, 100), with(feb, 150, 0, 150)},
+			wantCurrent:    mar,
+			wantCredit:     50,
+		},
+		{
+			name:           "账单日31日的二月账单",
+			acc:            monthEnd,
+			records:        []model.BillRecord{expense(unix(2025, 2, 28), 80), expense(unix(2025, 3, 1), 20)},
+			now:            time.Date(2025, 3, 5, 0, 0, 0, 0, time.UTC),
+			wantStatements: []CreditStatement{with(CreditStatement{StartAt: startOf(2025, 2, 1), StatementAt: endOf(2025, 2, 28), DueAt: endOf(2025, 3, 20)}, 80, 0, 0)},
+			wantCurrent:    with(CreditStatement{StartAt: startOf(2025, 3, 1), StatementAt: endOf(2025, 3, 31), DueAt: endOf(2025, 4, 20)}, 20, 0, 0),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statements, current, credit := BuildCreditStatements(tt.acc, tt.records, tt.repayments, tt.now)
+			if !reflect.DeepEqual(statements, tt.wantStatements) {
+				t.Errorf("statements = %+v, want %+v", statements, tt.wantStatements)
+			}
+			if current != tt.wantCurrent {
+				t.Errorf("current = %+v, want %+v", current, tt.wantCurrent)
+			}
+			if credit != tt.wantCredit {
+				t.Errorf("credit = %v, want %v", credit, tt.wantCredit)
+			}
+		})
+	}
+}
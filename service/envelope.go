@@ -0,0 +1,117 @@
+package service
+
+import (
+	"sort"
+
+	"github.com/zxc7563598/fintrack-backend/model"
+)
+
+// 金额在统计月份之前与统计月份内的发生额
+type EnvelopeFlow struct {
+	Before  float64
+	Current float64
+}
+
+// Total 截至统计月份月末的累计发生额
+func (f EnvelopeFlow) Total() float64 {
+	return f.Before + f.Current
+}
+
+// 单个信封在统计月份的资金情况
+type EnvelopeBalance struct {
+	Envelope model.Envelope
+	Carried  float64 // 上月结余
+	Assigned float64 // 本月净转入
+	Spent    float64 // 本月支出
+	Balance  float64 // 月末余额，为负表示超支
+}
+
+// 信封预算月度汇总
+type EnvelopeSummary struct {
+	Income     EnvelopeFlow // 收入
+	Assigned   EnvelopeFlow // 从待分配净转入信封的金额
+	Uncovered  EnvelopeFlow // 未归属任何信封的支出，直接从待分配中扣除
+	ToAssign   float64      // 月末待分配余额
+	Envelopes  []EnvelopeBalance
+	Overspent  float64  // 本月超支合计
+	Unassigned []string // 本月有支出但未归属信封的分类
+}
+
+// BuildEnvelopeSummary 计算信封预算在 month 的资金情况
+// expenses 为按分类汇总的支出，income 为收入；信封余额按月累积结转
+// 已删除信封的划拨视为与待分配之间的划拨，即信封删除后其余额退回待分配
+func BuildEnvelopeSummary(envelopes []model.Envelope, transfers []model.EnvelopeTransfer, month string, income EnvelopeFlow, expenses map[string]EnvelopeFlow) EnvelopeSummary {
+	summary := EnvelopeSummary{Income: income, Unassigned: []string{}}
+	flows := map[uint]*struct{ in, spent EnvelopeFlow }{}
+	covered := map[string]bool{}
+	for _, e := range envelopes {
+		flows[e.ID] = &struct{ in, spent EnvelopeFlow }{}
+		for _, tradeType := range e.TradeTypes {
+			covered[tradeType] = true
+			flows[e.ID].spent.Before += expenses[tradeType].Before
+			flows[e.ID].spent.Current += expenses[tradeType].Current
+		}
+	}
+	// 未归属信封的支出
+	for tradeType, flow := range expenses {
+		if covered[tradeType] {
+			continue
+		}
+		summary.Uncovered.Before += flow.Before
+		summary.Uncovered.Current += flow.Current
+		if flow.Current > 0 {
+			summary.Unassigned = append(summary.Unassigned, tradeType)
+		}
+	}
+	// 划拨
+	add := func(f *EnvelopeFlow, current bool, amount float64) {
+		if current {
+			f.Current += amount
+		} else {
+			f.Before += amount
+		}
+	}
+	for _, t := range transfers {
+		if t.Month > month {
+			continue
+		}
+		current := t.Month == month
+		from, to := t.FromEnvelopeID, t.ToEnvelopeID
+		if flows[from] == nil {
+			from = 0
+		}
+		if flows[to] == nil {
+			to = 0
+		}
+		if from == to {
+			continue
+		}
+		if from == 0 {
+			add(&summary.Assigned, current, t.Amount)
+		} else {
+			add(&flows[from].in, current, -t.Amount)
+		}
+		if to == 0 {
+			add(&summary.Assigned, current, -t.Amount)
+		} else {
+			add(&flows[to].in, current, t.Amount)
+		}
+	}
+	sort.Strings(summary.Unassigned)
+	summary.ToAssign = income.Total() - summary.Assigned.Total() - summary.Uncovered.Total()
+	for _, e := range envelopes {
+		f := flows[e.ID]
+		b := EnvelopeBalance{
+			Envelope: e,
+			Carried:  f.in.Before - f.spent.Before,
+			Assigned: f.in.Current,
+			Spent:    f.spent.Current,
+		}
+		b.Balance = b.Carried + b.Assigned - b.Spent
+		if b.Balance < 0 {
+			summary.Overspent -= b.Balance
+		}
+		summary.Envelopes = append(summary.Envelopes, b)
+	}
+	return summary
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/zxc7563598/fintrack-backend/model"
+)
+
+func TestBuildEnvelopeSummary(t *testing.T) {
+	food := model.Envelope{ID: 1, Name: "餐饮", TradeTypes: []string{"餐饮"}}
+	traffic := model.Envelope{ID: 2, Name: "交通", TradeTypes: []string{"交通", "加油"}}
+	tests := []struct {
+		name      string
+		envelopes []model.Envelope
+		transfers []model.EnvelopeTransfer
+		income    EnvelopeFlow
+		expenses  map[string]EnvelopeFlow
+		want      EnvelopeSummary
+	}{
+		{
+			name:   "没有信封",
+			income: EnvelopeFlow{Before: 1000, Current: 500},
+			want: EnvelopeSummary{
+				Income:     EnvelopeFlow{Before: 1000, Current: 500},
+				ToAssign:   1500,
+				Unassigned: []string{},
+			},
+		},
+		{
+			name:      "结转、信封间划拨与超支",
+			envelopes: []model.Envelope{food, traffic},
+			transfers: []model.EnvelopeTransfer{
+				{Month: "2025-01", ToEnvelopeID: 1, Amount: 500},
+				{Month: "2025-01", ToEnvelopeID: 2, Amount: 200},
+				{Month: "2025-02", ToEnvelopeID: 1, Amount: 300},
+				{Month: "2025-02", FromEnvelopeID: 1, ToEnvelopeID: 2, Amount: 100},
+				{Month: "2025-03", ToEnvelopeID: 1, Amount: 999},
+			},
+			income: EnvelopeFlow{Before: 3000, Current: 2000},
+			expenses: map[string]EnvelopeFlow{
+				"餐饮": {Before: 400, Current: 450},
+				"交通": {Before: 100, Current: 200},
+				"加油": {Before: 50, Current: 50},
+				"购物": {Current: 80},
+				"医疗": {Before: 20},
+			},
+			want: EnvelopeSummary{
+				Income:    EnvelopeFlow{Before: 3000, Current: 2000},
+				Assigned:  EnvelopeFlow{Before: 700, Current: 300},
+				Uncovered: EnvelopeFlow{Before: 20, Current: 80},
+				ToAssign:  3900,
+				Envelopes: []EnvelopeBalance{
+					{Envelope: food, Carried: 100, Assigned: 200, Spent: 450, Balance: -150},
+					{Envelope: traffic, Carried: 50, Assigned: 100, Spent: 250, Balance: -100},
+				},
+				Overspent:  250,
+				Unassigned: []string{"购物"},
+			},
+		},
+		{
+			name:      "已删除信封的划拨视为与待分配之间的划拨",
+			envelopes: []model.Envelope{food},
+			transfers: []model.EnvelopeTransfer{
+				{Month: "2025-01", ToEnvelopeID: 9, Amount: 300},
+				{Month: "2025-02", FromEnvelopeID: 9, ToEnvelopeID: 1, Amount: 120},
+				{Month: "2025-02", FromEnvelopeID: 9, ToEnvelopeID: 8, Amount: 50},
+			},
+			income:   EnvelopeFlow{Current: 1000},
+			expenses: map[string]EnvelopeFlow{"餐饮": {Current: 100}},
+			want: EnvelopeSummary{
+				Income:   EnvelopeFlow{Current: 1000},
+				Assigned: EnvelopeFlow{Current: 120},
+				ToAssign: 880,
+				Envelopes: []EnvelopeBalance{
+					{Envelope: food, Assigned: 120, Spent: 100, Balance: 20},
+				},
+				Unassigned: []string{},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BuildEnvelopeSummary(tt.envelopes, tt.transfers, "2025-02", tt.income, tt.expenses)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BuildEnvelopeSummary() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/zxc7563598/fintrack-backend/model"
+	"gorm.io/gorm"
+)
+
+// 卖出份额超过持有份额
+var ErrInsufficientUnits = errors.New("insufficient units")
+
+// 持仓汇总
+type InvestmentPosition struct {
+	Units        float64 // 持有份额
+	CostBasis    float64 // 持仓成本
+	RealizedGain float64 // 已实现收益
+	Invested     float64 // 累计投入（买入金额 + 手续费）
+	LastPrice    float64 // 最近一次交易的成交净值
+}
+
+// BuildInvestmentPosition 按交易时间顺序汇总持仓，成本采用移动加权平均法
+// 卖出按平均成本结转，差额计入已实现收益；分红计入已实现收益，再投资的收益同时增加份额和成本
+func BuildInvestmentPosition(txs []model.InvestmentTransaction) (InvestmentPosition, error) {
+	sorted := append([]model.InvestmentTransaction(nil), txs...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].TradeAt != sorted[j].TradeAt {
+			return sorted[i].TradeAt < sorted[j].TradeAt
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+	var p InvestmentPosition
+	for _, t := range sorted {
+		switch model.InvestmentTransactionType(t.Type) {
+		case model.InvestmentBuy:
+			p.Units += t.Units
+			p.CostBasis += t.Amount + t.Fee
+			p.Invested += t.Amount + t.Fee
+		case model.InvestmentSell:
+			if t.Units > p.Units+1e-6 {
+				return p, ErrInsufficientUnits
+			}
+			cost := 0.0
+			if p.Units > 0 {
+				cost = p.CostBasis * t.Units / p.Units
+			}
+			p.RealizedGain += t.Amount - t.Fee - cost
+			p.CostBasis -= cost
+			p.Units -= t.Units
+			if p.Units < 1e-6 {
+				p.Units = 0
+				p.CostBasis = 0
+			}
+		case model.InvestmentDividend:
+			p.RealizedGain += t.Amount - t.Fee
+		case model.InvestmentReinvest:
+			p.Units += t.Units
+			p.CostBasis += t.Amount
+			p.RealizedGain += t.Amount
+		}
+		if t.Price > 0 {
+			p.LastPrice = t.Price
+		}
+	}
+	return p, nil
+}
+
+// RecalculateInvestmentHolding 根据交易记录重新计算并保存持仓份额与成本
+func RecalculateInvestmentHolding(db *gorm.DB, holdingID uint) error {
+	var txs []model.InvestmentTransaction
+	if err := db.Where("holding_id = ?", holdingID).Find(&txs).Error; err != nil {
+		return err
+	}
+	p, err := BuildInvestmentPosition(txs)
+	if err != nil {
+		return err
+	}
+	return db.Model(&model.InvestmentHolding{}).
+		Where("id = ?", holdingID).
+		Updates(map[string]any{
+			"units":         p.Units,
+			"cost_basis":    p.CostBasis,
+			"realized_gain": p.RealizedGain,
+			"invested":      p.Invested,
+		}).Error
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/zxc7563598/fintrack-backend/model"
+)
+
+func TestBuildInvestmentPosition(t *testing.T) {
+	tx := func(id uint, typ model.InvestmentTransactionType, tradeAt int64, units, price, amount, fee float64) model.InvestmentTransaction {
+		return model.InvestmentTransaction{ID: id, Type: uint8(typ), TradeAt: tradeAt, Units: units, Price: price, Amount: amount, Fee: fee}
+	}
+	tests := []struct {
+		name    string
+		txs     []model.InvestmentTransaction
+		want    InvestmentPosition
+		wantErr error
+	}{
+		{
+			name: "没有交易",
+			want: InvestmentPosition{},
+		},
+		{
+			name: "买入手续费计入成本",
+			txs:  []model.InvestmentTransaction{tx(1, model.InvestmentBuy, 100, 100, 10, 1000, 5)},
+			want: InvestmentPosition{Units: 100, CostBasis: 1005, Invested: 1005, LastPrice: 10},
+		},
+		{
+			name: "多次买入按移动加权平均计算成本",
+			txs: []model.InvestmentTransaction{
+				tx(1, model.InvestmentBuy, 100, 100, 10, 1000, 0),
+				tx(2, model.InvestmentBuy, 200, 100, 12, 1200, 0),
+				tx(3, model.InvestmentSell, 300, 50, 13, 650, 2),
+			},
+			want: InvestmentPosition{Units: 150, CostBasis: 1650, RealizedGain: 98, Invested: 2200, LastPrice: 13},
+		},
+		{
+			name: "全部卖出后清零成本",
+			txs: []model.InvestmentTransaction{
+				tx(1, model.InvestmentBuy, 100, 3, 10, 30, 0),
+				tx(2, model.InvestmentSell, 200, 3, 9, 27, 0),
+			},
+			want: InvestmentPosition{Units: 0, CostBasis: 0, RealizedGain: -3, Invested: 30, LastPrice: 9},
+		},
+		{
+			name: "卖出后再买入沿用剩余平均成本",
+			txs: []model.InvestmentTransaction{
+				tx(1, model.InvestmentBuy, 100, 100, 10, 1000, 0),
+				tx(2, model.InvestmentSell, 200, 40, 11, 440, 0),
+				tx(3, model.InvestmentBuy, 300, 40, 12, 480, 0),
+			},
+			want: InvestmentPosition{Units: 100, CostBasis: 1080, RealizedGain: 40, Invested: 1480, LastPrice: 12},
+		},
+		{
+			name: "分红计入收益，再投资同时增加份额和成本",
+			txs: []model.InvestmentTransaction{
+				tx(1, model.InvestmentBuy, 100, 100, 1, 100, 0),
+				tx(2, model.InvestmentDividend, 200, 0, 0, 5, 0.5),
+				tx(3, model.InvestmentReinvest, 300, 2, 1, 2, 0),
+			},
+			want: InvestmentPosition{Units: 102, CostBasis: 102, RealizedGain: 6.5, Invested: 100, LastPrice: 1},
+		},
+		{
+			name: "按交易时间排序，时间相同按ID",
+			txs: []model.InvestmentTransaction{
+				tx(3, model.InvestmentSell, 200, 50, 12, 600, 0),
+				tx(2, model.InvestmentBuy, 100, 50, 10, 500, 0),
+				tx(1, model.InvestmentBuy, 100, 50, 10, 500, 0),
+			},
+			want: InvestmentPosition{Units: 50, CostBasis: 500, RealizedGain: 100, Invested: 1000, LastPrice: 12},
+		},
+		{
+			name: "卖出份额超过持有份额",
+			txs: []model.InvestmentTransaction{
+				tx(1, model.InvestmentBuy, 100, 10, 1, 10, 0),
+				tx(2, model.InvestmentSell, 200, 10.01, 1, 10.01, 0),
+			},
+			wantErr: ErrInsufficientUnits,
+		},
+	}
+	near := func(a, b float64) bool {
+		return math.Abs(a-b) < 1e-6
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := BuildInvestmentPosition(tt.txs)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if !near(got.Units, tt.want.Units) || !near(got.CostBasis, tt.want.CostBasis) || !near(got.RealizedGain, tt.want.RealizedGain) ||
+				!near(got.Invested, tt.want.Invested) || !near(got.LastPrice, tt.want.LastPrice) {
+				t.Errorf("BuildInvestmentPosition() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
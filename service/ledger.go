@@ -0,0 +1,62 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/zxc7563598/fintrack-backend/model"
+	"gorm.io/gorm"
+)
+
+// 默认账本名称
+const DefaultLedgerName = "默认账本"
+
+// DefaultLedger 获取用户的默认账本，不存在时创建
+// 创建时将用户尚未归属账本的历史账单及其他按账本区分的数据归入默认账本
+func DefaultLedger(db *gorm.DB, userID uint) (model.Ledger, error) {
+	var ledger model.Ledger
+	err := db.Where("owner_id = ? AND is_default = ?", userID, true).First(&ledger).Error
+	if err == nil {
+		return ledger, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return ledger, err
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		ledger = model.Ledger{OwnerID: userID, Name: DefaultLedgerName, IsDefault: true}
+		if err := tx.Create(&ledger).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&model.LedgerMember{LedgerID: ledger.ID, UserID: userID, Role: uint8(model.LedgerOwner)}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&model.BillRecord{}).
+			Where("user_id = ? AND ledger_id = 0", userID).
+			Update("ledger_id", ledger.ID).Error; err != nil {
+			return err
+		}
+		for _, m := range model.LedgerScopedModels() {
+			if err := tx.Unscoped().Model(m).
+				Where("user_id = ? AND ledger_id = 0", userID).
+				Update("ledger_id", ledger.ID).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return ledger, err
+}
+
+// LedgerRoleOf 获取用户在账本中的角色，不是成员时返回0
+func LedgerRoleOf(db *gorm.DB, ledgerID uint, userID uint) (model.LedgerRole, error) {
+	var member model.LedgerMember
+	err := db.Joins("JOIN ledgers ON ledgers.id = ledger_members.ledger_id AND ledgers.deleted_at IS NULL").
+		Where("ledger_members.ledger_id = ? AND ledger_members.user_id = ?", ledgerID, userID).
+		First(&member).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return model.LedgerRole(member.Role), nil
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io"
+	"os"
+
+	"github.com/zxc7563598/fintrack-backend/model"
+	"gorm.io/gorm"
+)
+
+// 备份中的附件清单
+type LedgerBackupAttachment struct {
+	model.BillAttachment
+	Hash     string `json:"hash"`
+	Size     int64  `json:"size"`
+	MimeType string `json:"mime_type"`
+	Path     string `json:"path"` // 附件文件在备份中的路径
+}
+
+// WriteLedgerBackup 将账本的完整数据写入 ZIP：账本信息及成员、账单、拆分、标签及标签关联、退款关联、合并记录、
+// 变更历史、商户及别名、交易方式别名、账单规则、附件清单及附件文件
+// 回收站中的账单不包含在内，相同内容的附件文件只写入一份
+func WriteLedgerBackup(db *gorm.DB, store *AttachmentStore, ledgerID uint, w io.Writer) error {
+	var ledger model.Ledger
+	if err := db.First(&ledger, ledgerID).Error; err != nil {
+		return err
+	}
+	var members []model.LedgerMember
+	if err := db.Where("ledger_id = ?", ledgerID).Order("id").Find(&members).Error; err != nil {
+		return err
+	}
+	billIDs := db.Model(&model.BillRecord{}).Select("id").Where("ledger_id = ?", ledgerID)
+	var bills []model.BillRecord
+	if err := db.Where("ledger_id = ?", ledgerID).Order("id").Find(&bills).Error; err != nil {
+		return err
+	}
+	var splits []model.BillSplit
+	if err := db.Where("bill_record_id IN (?)", billIDs).Order("id").Find(&splits).Error; err != nil {
+		return err
+	}
+	var tags []model.BillRecordTag
+	if err := db.Where("bill_record_id IN (?)", billIDs).Order("bill_record_id, tag_id").Find(&tags).Error; err != nil {
+		return err
+	}
+	var tagItems []model.Tag
+	if err := db.Where("ledger_id = ?", ledgerID).Order("id").Find(&tagItems).Error; err != nil {
+		return err
+	}
+	var refunds []model.BillRefund
+	if err := db.Where("ledger_id = ? AND refund_bill_record_id IN (?)", ledgerID, billIDs).Order("id").Find(&refunds).Error; err != nil {
+		return err
+	}
+	var merges []model.BillMerge
+	if err := db.Where("ledger_id = ?", ledgerID).Order("id").Find(&merges).Error; err != nil {
+		return err
+	}
+	var histories []model.BillRecordHistory
+	if err := db.Where("ledger_id = ?", ledgerID).Order("id").Find(&histories).Error; err != nil {
+		return err
+	}
+	var merchants []model.Merchant
+	if err := db.Where("ledger_id = ?", ledgerID).Order("id").Find(&merchants).Error; err != nil {
+		return err
+	}
+	var merchantAliases []model.MerchantAlias
+	if err := db.Where("ledger_id = ?", ledgerID).Order("id").Find(&merchantAliases).Error; err != nil {
+		return err
+	}
+	var paymentMethodAliases []model.PaymentMethodAlias
+	if err := db.Where("ledger_id = ?", ledgerID).Order("id").Find(&paymentMethodAliases).Error; err != nil {
+		return err
+	}
+	var rules []model.BillRule
+	if err := db.Where("ledger_id = ?", ledgerID).Order("id").Find(&rules).Error; err != nil {
+		return err
+	}
+	var attachments []model.BillAttachment
+	if err := db.Where("ledger_id = ? AND bill_record_id IN (?)", ledgerID, billIDs).Order("id").Find(&attachments).Error; err != nil {
+		return err
+	}
+	files := map[uint]model.AttachmentFile{}
+	if len(attachments) > 0 {
+		var rows []model.AttachmentFile
+		if err := db.Where("id IN (?)", db.Model(&model.BillAttachment{}).Select("attachment_file_id").Where("ledger_id = ?", ledgerID)).Find(&rows).Error; err != nil {
+			return err
+		}
+		for _, f := range rows {
+			files[f.ID] = f
+		}
+	}
+	manifest := make([]LedgerBackupAttachment, 0, len(attachments))
+	for _, a := range attachments {
+		f := files[a.AttachmentFileID]
+		manifest = append(manifest, LedgerBackupAttachment{
+			BillAttachment: a,
+			Hash:           f.Hash,
+			Size:           f.Size,
+			MimeType:       f.MimeType,
+			Path:           "attachments/" + f.Hash,
+		})
+	}
+	// 写入 ZIP
+	zw := zip.NewWriter(w)
+	for _, item := range []struct {
+		Name string
+		Data any
+	}{
+		{"ledger.json", ledger},
+		{"ledger_members.json", members},
+		{"bills.json", bills},
+		{"bill_splits.json", splits},
+		{"tags.json", tagItems},
+		{"bill_record_tags.json", tags},
+		{"bill_refunds.json", refunds},
+		{"bill_merges.json", merges},
+		{"bill_histories.json", histories},
+		{"merchants.json", merchants},
+		{"merchant_aliases.json", merchantAliases},
+		{"payment_method_aliases.json", paymentMethodAliases},
+		{"bill_rules.json", rules},
+		{"attachments.json", manifest},
+	} {
+		entry, err := zw.Create(item.Name)
+		if err != nil {
+			return err
+		}
+		if err := json.NewEncoder(entry).Encode(item.Data); err != nil {
+			return err
+		}
+	}
+	written := map[string]bool{}
+	for _, a := range manifest {
+		if written[a.Path] {
+			continue
+		}
+		written[a.Path] = true
+		if err := writeBackupFile(zw, a.Path, store.Path(files[a.AttachmentFileID])); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// 将文件写入 ZIP
+func writeBackupFile(zw *zip.Writer, name, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	entry, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(entry, f)
+	return err
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/zxc7563598/fintrack-backend/model"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrLoanOverpaid   = errors.New("还款金额超过未还金额")
+	ErrLoanBillLinked = errors.New("账单已关联其他借贷或还款记录")
+)
+
+// LoanBalance 借贷记录的已还、未还金额
+type LoanBalance struct {
+	Loan        model.Loan
+	Repaid      float64 // 已还金额
+	Outstanding float64 // 未还金额，已还清为0
+	Overdue     bool    // 已过约定还款时间仍未还清
+}
+
+// PersonLoanBalance 联系人未还清借贷的汇总
+type PersonLoanBalance struct {
+	PersonID     uint
+	Receivable   float64 // 对方欠我
+	Payable      float64 // 我欠对方
+	Net          float64 // 应收减应付
+	OverdueCount int     // 逾期笔数
+}
+
+// BuildLoanBalances 根据各借贷记录的已还金额计算未还金额及是否逾期，保持 loans 的顺序
+// repaid 为借贷记录ID到已还金额的映射
+func BuildLoanBalances(loans []model.Loan, repaid map[uint]float64, now time.Time) []LoanBalance {
+	balances := make([]LoanBalance, 0, len(loans))
+	for _, l := range loans {
+		outstanding := l.Amount - repaid[l.ID]
+		if outstanding < 0.005 {
+			outstanding = 0
+		}
+		balances = append(balances, LoanBalance{
+			Loan:        l,
+			Repaid:      repaid[l.ID],
+			Outstanding: outstanding,
+			Overdue:     outstanding > 0 && l.DueAt > 0 && l.DueAt < now.Unix(),
+		})
+	}
+	return balances
+}
+
+// SummarizePersonLoans 按联系人汇总未还清的借贷，按联系人首次出现的顺序返回
+func SummarizePersonLoans(balances []LoanBalance) []PersonLoanBalance {
+	index := map[uint]int{}
+	list := []PersonLoanBalance{}
+	for _, b := range balances {
+		if b.Outstanding <= 0 {
+			continue
+		}
+		i, ok := index[b.Loan.PersonID]
+		if !ok {
+			i = len(list)
+			index[b.Loan.PersonID] = i
+			list = append(list, PersonLoanBalance{PersonID: b.Loan.PersonID})
+		}
+		p := &list[i]
+		if b.Loan.Direction == uint8(model.LoanLent) {
+			p.Receivable += b.Outstanding
+		} else {
+			p.Payable += b.Outstanding
+		}
+		p.Net = p.Receivable - p.Payable
+		if b.Overdue {
+			p.OverdueCount++
+		}
+	}
+	return list
+}
+
+// AddLoanRepayment 在事务中校验未还金额并写入还款记录，返回还款后的未还金额
+// 关联的账单已被其他借贷或还款记录关联时返回 ErrLoanBillLinked，超过未还金额时返回 ErrLoanOverpaid
+func AddLoanRepayment(db *gorm.DB, loan model.Loan, repayment *model.LoanRepayment) (float64, error) {
+	var outstanding float64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if repayment.BillRecordID > 0 {
+			var linked int64
+			if err := tx.Model(&model.LoanRepayment{}).Where("bill_record_id = ?", repayment.BillRecordID).Count(&linked).Error; err != nil {
+				return err
+			}
+			if linked == 0 {
+				if err := tx.Model(&model.Loan{}).Where("bill_record_id = ?", repayment.BillRecordID).Count(&linked).Error; err != nil {
+					return err
+				}
+			}
+			if linked > 0 {
+				return ErrLoanBillLinked
+			}
+		}
+		var repaid float64
+		if err := tx.Model(&model.LoanRepayment{}).Select("COALESCE(SUM(amount),0)").Where("loan_id = ?", loan.ID).Scan(&repaid).Error; err != nil {
+			return err
+		}
+		if repayment.Amount <= 0 || repayment.Amount > loan.Amount-repaid+0.005 {
+			return ErrLoanOverpaid
+		}
+		outstanding = loan.Amount - repaid - repayment.Amount
+		return tx.Create(repayment).Error
+	})
+	return outstanding, err
+}
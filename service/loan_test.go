@@ -0,0 +1,131 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/zxc7563598/fintrack-backend/model"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestBuildLoanBalances(t *testing.T) {
+	now := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+	past := now.AddDate(0, 0, -1).Unix()
+	future := now.AddDate(0, 0, 1).Unix()
+	tests := []struct {
+		name   string
+		loan   model.Loan
+		repaid float64
+		want   LoanBalance
+	}{
+		{"未还款", model.Loan{ID: 1, Amount: 100, DueAt: future}, 0, LoanBalance{Outstanding: 100}},
+		{"部分还款", model.Loan{ID: 1, Amount: 100, DueAt: future}, 40, LoanBalance{Repaid: 40, Outstanding: 60}},
+		{"已过还款日未还清", model.Loan{ID: 1, Amount: 100, DueAt: past}, 40, LoanBalance{Repaid: 40, Outstanding: 60, Overdue: true}},
+		{"已过还款日已还清", model.Loan{ID: 1, Amount: 100, DueAt: past}, 100, LoanBalance{Repaid: 100}},
+		{"未约定还款日不逾期", model.Loan{ID: 1, Amount: 100}, 0, LoanBalance{Outstanding: 100}},
+		{"不足一分视为还清", model.Loan{ID: 1, Amount: 0.3, DueAt: past}, 0.1 + 0.2, LoanBalance{Repaid: 0.1 + 0.2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BuildLoanBalances([]model.Loan{tt.loan}, map[uint]float64{tt.loan.ID: tt.repaid}, now)
+			tt.want.Loan = tt.loan
+			if len(got) != 1 || got[0] != tt.want {
+				t.Errorf("BuildLoanBalances() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummarizePersonLoans(t *testing.T) {
+	balance := func(personID uint, direction model.LoanDirection, outstanding float64, overdue bool) LoanBalance {
+		return LoanBalance{Loan: model.Loan{PersonID: personID, Direction: uint8(direction)}, Outstanding: outstanding, Overdue: overdue}
+	}
+	tests := []struct {
+		name     string
+		balances []LoanBalance
+		want     []PersonLoanBalance
+	}{
+		{"没有借贷", nil, []PersonLoanBalance{}},
+		{"已还清的不计入", []LoanBalance{balance(1, model.LoanLent, 0, false)}, []PersonLoanBalance{}},
+		{
+			"借出与借入相抵，按联系人首次出现顺序",
+			[]LoanBalance{
+				balance(2, model.LoanBorrowed, 30, false),
+				balance(1, model.LoanLent, 100, true),
+				balance(2, model.LoanLent, 50, true),
+				balance(1, model.LoanLent, 20, false),
+			},
+			[]PersonLoanBalance{
+				{PersonID: 2, Receivable: 50, Payable: 30, Net: 20, OverdueCount: 1},
+				{PersonID: 1, Receivable: 120, Net: 120, OverdueCount: 1},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SummarizePersonLoans(tt.balances); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SummarizePersonLoans() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddLoanRepayment(t *testing.T) {
+	tests := []struct {
+		name            string
+		repayment       model.LoanRepayment
+		wantOutstanding float64
+		wantErr         error
+	}{
+		{"部分还款", model.LoanRepayment{Amount: 30}, 30, nil},
+		{"还清", model.LoanRepayment{Amount: 60, BillRecordID: 3}, 0, nil},
+		{"超过未还金额", model.LoanRepayment{Amount: 60.01}, 0, ErrLoanOverpaid},
+		{"金额为0", model.LoanRepayment{}, 0, ErrLoanOverpaid},
+		{"账单已关联其他还款", model.LoanRepayment{Amount: 10, BillRecordID: 1}, 0, ErrLoanBillLinked},
+		{"账单已关联借贷", model.LoanRepayment{Amount: 10, BillRecordID: 2}, 0, ErrLoanBillLinked},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := db.AutoMigrate(&model.Loan{}, &model.LoanRepayment{}); err != nil {
+				t.Fatal(err)
+			}
+			loans := []model.Loan{
+				{ID: 1, UserID: 1, PersonID: 1, Direction: uint8(model.LoanLent), Amount: 100},
+				{ID: 2, UserID: 1, PersonID: 1, Direction: uint8(model.LoanLent), Amount: 50, BillRecordID: 2},
+			}
+			if err := db.Create(&loans).Error; err != nil {
+				t.Fatal(err)
+			}
+			if err := db.Create(&model.LoanRepayment{UserID: 1, LoanID: 1, Amount: 40, BillRecordID: 1}).Error; err != nil {
+				t.Fatal(err)
+			}
+			repayment := tt.repayment
+			repayment.UserID, repayment.LoanID = 1, 1
+			outstanding, err := AddLoanRepayment(db, loans[0], &repayment)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			var count int64
+			if err := db.Model(&model.LoanRepayment{}).Count(&count).Error; err != nil {
+				t.Fatal(err)
+			}
+			wantCount := int64(1)
+			if tt.wantErr == nil {
+				wantCount = 2
+				if toCents(outstanding) != toCents(tt.wantOutstanding) {
+					t.Errorf("outstanding = %v, want %v", outstanding, tt.wantOutstanding)
+				}
+			}
+			if count != wantCount {
+				t.Errorf("repayments = %d, want %d", count, wantCount)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/zxc7563598/fintrack-backend/model"
+	"gorm.io/gorm"
+)
+
+type merchantRule struct {
+	merchantID uint
+	matchType  model.MatchType
+	pattern    string
+	re         *regexp.Regexp
+}
+
+type MerchantNormalizer struct {
+	rules []merchantRule
+}
+
+// NewMerchantNormalizer 加载账本的商户匹配规则
+func NewMerchantNormalizer(db *gorm.DB, ledgerID uint) (*MerchantNormalizer, error) {
+	var aliases []model.MerchantAlias
+	err := db.Model(&model.MerchantAlias{}).
+		Joins("JOIN merchants ON merchants.id = merchant_aliases.merchant_id AND merchants.deleted_at IS NULL").
+		Where("merchant_aliases.ledger_id = ?", ledgerID).
+		Find(&aliases).Error
+	if err != nil {
+		return nil, err
+	}
+	n := &MerchantNormalizer{}
+	for _, a := range aliases {
+		rule := merchantRule{
+			merchantID: a.MerchantID,
+			matchType:  model.MatchType(a.MatchType),
+			pattern:    a.Pattern,
+		}
+		if rule.matchType == model.MatchTypeRegexp {
+			re, err := regexp.Compile(a.Pattern)
+			if err != nil {
+				continue
+			}
+			rule.re = re
+		}
+		n.rules = append(n.rules, rule)
+	}
+	// 完全匹配优先，其次包含（较长的内容优先），最后正则
+	sort.SliceStable(n.rules, func(i, j int) bool {
+		if n.rules[i].matchType != n.rules[j].matchType {
+			return n.rules[i].matchType < n.rules[j].matchType
+		}
+		return len(n.rules[i].pattern) > len(n.rules[j].pattern)
+	})
+	return n, nil
+}
+
+// Match 返回交易对方对应的商户ID，未匹配返回0
+func (n *MerchantNormalizer) Match(counterparty string) uint {
+	counterparty = strings.TrimSpace(counterparty)
+	if counterparty == "" {
+		return 0
+	}
+	for _, r := range n.rules {
+		switch r.matchType {
+		case model.MatchTypeExact:
+			if counterparty == r.pattern {
+				return r.merchantID
+			}
+		case model.MatchTypeContains:
+			if strings.Contains(counterparty, r.pattern) {
+				return r.merchantID
+			}
+		case model.MatchTypeRegexp:
+			if r.re.MatchString(counterparty) {
+				return r.merchantID
+			}
+		}
+	}
+	return 0
+}
+
+// Apply 对账本已有账单重新进行商户规范化，返回变更的记录数
+func (n *MerchantNormalizer) Apply(db *gorm.DB, userID, ledgerID uint) (int64, error) {
+	var counterpartys []string
+	if err := db.Model(&model.BillRecord{}).
+		Distinct("counterparty").
+		Where("ledger_id = ?", ledgerID).
+		Pluck("counterparty", &counterpartys).Error; err != nil {
+		return 0, err
+	}
+	// 按商户分组后批量更新
+	groups := map[uint][]string{}
+	for _, cp := range counterpartys {
+		merchantID := n.Match(cp)
+		groups[merchantID] = append(groups[merchantID], cp)
+	}
+	var affected int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for merchantID, list := range groups {
+			rows, err := UpdateBillRecords(tx, userID, model.BillSourceRule,
+				map[string]any{"merchant_id": merchantID},
+				"ledger_id = ? AND counterparty IN ? AND merchant_id <> ?", ledgerID, list, merchantID)
+			if err != nil {
+				return err
+			}
+			affected += rows
+		}
+		return nil
+	})
+	return affected, err
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/zxc7563598/fintrack-backend/model"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newMerchantTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&model.BillRecord{}, &model.BillRecordHistory{}, &model.Merchant{}, &model.MerchantAlias{}); err != nil {
+		t.Fatal(err)
+	}
+	merchants := []model.Merchant{
+		{ID: 1, UserID: 1, LedgerID: 1, Name: "星巴克"},
+		{ID: 2, UserID: 1, LedgerID: 1, Name: "美团"},
+		{ID: 3, UserID: 1, LedgerID: 1, Name: "滴滴"},
+		{ID: 4, UserID: 1, LedgerID: 2, Name: "瑞幸"},
+	}
+	alias := func(ledgerID, merchantID uint, matchType model.MatchType, pattern string) model.MerchantAlias {
+		return model.MerchantAlias{UserID: 1, LedgerID: ledgerID, MerchantID: merchantID, MatchType: uint8(matchType), Pattern: pattern}
+	}
+	aliases := []model.MerchantAlias{
+		alias(1, 1, model.MatchTypeContains, "星巴克"),
+		alias(1, 1, model.MatchTypeExact, "星巴克咖啡"),
+		alias(1, 1, model.MatchTypeExact, "美团星巴克"),
+		alias(1, 2, model.MatchTypeContains, "美团"),
+		alias(1, 2, model.MatchTypeContains, "美团外卖"),
+		alias(1, 1, model.MatchTypeRegexp, `^STARBUCKS\s*\d+$`),
+		// 无效的正则忽略
+		alias(1, 2, model.MatchTypeRegexp, `([`),
+		// 商户已删除
+		alias(1, 3, model.MatchTypeRegexp, `^滴滴`),
+		// 其他账本
+		alias(2, 4, model.MatchTypeContains, "瑞幸"),
+	}
+	deleted := alias(1, 2, model.MatchTypeContains, "肯德基")
+	if err := db.Create(&merchants).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&aliases).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&deleted).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Delete(&deleted).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Delete(&model.Merchant{}, 3).Error; err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestMerchantNormalizerMatch(t *testing.T) {
+	db := newMerchantTestDB(t)
+	n, err := NewMerchantNormalizer(db, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name         string
+		counterparty string
+		want         uint
+	}{
+		{"完全匹配", "星巴克咖啡", 1},
+		{"去除首尾空白", "  星巴克咖啡 ", 1},
+		{"包含", "美团买菜", 2},
+		{"完全匹配优先于包含", "美团星巴克", 1},
+		{"较长的包含内容优先", "美团外卖星巴克店", 2},
+		{"正则", "STARBUCKS 1234", 1},
+		{"商户已删除", "滴滴出行", 0},
+		{"别名已删除", "肯德基", 0},
+		{"其他账本的别名", "瑞幸咖啡", 0},
+		{"未匹配", "全家便利店", 0},
+		{"空交易对方", " ", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := n.Match(tt.counterparty); got != tt.want {
+				t.Errorf("Match(%q) = %d, want %d", tt.counterparty, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMerchantNormalizerApply(t *testing.T) {
+	db := newMerchantTestDB(t)
+	bills := []model.BillRecord{
+		{ID: 1, UserID: 1, LedgerID: 1, Counterparty: "星巴克咖啡", TradeTime: 1},
+		// 已是匹配结果
+		{ID: 2, UserID: 1, LedgerID: 1, Counterparty: "美团买菜", MerchantID: 2, TradeTime: 1},
+		// 规则不再匹配时清除商户
+		{ID: 3, UserID: 1, LedgerID: 1, Counterparty: "滴滴出行", MerchantID: 3, TradeTime: 1},
+		// 其他账本
+		{ID: 4, UserID: 1, LedgerID: 2, Counterparty: "星巴克咖啡", TradeTime: 1},
+	}
+	if err := db.Create(&bills).Error; err != nil {
+		t.Fatal(err)
+	}
+	n, err := NewMerchantNormalizer(db, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	affected, err := n.Apply(db, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if affected != 2 {
+		t.Errorf("affected = %d, want 2", affected)
+	}
+	var got []model.BillRecord
+	if err := db.Order("id").Find(&got).Error; err != nil {
+		t.Fatal(err)
+	}
+	merchantIDs := map[uint]uint{}
+	for _, b := range got {
+		merchantIDs[b.ID] = b.MerchantID
+	}
+	want := map[uint]uint{1: 1, 2: 2, 3: 0, 4: 0}
+	if !reflect.DeepEqual(merchantIDs, want) {
+		t.Errorf("merchant_id = %v, want %v", merchantIDs, want)
+	}
+	var history int64
+	if err := db.Model(&model.BillRecordHistory{}).Count(&history).Error; err != nil {
+		t.Fatal(err)
+	}
+	if history != 2 {
+		t.Errorf("history = %d, want 2", history)
+	}
+}
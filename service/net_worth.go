@@ -0,0 +1,102 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/zxc7563598/fintrack-backend/model"
+	"gorm.io/gorm"
+)
+
+// 快照月份格式
+const netWorthMonthLayout = "2006-01"
+
+// ComputeNetWorth 计算账本当前的净资产：手动估值的资产负债，加上关联账户在估值后的收支
+func ComputeNetWorth(db *gorm.DB, ledgerID uint, now time.Time) (model.NetWorthSnapshot, error) {
+	snapshot := model.NetWorthSnapshot{
+		LedgerID: ledgerID,
+		Month:    now.Format(netWorthMonthLayout),
+		TakenAt:  now.Unix(),
+		Items:    []model.NetWorthSnapshotItem{},
+	}
+	var items []model.NetWorthItem
+	if err := db.Where("ledger_id = ?", ledgerID).Order("type, id").Find(&items).Error; err != nil {
+		return snapshot, err
+	}
+	for _, item := range items {
+		value := item.Value
+		if item.Type == uint8(model.NetWorthItemAsset) && item.PaymentMethod != "" {
+			var flow float64
+			if err := db.Model(&model.BillRecord{}).
+				Select("COALESCE(SUM(CASE WHEN income_type = 1 THEN amount WHEN income_type = 2 THEN -amount ELSE 0 END),0)").
+				Where("ledger_id = ? AND payment_method = ? AND trade_time > ? AND trade_time <= ?", ledgerID, item.PaymentMethod, item.ValuedAt, now.Unix()).
+				Where("trade_status_type NOT IN ?", model.UnsettledTradeStatusTypes).
+				Scan(&flow).Error; err != nil {
+				return snapshot, err
+			}
+			value += flow
+		}
+		if item.Type == uint8(model.NetWorthItemLiability) {
+			snapshot.TotalLiabilities += value
+		} else {
+			snapshot.TotalAssets += value
+		}
+		snapshot.Items = append(snapshot.Items, model.NetWorthSnapshotItem{
+			ItemID:   item.ID,
+			Name:     item.Name,
+			Type:     item.Type,
+			Category: item.Category,
+			Value:    value,
+		})
+	}
+	snapshot.NetWorth = snapshot.TotalAssets - snapshot.TotalLiabilities
+	return snapshot, nil
+}
+
+// TakeNetWorthSnapshot 生成账本当月的净资产快照，当月已有快照时覆盖
+func TakeNetWorthSnapshot(db *gorm.DB, userID uint, ledgerID uint, now time.Time) (model.NetWorthSnapshot, error) {
+	snapshot, err := ComputeNetWorth(db, ledgerID, now)
+	if err != nil {
+		return snapshot, err
+	}
+	snapshot.UserID = userID
+	var exist model.NetWorthSnapshot
+	if err := db.Where("ledger_id = ? AND month = ?", ledgerID, snapshot.Month).Limit(1).Find(&exist).Error; err != nil {
+		return snapshot, err
+	}
+	snapshot.ID = exist.ID
+	snapshot.CreatedAt = exist.CreatedAt
+	return snapshot, db.Save(&snapshot).Error
+}
+
+// RunNetWorthSnapshots 为当月还没有快照且设置了资产负债项的账本生成快照，快照记在账本所有者名下
+func RunNetWorthSnapshots(db *gorm.DB, now time.Time) error {
+	var ledgers []model.Ledger
+	if err := db.Where("id IN (?)", db.Model(&model.NetWorthItem{}).Select("ledger_id")).
+		Where("id NOT IN (?)", db.Model(&model.NetWorthSnapshot{}).Select("ledger_id").Where("month = ?", now.Format(netWorthMonthLayout))).
+		Find(&ledgers).Error; err != nil {
+		return err
+	}
+	var errs []error
+	for _, ledger := range ledgers {
+		// 单个账本失败不影响其他账本
+		if _, err := TakeNetWorthSnapshot(db, ledger.OwnerID, ledger.ID, now); err != nil {
+			log.Printf("账本 %d 净资产快照生成失败: %v", ledger.ID, err)
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// StartNetWorthSnapshotJob 启动净资产月度快照任务：启动时及之后按间隔检查，每月为每个账本生成一次快照
+func StartNetWorthSnapshotJob(db *gorm.DB, interval time.Duration) {
+	go func() {
+		for {
+			if err := RunNetWorthSnapshots(db, time.Now()); err != nil {
+				log.Printf("净资产快照生成失败: %v", err)
+			}
+			time.Sleep(interval)
+		}
+	}()
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/zxc7563598/fintrack-backend/model"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newNetWorthTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&model.Ledger{}, &model.BillRecord{}, &model.NetWorthItem{}, &model.NetWorthSnapshot{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestTakeNetWorthSnapshot(t *testing.T) {
+	now := time.Date(2025, 3, 15, 12, 0, 0, 0, time.Local)
+	valuedAt := time.Date(2025, 3, 1, 0, 0, 0, 0, time.Local).Unix()
+	tests := []struct {
+		name   string
+		exist  *model.NetWorthSnapshot
+		wantID uint
+	}{
+		{name: "当月没有快照时新建", wantID: 1},
+		{name: "当月已有快照时覆盖", exist: &model.NetWorthSnapshot{ID: 5, UserID: 1, LedgerID: 1, Month: "2025-03", NetWorth: 1}, wantID: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newNetWorthTestDB(t)
+			items := []model.NetWorthItem{
+				{ID: 1, UserID: 1, LedgerID: 1, Name: "储蓄卡", Type: uint8(model.NetWorthItemAsset), PaymentMethod: "招商银行", Value: 1000, ValuedAt: valuedAt},
+				{ID: 2, UserID: 1, LedgerID: 1, Name: "房产", Type: uint8(model.NetWorthItemAsset), Category: "房产", Value: 500000, ValuedAt: valuedAt},
+				{ID: 3, UserID: 1, LedgerID: 1, Name: "房贷", Type: uint8(model.NetWorthItemLiability), Category: "房贷", Value: 300000, ValuedAt: valuedAt},
+				// 其他账本
+				{ID: 4, UserID: 1, LedgerID: 2, Name: "现金", Type: uint8(model.NetWorthItemAsset), Value: 100, ValuedAt: valuedAt},
+			}
+			bill := func(ledgerID uint, incomeType model.IncomeType, status model.TradeStatusType, amount float64, tradeTime int64) model.BillRecord {
+				return model.BillRecord{UserID: 1, LedgerID: ledgerID, PaymentMethod: "招商银行", IncomeType: uint8(incomeType), TradeStatusType: uint8(status), Amount: amount, TradeTime: tradeTime}
+			}
+			bills := []model.BillRecord{
+				bill(1, model.IncomeTypeIncome, model.TradeStatusSuccess, 200, valuedAt+1),
+				bill(1, model.IncomeTypeExpense, model.TradeStatusSuccess, 50, valuedAt+2),
+				// 估值之前
+				bill(1, model.IncomeTypeExpense, model.TradeStatusSuccess, 30, valuedAt),
+				// 未结算
+				bill(1, model.IncomeTypeExpense, model.TradeStatusPending, 40, valuedAt+3),
+				// 快照之后
+				bill(1, model.IncomeTypeIncome, model.TradeStatusSuccess, 100, now.Unix()+1),
+				// 其他账本
+				bill(2, model.IncomeTypeExpense, model.TradeStatusSuccess, 70, valuedAt+4),
+			}
+			if err := db.Create(&items).Error; err != nil {
+				t.Fatal(err)
+			}
+			if err := db.Create(&bills).Error; err != nil {
+				t.Fatal(err)
+			}
+			if tt.exist != nil {
+				if err := db.Create(tt.exist).Error; err != nil {
+					t.Fatal(err)
+				}
+			}
+			if _, err := TakeNetWorthSnapshot(db, 1, 1, now); err != nil {
+				t.Fatal(err)
+			}
+			var got []model.NetWorthSnapshot
+			if err := db.Find(&got).Error; err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != 1 {
+				t.Fatalf("snapshots = %d, want 1", len(got))
+			}
+			s := got[0]
+			if s.ID != tt.wantID || s.UserID != 1 || s.LedgerID != 1 || s.Month != "2025-03" || s.TakenAt != now.Unix() {
+				t.Errorf("snapshot = %+v", s)
+			}
+			if s.TotalAssets != 501150 || s.TotalLiabilities != 300000 || s.NetWorth != 201150 {
+				t.Errorf("assets, liabilities, net worth = %v, %v, %v, want 501150, 300000, 201150", s.TotalAssets, s.TotalLiabilities, s.NetWorth)
+			}
+			wantItems := []model.NetWorthSnapshotItem{
+				{ItemID: 1, Name: "储蓄卡", Type: uint8(model.NetWorthItemAsset), Value: 1150},
+				{ItemID: 2, Name: "房产", Type: uint8(model.NetWorthItemAsset), Category: "房产", Value: 500000},
+				{ItemID: 3, Name: "房贷", Type: uint8(model.NetWorthItemLiability), Category: "房贷", Value: 300000},
+			}
+			if !reflect.DeepEqual(s.Items, wantItems) {
+				t.Errorf("items = %+v, want %+v", s.Items, wantItems)
+			}
+		})
+	}
+}
+
+func TestRunNetWorthSnapshots(t *testing.T) {
+	db := newNetWorthTestDB(t)
+	now := time.Date(2025, 3, 15, 12, 0, 0, 0, time.Local)
+	ledgers := []model.Ledger{
+		{ID: 1, OwnerID: 11, Name: "当月没有快照"},
+		{ID: 2, OwnerID: 12, Name: "当月已有快照"},
+		{ID: 3, OwnerID: 13, Name: "没有资产负债项"},
+		{ID: 4, OwnerID: 14, Name: "生成失败"},
+		{ID: 5, OwnerID: 15, Name: "只有上月快照"},
+	}
+	items := []model.NetWorthItem{
+		{UserID: 11, LedgerID: 1, Name: "现金", Type: uint8(model.NetWorthItemAsset), Value: 100},
+		{UserID: 12, LedgerID: 2, Name: "现金", Type: uint8(model.NetWorthItemAsset), Value: 200},
+		{UserID: 14, LedgerID: 4, Name: "现金", Type: uint8(model.NetWorthItemAsset), Value: 400},
+		// 由其他成员添加，快照仍记在账本所有者名下
+		{UserID: 99, LedgerID: 5, Name: "现金", Type: uint8(model.NetWorthItemAsset), Value: 500},
+	}
+	snapshots := []model.NetWorthSnapshot{
+		{UserID: 12, LedgerID: 2, Month: "2025-03", NetWorth: 7},
+		{UserID: 15, LedgerID: 5, Month: "2025-02", NetWorth: 8},
+	}
+	for _, v := range []any{&ledgers, &items, &snapshots} {
+		if err := db.Create(v).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 账本4写入快照失败
+	errSave := errors.New("save failed")
+	err := db.Callback().Create().Before("gorm:create").Register("fail_ledger_4", func(tx *gorm.DB) {
+		if s, ok := tx.Statement.Dest.(*model.NetWorthSnapshot); ok && s.LedgerID == 4 {
+			tx.AddError(errSave)
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := RunNetWorthSnapshots(db, now); !errors.Is(err, errSave) {
+		t.Errorf("err = %v, want %v", err, errSave)
+	}
+	var got []model.NetWorthSnapshot
+	if err := db.Order("ledger_id, month").Find(&got).Error; err != nil {
+		t.Fatal(err)
+	}
+	type key struct {
+		LedgerID uint
+		UserID   uint
+		Month    string
+		NetWorth float64
+	}
+	var keys []key
+	for _, s := range got {
+		keys = append(keys, key{s.LedgerID, s.UserID, s.Month, s.NetWorth})
+	}
+	want := []key{
+		{1, 11, "2025-03", 100},
+		{2, 12, "2025-03", 7},
+		{5, 15, "2025-02", 8},
+		{5, 15, "2025-03", 500},
+	}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("snapshots = %+v, want %+v", keys, want)
+	}
+}
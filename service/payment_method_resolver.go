@@ -0,0 +1,54 @@
+package service
+
+import (
+	"github.com/zxc7563598/fintrack-backend/model"
+	"gorm.io/gorm"
+)
+
+// PaymentMethodResolver 将原始交易方式归类为别名。
+// 归类结果在写入时落到账单的 payment_method 上（原始值保留在 raw_payment_method），
+// 统计、预算、信用账户等按 payment_method 查询和单条账单手动修改账户都依赖这一列，
+// 因此别名是有意冗余存储的，而不是在读取时再关联别名表；撤销别名时通过 RevertPaymentMethodAlias 恢复
+type PaymentMethodResolver struct {
+	aliases map[string]string
+}
+
+// NewPaymentMethodResolver 加载账本的交易方式别名
+func NewPaymentMethodResolver(db *gorm.DB, ledgerID uint) (*PaymentMethodResolver, error) {
+	var aliases []model.PaymentMethodAlias
+	if err := db.Where("ledger_id = ?", ledgerID).Find(&aliases).Error; err != nil {
+		return nil, err
+	}
+	r := &PaymentMethodResolver{aliases: make(map[string]string, len(aliases))}
+	for _, a := range aliases {
+		r.aliases[a.RawName] = a.Name
+	}
+	return r, nil
+}
+
+// Resolve 返回原始交易方式归类后的名称，没有别名时原样返回
+func (r *PaymentMethodResolver) Resolve(raw string) string {
+	if name, ok := r.aliases[raw]; ok {
+		return name
+	}
+	return raw
+}
+
+// RevertPaymentMethodAlias 删除原始账户的别名，并将账本中仍为归类结果的账单恢复为原始账户名称（单独修改过账户的账单保持不变）
+func RevertPaymentMethodAlias(tx *gorm.DB, userID, ledgerID uint, raw string) error {
+	var alias model.PaymentMethodAlias
+	result := tx.Where("ledger_id = ? AND raw_name = ?", ledgerID, raw).Limit(1).Find(&alias)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+	if err := tx.Delete(&alias).Error; err != nil {
+		return err
+	}
+	_, err := UpdateBillRecords(tx, userID, model.BillSourceManual,
+		map[string]any{"payment_method": raw},
+		"ledger_id = ? AND raw_payment_method = ? AND payment_method = ?", ledgerID, raw, alias.Name)
+	return err
+}
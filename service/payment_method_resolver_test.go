@@ -0,0 +1,104 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/zxc7563598/fintrack-backend/model"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestRevertPaymentMethodAlias(t *testing.T) {
+	tests := []struct {
+		name        string
+		raw         string
+		wantMethods map[uint]string
+		wantAliases []string
+		wantHistory int64
+	}{
+		{
+			name: "撤销别名恢复仍为归类结果的账单",
+			raw:  "招商银行储蓄卡(1234)",
+			wantMethods: map[uint]string{
+				1: "招商银行储蓄卡(1234)",
+				2: "信用卡",
+				3: "银行卡",
+				4: "银行卡",
+			},
+			wantAliases: []string{"零钱"},
+			wantHistory: 1,
+		},
+		{
+			name: "没有别名时不修改账单",
+			raw:  "余额宝",
+			wantMethods: map[uint]string{
+				1: "银行卡",
+				2: "信用卡",
+				3: "银行卡",
+				4: "银行卡",
+			},
+			wantAliases: []string{"银行卡", "零钱"},
+			wantHistory: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := db.AutoMigrate(&model.BillRecord{}, &model.BillRecordHistory{}, &model.PaymentMethodAlias{}); err != nil {
+				t.Fatal(err)
+			}
+			aliases := []model.PaymentMethodAlias{
+				{UserID: 1, LedgerID: 1, RawName: "招商银行储蓄卡(1234)", Name: "银行卡"},
+				{UserID: 1, LedgerID: 1, RawName: "微信零钱", Name: "零钱"},
+			}
+			bills := []model.BillRecord{
+				// 仍为归类结果
+				{ID: 1, UserID: 1, LedgerID: 1, RawPaymentMethod: "招商银行储蓄卡(1234)", PaymentMethod: "银行卡", TradeTime: 1},
+				// 单独修改过账户
+				{ID: 2, UserID: 1, LedgerID: 1, RawPaymentMethod: "招商银行储蓄卡(1234)", PaymentMethod: "信用卡", TradeTime: 1},
+				// 其他账本
+				{ID: 3, UserID: 1, LedgerID: 2, RawPaymentMethod: "招商银行储蓄卡(1234)", PaymentMethod: "银行卡", TradeTime: 1},
+				// 其他原始账户
+				{ID: 4, UserID: 1, LedgerID: 1, RawPaymentMethod: "建设银行储蓄卡(5678)", PaymentMethod: "银行卡", TradeTime: 1},
+			}
+			if err := db.Create(&aliases).Error; err != nil {
+				t.Fatal(err)
+			}
+			if err := db.Create(&bills).Error; err != nil {
+				t.Fatal(err)
+			}
+			if err := RevertPaymentMethodAlias(db, 1, 1, tt.raw); err != nil {
+				t.Fatal(err)
+			}
+			var got []model.BillRecord
+			if err := db.Order("id").Find(&got).Error; err != nil {
+				t.Fatal(err)
+			}
+			methods := make(map[uint]string, len(got))
+			for _, b := range got {
+				methods[b.ID] = b.PaymentMethod
+			}
+			if !reflect.DeepEqual(methods, tt.wantMethods) {
+				t.Errorf("payment_method = %v, want %v", methods, tt.wantMethods)
+			}
+			var names []string
+			if err := db.Model(&model.PaymentMethodAlias{}).Order("id").Pluck("name", &names).Error; err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(names, tt.wantAliases) {
+				t.Errorf("aliases = %v, want %v", names, tt.wantAliases)
+			}
+			var history int64
+			if err := db.Model(&model.BillRecordHistory{}).Count(&history).Error; err != nil {
+				t.Fatal(err)
+			}
+			if history != tt.wantHistory {
+				t.Errorf("history = %d, want %d", history, tt.wantHistory)
+			}
+		})
+	}
+}
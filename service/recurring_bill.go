@@ -0,0 +1,247 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zxc7563598/fintrack-backend/model"
+	"gorm.io/gorm"
+)
+
+var ErrRecurringLedgerDenied = errors.New("无权在模板所属账本中记账")
+
+// 单次生成的最大期数，避免开始时间过早时一次写入过多数据
+const maxRecurringOccurrencesPerRun = 1000
+
+// RecurringOccurrence 返回模板第 n 期（从0开始）的发生时间
+func RecurringOccurrence(tpl *model.RecurringBill, n int) time.Time {
+	start := time.Unix(tpl.StartAt, 0)
+	interval := tpl.Interval
+	if interval <= 0 {
+		interval = 1
+	}
+	switch model.Frequency(tpl.Frequency) {
+	case model.FrequencyDaily:
+		return start.AddDate(0, 0, n*interval)
+	case model.FrequencyWeekly:
+		return start.AddDate(0, 0, 7*n*interval)
+	case model.FrequencyYearly:
+		return addMonthsClamped(start, 12*n*interval, start.Day())
+	default:
+		day := tpl.DayOfMonth
+		if day <= 0 {
+			day = start.Day()
+		}
+		return addMonthsClamped(start, n*interval, day)
+	}
+}
+
+// 按月偏移并指定日期，超出当月天数时取月末
+func addMonthsClamped(t time.Time, months int, day int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), 0, t.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+// RecurringIndexAfter 返回发生时间晚于 at 的第一期
+func RecurringIndexAfter(tpl *model.RecurringBill, at int64) int {
+	n := 0
+	for ; n < 100000; n++ {
+		if RecurringOccurrence(tpl, n).Unix() > at {
+			break
+		}
+	}
+	return n
+}
+
+// IsRecurringOccurrence 判断指定时间是否为模板的某一期
+func IsRecurringOccurrence(tpl *model.RecurringBill, at int64) bool {
+	for n := 0; n < 100000; n++ {
+		t := RecurringOccurrence(tpl, n).Unix()
+		if t == at {
+			return true
+		}
+		if t > at {
+			return false
+		}
+	}
+	return false
+}
+
+// RecurringEnded 判断指定时间是否已超过模板的结束时间
+func RecurringEnded(tpl *model.RecurringBill, t time.Time) bool {
+	return tpl.EndAt > 0 && t.Unix() > tpl.EndAt
+}
+
+// RecurringLedgerID 返回模板所属的账本ID，未指定账本的模板（账本功能之前创建的）归入用户的默认账本
+func RecurringLedgerID(db *gorm.DB, tpl *model.RecurringBill) (uint, error) {
+	if tpl.LedgerID > 0 {
+		return tpl.LedgerID, nil
+	}
+	ledger, err := DefaultLedger(db, tpl.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return ledger.ID, nil
+}
+
+// 生成周期记账账单所需的账本及规范化工具
+type recurringBillGenerator struct {
+	tpl        *model.RecurringBill
+	ledgerID   uint
+	normalizer *MerchantNormalizer
+	resolver   *PaymentMethodResolver
+}
+
+// 准备模板的账单生成：账单归入模板所属账本，模板创建者需仍具备编辑权限
+func newRecurringBillGenerator(db *gorm.DB, tpl *model.RecurringBill) (*recurringBillGenerator, error) {
+	ledgerID, err := RecurringLedgerID(db, tpl)
+	if err != nil {
+		return nil, err
+	}
+	role, err := LedgerRoleOf(db, ledgerID, tpl.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !role.Allows(model.LedgerEditor) {
+		return nil, ErrRecurringLedgerDenied
+	}
+	normalizer, err := NewMerchantNormalizer(db, ledgerID)
+	if err != nil {
+		return nil, err
+	}
+	resolver, err := NewPaymentMethodResolver(db, ledgerID)
+	if err != nil {
+		return nil, err
+	}
+	return &recurringBillGenerator{tpl: tpl, ledgerID: ledgerID, normalizer: normalizer, resolver: resolver}, nil
+}
+
+// 为期次生成账单（单独修改的金额、备注优先），并将期次标记为已生成
+func (g *recurringBillGenerator) generate(tx *gorm.DB, occ *model.RecurringBillOccurrence, t time.Time) error {
+	tpl := g.tpl
+	bill := model.BillRecord{
+		UserID:           tpl.UserID,
+		LedgerID:         g.ledgerID,
+		TradeNo:          uuid.NewString(),
+		MerchantOrderNo:  uuid.NewString(),
+		Platform:         tpl.Platform,
+		IncomeType:       tpl.IncomeType,
+		TradeType:        tpl.TradeType,
+		ProductName:      tpl.ProductName,
+		Counterparty:     tpl.Counterparty,
+		MerchantID:       g.normalizer.Match(tpl.Counterparty),
+		PaymentMethod:    g.resolver.Resolve(tpl.PaymentMethod),
+		RawPaymentMethod: tpl.PaymentMethod,
+		Amount:           tpl.Amount,
+		TradeStatus:      "交易成功",
+		TradeStatusType:  uint8(model.TradeStatusSuccess),
+		TradeTime:        t.Unix(),
+		Remark:           tpl.Remark,
+	}
+	if occ.Amount > 0 {
+		bill.Amount = occ.Amount
+	}
+	if occ.Remark != "" {
+		bill.Remark = occ.Remark
+	}
+	if err := tx.Create(&bill).Error; err != nil {
+		return err
+	}
+	if err := RecordBillCreated(tx, tpl.UserID, model.BillSourceRule, []model.BillRecord{bill}); err != nil {
+		return err
+	}
+	occ.UserID = tpl.UserID
+	occ.RecurringBillID = tpl.ID
+	occ.ScheduledAt = t.Unix()
+	occ.Status = uint8(model.OccurrenceStatusGenerated)
+	occ.BillRecordID = bill.ID
+	return tx.Save(occ).Error
+}
+
+// MaterializeRecurringBill 将模板截至 now 的到期期次生成为账单（包括应用关闭期间错过的期次），返回生成的账单数
+func MaterializeRecurringBill(db *gorm.DB, tpl *model.RecurringBill, now time.Time) (int, error) {
+	generated := 0
+	generator, err := newRecurringBillGenerator(db, tpl)
+	if err != nil {
+		return 0, err
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		for i := 0; i < maxRecurringOccurrencesPerRun; i++ {
+			t := RecurringOccurrence(tpl, tpl.NextIndex)
+			if t.After(now) || RecurringEnded(tpl, t) {
+				break
+			}
+			var occ model.RecurringBillOccurrence
+			if err := tx.Where("recurring_bill_id = ? AND scheduled_at = ?", tpl.ID, t.Unix()).Limit(1).Find(&occ).Error; err != nil {
+				return err
+			}
+			if occ.Status != uint8(model.OccurrenceStatusSkipped) && occ.Status != uint8(model.OccurrenceStatusGenerated) {
+				if err := generator.generate(tx, &occ, t); err != nil {
+					return err
+				}
+				generated++
+			}
+			tpl.NextIndex++
+		}
+		// 更新下一次生成时间
+		next := RecurringOccurrence(tpl, tpl.NextIndex)
+		tpl.NextRunAt = next.Unix()
+		if RecurringEnded(tpl, next) {
+			tpl.NextRunAt = 0
+		}
+		return tx.Model(&model.RecurringBill{}).
+			Where("id = ?", tpl.ID).
+			Updates(map[string]any{"next_index": tpl.NextIndex, "next_run_at": tpl.NextRunAt}).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return generated, nil
+}
+
+// GenerateRecurringOccurrence 为已过生成时间的期次（如跳过后又单独修改的）补生成账单
+func GenerateRecurringOccurrence(db *gorm.DB, tpl *model.RecurringBill, occ *model.RecurringBillOccurrence) error {
+	generator, err := newRecurringBillGenerator(db, tpl)
+	if err != nil {
+		return err
+	}
+	return generator.generate(db, occ, time.Unix(occ.ScheduledAt, 0))
+}
+
+// RecurringOccurrenceDue 判断期次是否已过生成时间（已由后台任务处理过）
+func RecurringOccurrenceDue(tpl *model.RecurringBill, scheduledAt int64) bool {
+	return scheduledAt < RecurringOccurrence(tpl, tpl.NextIndex).Unix()
+}
+
+// RunRecurringBills 为所有到期的模板生成账单，单个模板生成失败时记录日志并继续处理其他模板
+func RunRecurringBills(db *gorm.DB, now time.Time) error {
+	var templates []model.RecurringBill
+	if err := db.Where("enabled = ? AND next_run_at > 0 AND next_run_at <= ?", true, now.Unix()).Find(&templates).Error; err != nil {
+		return err
+	}
+	for i := range templates {
+		// 单个模板失败不影响其他模板
+		if _, err := MaterializeRecurringBill(db, &templates[i], now); err != nil {
+			log.Printf("周期记账模板 %d 生成失败: %v", templates[i].ID, err)
+		}
+	}
+	return nil
+}
+
+// StartRecurringBillJob 启动周期记账后台任务：启动时立即补齐错过的期次，之后按间隔定时执行
+func StartRecurringBillJob(db *gorm.DB, interval time.Duration) {
+	go func() {
+		for {
+			if err := RunRecurringBills(db, time.Now()); err != nil {
+				log.Printf("周期记账生成失败: %v", err)
+			}
+			time.Sleep(interval)
+		}
+	}()
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zxc7563598/fintrack-backend/model"
+)
+
+func TestRecurringOccurrence(t *testing.T) {
+	date := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 9, 30, 0, 0, time.Local)
+	}
+	tests := []struct {
+		name       string
+		frequency  model.Frequency
+		interval   int
+		dayOfMonth int
+		start      time.Time
+		n          int
+		want       time.Time
+	}{
+		{"首期为开始时间", model.FrequencyMonthly, 1, 0, date(2025, 1, 15), 0, date(2025, 1, 15)},
+		{"每天", model.FrequencyDaily, 1, 0, date(2025, 1, 30), 3, date(2025, 2, 2)},
+		{"每两天", model.FrequencyDaily, 2, 0, date(2025, 1, 1), 5, date(2025, 1, 11)},
+		{"每周", model.FrequencyWeekly, 1, 0, date(2025, 1, 1), 2, date(2025, 1, 15)},
+		{"每两周", model.FrequencyWeekly, 2, 0, date(2025, 1, 1), 2, date(2025, 1, 29)},
+		{"每月沿用开始日期", model.FrequencyMonthly, 1, 0, date(2025, 1, 15), 3, date(2025, 4, 15)},
+		{"每月指定日期", model.FrequencyMonthly, 1, 5, date(2025, 1, 15), 1, date(2025, 2, 5)},
+		{"每月31日遇到小月取月末", model.FrequencyMonthly, 1, 31, date(2025, 1, 31), 1, date(2025, 2, 28)},
+		{"取月末后恢复31日", model.FrequencyMonthly, 1, 31, date(2025, 1, 31), 2, date(2025, 3, 31)},
+		{"闰年二月月末", model.FrequencyMonthly, 1, 31, date(2024, 1, 31), 1, date(2024, 2, 29)},
+		{"每季度（间隔3个月）", model.FrequencyMonthly, 3, 0, date(2025, 11, 10), 1, date(2026, 2, 10)},
+		{"每年", model.FrequencyYearly, 1, 0, date(2025, 6, 1), 2, date(2027, 6, 1)},
+		{"闰日按年重复取月末", model.FrequencyYearly, 1, 0, date(2024, 2, 29), 1, date(2025, 2, 28)},
+		{"间隔为0按1处理", model.FrequencyDaily, 0, 0, date(2025, 1, 1), 1, date(2025, 1, 2)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tpl := &model.RecurringBill{
+				Frequency:  uint8(tt.frequency),
+				Interval:   tt.interval,
+				DayOfMonth: tt.dayOfMonth,
+				StartAt:    tt.start.Unix(),
+			}
+			if got := RecurringOccurrence(tpl, tt.n); !got.Equal(tt.want) {
+				t.Errorf("RecurringOccurrence(%d) = %v, want %v", tt.n, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecurringIndexAfter(t *testing.T) {
+	start := time.Date(2025, 1, 15, 9, 0, 0, 0, time.Local)
+	tpl := &model.RecurringBill{Frequency: uint8(model.FrequencyMonthly), Interval: 1, StartAt: start.Unix()}
+	tests := []struct {
+		name string
+		at   time.Time
+		want int
+	}{
+		{"开始之前", start.Add(-time.Hour), 0},
+		{"恰好为首期", start, 1},
+		{"两期之间", time.Date(2025, 2, 20, 0, 0, 0, 0, time.Local), 2},
+		{"恰好为第三期", time.Date(2025, 3, 15, 9, 0, 0, 0, time.Local), 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RecurringIndexAfter(tpl, tt.at.Unix()); got != tt.want {
+				t.Errorf("RecurringIndexAfter() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
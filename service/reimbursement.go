@@ -0,0 +1,131 @@
+package service
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/zxc7563598/fintrack-backend/model"
+	"gorm.io/gorm"
+)
+
+// 报销收入匹配时的关键字，命中的收入优先匹配
+var reimbursementKeywords = []string{"报销", "差旅"}
+
+// 待匹配的报销单
+type ReimbursementCandidate struct {
+	ClaimID     uint
+	Total       float64 // 报销单内账单合计
+	SubmittedAt int64   // 提交时间，早于该时间的收入不参与匹配
+}
+
+// MatchReimbursementIncome 为已提交、已批准的报销单匹配报销到账的收入账单
+// 金额需一致且在提交之后入账，同一收入只匹配一个报销单；候选收入中优先选择备注或名称含报销关键字的，其次按时间最早
+// 返回报销单ID到收入账单的映射
+func MatchReimbursementIncome(claims []ReimbursementCandidate, incomes []model.BillRecord) map[uint]model.BillRecord {
+	sort.SliceStable(claims, func(i, j int) bool {
+		return claims[i].SubmittedAt < claims[j].SubmittedAt
+	})
+	sort.SliceStable(incomes, func(i, j int) bool {
+		return incomes[i].TradeTime < incomes[j].TradeTime
+	})
+	used := map[uint]bool{}
+	matched := map[uint]model.BillRecord{}
+	for _, claim := range claims {
+		if claim.Total <= 0 {
+			continue
+		}
+		best := -1
+		for i, income := range incomes {
+			if used[income.ID] || income.TradeTime < claim.SubmittedAt || math.Abs(income.Amount-claim.Total) > 0.005 {
+				continue
+			}
+			if best < 0 {
+				best = i
+			}
+			if hasReimbursementKeyword(income) {
+				best = i
+				break
+			}
+		}
+		if best >= 0 {
+			used[incomes[best].ID] = true
+			matched[claim.ClaimID] = incomes[best]
+		}
+	}
+	return matched
+}
+
+// MatchReimbursements 为用户未到账的报销单自动匹配账本中的报销收入，匹配成功的报销单标记为已到账
+// 返回本次匹配成功的报销单数量
+func MatchReimbursements(db *gorm.DB, userID, ledgerID uint) (int, error) {
+	var claims []model.ReimbursementClaim
+	if err := db.Where("user_id = ? AND status IN ? AND income_bill_record_id = 0", userID,
+		[]int{int(model.ReimbursementSubmitted), int(model.ReimbursementApproved)}).
+		Find(&claims).Error; err != nil {
+		return 0, err
+	}
+	if len(claims) == 0 {
+		return 0, nil
+	}
+	totals, err := ReimbursementClaimTotals(db, userID)
+	if err != nil {
+		return 0, err
+	}
+	candidates := make([]ReimbursementCandidate, 0, len(claims))
+	for _, claim := range claims {
+		candidates = append(candidates, ReimbursementCandidate{ClaimID: claim.ID, Total: totals[claim.ID], SubmittedAt: claim.SubmittedAt})
+	}
+	// 已被其他报销单匹配或本身是可报销账单的收入不参与匹配
+	var incomes []model.BillRecord
+	if err := db.Where("ledger_id = ? AND income_type = ?", ledgerID, model.IncomeTypeIncome).
+		Where("id NOT IN (?)", db.Model(&model.ReimbursementClaim{}).Select("income_bill_record_id").Where("user_id = ?", userID)).
+		Where("id NOT IN (?)", db.Model(&model.ReimbursementItem{}).Select("bill_record_id").Where("user_id = ?", userID)).
+		Find(&incomes).Error; err != nil {
+		return 0, err
+	}
+	matched := MatchReimbursementIncome(candidates, incomes)
+	for claimID, income := range matched {
+		if err := db.Model(&model.ReimbursementClaim{}).Where("id = ?", claimID).Updates(map[string]any{
+			"status":                model.ReimbursementPaid,
+			"paid_at":               income.TradeTime,
+			"income_bill_record_id": income.ID,
+		}).Error; err != nil {
+			return 0, err
+		}
+	}
+	return len(matched), nil
+}
+
+// ReimbursementClaimTotals 统计用户各报销单内账单的合计金额
+func ReimbursementClaimTotals(db *gorm.DB, userID uint) (map[uint]float64, error) {
+	type claimTotal struct {
+		ClaimID uint
+		Total   float64
+	}
+	var rows []claimTotal
+	if err := db.Model(&model.ReimbursementItem{}).
+		Select("reimbursement_items.claim_id, COALESCE(SUM(bill_records.amount),0) AS total").
+		Joins("JOIN bill_records ON bill_records.id = reimbursement_items.bill_record_id AND bill_records.deleted_at IS NULL").
+		Where("reimbursement_items.user_id = ? AND reimbursement_items.claim_id > 0", userID).
+		Group("reimbursement_items.claim_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	totals := map[uint]float64{}
+	for _, r := range rows {
+		totals[r.ClaimID] = r.Total
+	}
+	return totals, nil
+}
+
+// 判断收入账单是否含报销关键字
+func hasReimbursementKeyword(bill model.BillRecord) bool {
+	text := bill.ProductName + bill.Counterparty + bill.Remark
+	for _, k := range reimbursementKeywords {
+		if strings.Contains(text, k) {
+			return true
+		}
+	}
+	return false
+}
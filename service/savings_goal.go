@@ -0,0 +1,60 @@
+package service
+
+import (
+	"math"
+	"time"
+
+	"github.com/zxc7563598/fintrack-backend/model"
+)
+
+// 储蓄目标进度及预测
+type SavingsGoalProjection struct {
+	Saved           float64 // 已存金额（含开始前已有金额）
+	Remaining       float64 // 距目标的差额
+	Percent         float64 // 完成百分比
+	MonthsLeft      int     // 距截止时间的剩余月数（含当月），未设置截止时间为0
+	RequiredMonthly float64 // 按期完成每月需存入的金额，未设置截止时间为0
+	AverageMonthly  float64 // 最近几个月的月均存入
+	ProjectedAt     int64   // 按月均存入预计完成时间，无法完成为0
+	OnTrack         bool    // 预计能否在截止时间前完成
+}
+
+// ProjectSavingsGoal 根据已存金额及最近几个月的存入情况计算储蓄目标进度
+// recent 为最近几个完整月份的存入金额
+func ProjectSavingsGoal(goal model.SavingsGoal, contributed float64, recent []float64, now time.Time) SavingsGoalProjection {
+	p := SavingsGoalProjection{Saved: goal.InitialAmount + contributed}
+	p.Remaining = math.Max(goal.TargetAmount-p.Saved, 0)
+	if goal.TargetAmount > 0 {
+		p.Percent = math.Min(math.Round(p.Saved/goal.TargetAmount*10000)/100, 100)
+	}
+	if len(recent) > 0 {
+		var sum float64
+		for _, v := range recent {
+			sum += v
+		}
+		p.AverageMonthly = sum / float64(len(recent))
+	}
+	if p.Remaining == 0 {
+		p.ProjectedAt = now.Unix()
+		p.OnTrack = true
+		return p
+	}
+	if goal.Deadline > 0 {
+		deadline := time.Unix(goal.Deadline, 0)
+		p.MonthsLeft = (deadline.Year()-now.Year())*12 + int(deadline.Month()-now.Month()) + 1
+		if p.MonthsLeft < 1 {
+			p.MonthsLeft = 0
+			p.RequiredMonthly = p.Remaining
+		} else {
+			p.RequiredMonthly = p.Remaining / float64(p.MonthsLeft)
+		}
+	}
+	if p.AverageMonthly > 0 {
+		months := p.Remaining / p.AverageMonthly
+		whole := int(months)
+		days := int((months - float64(whole)) * 30)
+		p.ProjectedAt = now.AddDate(0, whole, days).Unix()
+		p.OnTrack = goal.Deadline == 0 || p.ProjectedAt <= goal.Deadline
+	}
+	return p
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zxc7563598/fintrack-backend/model"
+)
+
+func TestProjectSavingsGoal(t *testing.T) {
+	date := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+	now := date(2025, 3, 15)
+	tests := []struct {
+		name        string
+		goal        model.SavingsGoal
+		contributed float64
+		recent      []float64
+		want        SavingsGoalProjection
+	}{
+		{
+			name:        "已完成",
+			goal:        model.SavingsGoal{TargetAmount: 1000, InitialAmount: 200, Deadline: date(2025, 12, 31).Unix()},
+			contributed: 900,
+			recent:      []float64{100, 200},
+			want:        SavingsGoalProjection{Saved: 1100, Percent: 100, AverageMonthly: 150, ProjectedAt: now.Unix(), OnTrack: true},
+		},
+		{
+			name:        "按月均存入可按期完成",
+			goal:        model.SavingsGoal{TargetAmount: 1000, InitialAmount: 100, Deadline: date(2025, 12, 31).Unix()},
+			contributed: 200,
+			recent:      []float64{100, 100, 100},
+			want:        SavingsGoalProjection{Saved: 300, Remaining: 700, Percent: 30, MonthsLeft: 10, RequiredMonthly: 70, AverageMonthly: 100, ProjectedAt: date(2025, 10, 15).Unix(), OnTrack: true},
+		},
+		{
+			name:        "按月均存入无法按期完成",
+			goal:        model.SavingsGoal{TargetAmount: 1000, InitialAmount: 100, Deadline: date(2025, 6, 30).Unix()},
+			contributed: 200,
+			recent:      []float64{100, 100, 100},
+			want:        SavingsGoalProjection{Saved: 300, Remaining: 700, Percent: 30, MonthsLeft: 4, RequiredMonthly: 175, AverageMonthly: 100, ProjectedAt: date(2025, 10, 15).Unix()},
+		},
+		{
+			name:        "没有截止时间",
+			goal:        model.SavingsGoal{TargetAmount: 1000},
+			contributed: 300,
+			recent:      []float64{200, 150},
+			want:        SavingsGoalProjection{Saved: 300, Remaining: 700, Percent: 30, AverageMonthly: 175, ProjectedAt: date(2025, 7, 15).Unix(), OnTrack: true},
+		},
+		{
+			name:        "剩余月数不足整月按天计算",
+			goal:        model.SavingsGoal{TargetAmount: 1000},
+			contributed: 300,
+			recent:      []float64{280},
+			want:        SavingsGoalProjection{Saved: 300, Remaining: 700, Percent: 30, AverageMonthly: 280, ProjectedAt: date(2025, 5, 30).Unix(), OnTrack: true},
+		},
+		{
+			name:        "已过截止时间需一次存入全部差额",
+			goal:        model.SavingsGoal{TargetAmount: 1000, Deadline: date(2025, 2, 28).Unix()},
+			contributed: 300,
+			want:        SavingsGoalProjection{Saved: 300, Remaining: 700, Percent: 30, RequiredMonthly: 700},
+		},
+		{
+			name:        "月均为取出时无法预测",
+			goal:        model.SavingsGoal{TargetAmount: 300},
+			contributed: 100,
+			recent:      []float64{-50, 20},
+			want:        SavingsGoalProjection{Saved: 100, Remaining: 200, Percent: 33.33, AverageMonthly: -15},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ProjectSavingsGoal(tt.goal, tt.contributed, tt.recent, now); got != tt.want {
+				t.Errorf("ProjectSavingsGoal() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/zxc7563598/fintrack-backend/model"
+)
+
+// ErrInvalidSplitShares 分摊明细与分摊方式不符
+var ErrInvalidSplitShares = errors.New("invalid split shares")
+
+// 成员分摊参数
+type SplitShareInput struct {
+	MemberID uint
+	Value    float64 // 按比例为百分比，指定金额为金额，均摊时忽略
+}
+
+// 成员间的结算转账
+type SplitTransfer struct {
+	FromMemberID uint    `json:"from_member_id"`
+	ToMemberID   uint    `json:"to_member_id"`
+	Amount       float64 `json:"amount"`
+}
+
+// ComputeSplitShares 按分摊方式计算每个成员的应付金额
+// 以分为单位计算，除不尽的零头按顺序分给前面的成员，保证合计等于总额
+func ComputeSplitShares(mode model.SplitMode, amount float64, inputs []SplitShareInput) ([]model.SplitShare, error) {
+	if len(inputs) == 0 || amount <= 0 {
+		return nil, ErrInvalidSplitShares
+	}
+	total := toCents(amount)
+	cents := make([]int64, len(inputs))
+	switch mode {
+	case model.SplitEqual:
+		for i := range inputs {
+			cents[i] = total / int64(len(inputs))
+		}
+	case model.SplitPercent:
+		var percent float64
+		for _, in := range inputs {
+			if in.Value < 0 {
+				return nil, ErrInvalidSplitShares
+			}
+			percent += in.Value
+		}
+		if math.Abs(percent-100) > 0.01 {
+			return nil, ErrInvalidSplitShares
+		}
+		// 按比例合计折算，比例合计允许的误差不会使份额超过总额
+		for i, in := range inputs {
+			cents[i] = int64(math.Floor(float64(total) * in.Value / percent))
+		}
+	case model.SplitExact:
+		var sum int64
+		for i, in := range inputs {
+			if in.Value < 0 {
+				return nil, ErrInvalidSplitShares
+			}
+			cents[i] = toCents(in.Value)
+			sum += cents[i]
+		}
+		if sum != total {
+			return nil, ErrInvalidSplitShares
+		}
+	default:
+		return nil, ErrInvalidSplitShares
+	}
+	// 分配零头
+	var sum int64
+	for _, c := range cents {
+		sum += c
+	}
+	for i := 0; sum < total; i = (i + 1) % len(cents) {
+		cents[i]++
+		sum++
+	}
+	shares := make([]model.SplitShare, 0, len(inputs))
+	for i, in := range inputs {
+		value := in.Value
+		if mode == model.SplitEqual {
+			value = 0
+		}
+		shares = append(shares, model.SplitShare{MemberID: in.MemberID, Value: value, Amount: float64(cents[i]) / 100})
+	}
+	return shares, nil
+}
+
+// ComputeSplitBalances 计算分摊组内每个成员的净余额，正数为应收、负数为应付
+// 付款人垫付的金额计为应收，成员的份额计为应付，结算转账从付款方应付中扣除
+func ComputeSplitBalances(expenses []model.SplitExpense, settlements []model.SplitSettlement) map[uint]float64 {
+	cents := map[uint]int64{}
+	for _, e := range expenses {
+		cents[e.PayerMemberID] += toCents(e.Amount)
+		for _, s := range e.Shares {
+			cents[s.MemberID] -= toCents(s.Amount)
+		}
+	}
+	for _, s := range settlements {
+		cents[s.FromMemberID] += toCents(s.Amount)
+		cents[s.ToMemberID] -= toCents(s.Amount)
+	}
+	balances := make(map[uint]float64, len(cents))
+	for id, c := range cents {
+		balances[id] = float64(c) / 100
+	}
+	return balances
+}
+
+// SettleUp 根据净余额生成尽量少的结算转账
+// 每次由应付最多的成员向应收最多的成员转账，直至全部结清
+func SettleUp(balances map[uint]float64) []SplitTransfer {
+	type entry struct {
+		memberID uint
+		cents    int64
+	}
+	var creditors, debtors []entry
+	for id, b := range balances {
+		c := toCents(b)
+		if c > 0 {
+			creditors = append(creditors, entry{id, c})
+		} else if c < 0 {
+			debtors = append(debtors, entry{id, -c})
+		}
+	}
+	transfers := []SplitTransfer{}
+	for len(creditors) > 0 && len(debtors) > 0 {
+		sort.Slice(creditors, func(i, j int) bool {
+			if creditors[i].cents != creditors[j].cents {
+				return creditors[i].cents > creditors[j].cents
+			}
+			return creditors[i].memberID < creditors[j].memberID
+		})
+		sort.Slice(debtors, func(i, j int) bool {
+			if debtors[i].cents != debtors[j].cents {
+				return debtors[i].cents > debtors[j].cents
+			}
+			return debtors[i].memberID < debtors[j].memberID
+		})
+		amount := min(creditors[0].cents, debtors[0].cents)
+		transfers = append(transfers, SplitTransfer{
+			FromMemberID: debtors[0].memberID,
+			ToMemberID:   creditors[0].memberID,
+			Amount:       float64(amount) / 100,
+		})
+		creditors[0].cents -= amount
+		debtors[0].cents -= amount
+		if creditors[0].cents == 0 {
+			creditors = creditors[1:]
+		}
+		if debtors[0].cents == 0 {
+			debtors = debtors[1:]
+		}
+	}
+	return transfers
+}
+
+// 金额转换为分
+func toCents(v float64) int64 {
+	return int64(math.Round(v * 100))
+}
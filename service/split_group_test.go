@@ -0,0 +1,117 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/zxc7563598/fintrack-backend/model"
+)
+
+func TestComputeSplitShares(t *testing.T) {
+	members := func(values ...float64) []SplitShareInput {
+		inputs := make([]SplitShareInput, 0, len(values))
+		for i, v := range values {
+			inputs = append(inputs, SplitShareInput{MemberID: uint(i + 1), Value: v})
+		}
+		return inputs
+	}
+	tests := []struct {
+		name    string
+		mode    model.SplitMode
+		amount  float64
+		inputs  []SplitShareInput
+		want    []float64
+		wantErr error
+	}{
+		{"均摊零头分给前面的成员", model.SplitEqual, 100, members(0, 0, 0), []float64{33.34, 33.33, 33.33}, nil},
+		{"均摊不足每人一分", model.SplitEqual, 0.05, members(0, 0, 0), []float64{0.02, 0.02, 0.01}, nil},
+		{"按比例零头分给前面的成员", model.SplitPercent, 10.01, members(50, 50), []float64{5.01, 5}, nil},
+		{"按比例三人", model.SplitPercent, 100, members(33.33, 33.33, 33.34), []float64{33.33, 33.33, 33.34}, nil},
+		{"比例合计略超100不超过总额", model.SplitPercent, 100, members(50.01, 50), []float64{50.01, 49.99}, nil},
+		{"比例合计略低于100仍分完总额", model.SplitPercent, 100, members(50, 49.99), []float64{50.01, 49.99}, nil},
+		{"比例合计超出误差", model.SplitPercent, 100, members(60, 40.02), nil, ErrInvalidSplitShares},
+		{"比例为负数", model.SplitPercent, 100, members(110, -10), nil, ErrInvalidSplitShares},
+		{"指定金额", model.SplitExact, 100, members(30.5, 69.5), []float64{30.5, 69.5}, nil},
+		{"指定金额合计不等于总额", model.SplitExact, 100, members(30, 60), nil, ErrInvalidSplitShares},
+		{"没有成员", model.SplitEqual, 100, nil, nil, ErrInvalidSplitShares},
+		{"金额为0", model.SplitEqual, 0, members(0, 0), nil, ErrInvalidSplitShares},
+		{"未知分摊方式", model.SplitMode(9), 100, members(0, 0), nil, ErrInvalidSplitShares},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shares, err := ComputeSplitShares(tt.mode, tt.amount, tt.inputs)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			var got []float64
+			var sum int64
+			for i, s := range shares {
+				if s.MemberID != tt.inputs[i].MemberID {
+					t.Errorf("shares[%d].MemberID = %d, want %d", i, s.MemberID, tt.inputs[i].MemberID)
+				}
+				got = append(got, s.Amount)
+				sum += toCents(s.Amount)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("amounts = %v, want %v", got, tt.want)
+			}
+			if sum != toCents(tt.amount) {
+				t.Errorf("sum = %d cents, want %d", sum, toCents(tt.amount))
+			}
+		})
+	}
+}
+
+func TestComputeSplitBalances(t *testing.T) {
+	expenses := []model.SplitExpense{
+		{PayerMemberID: 1, Amount: 100, Shares: []model.SplitShare{{MemberID: 1, Amount: 33.34}, {MemberID: 2, Amount: 33.33}, {MemberID: 3, Amount: 33.33}}},
+		{PayerMemberID: 2, Amount: 0.3, Shares: []model.SplitShare{{MemberID: 2, Amount: 0.1}, {MemberID: 3, Amount: 0.2}}},
+	}
+	settlements := []model.SplitSettlement{{FromMemberID: 3, ToMemberID: 1, Amount: 10}}
+	want := map[uint]float64{1: 56.66, 2: -33.13, 3: -23.53}
+	if got := ComputeSplitBalances(expenses, settlements); !reflect.DeepEqual(got, want) {
+		t.Errorf("ComputeSplitBalances() = %v, want %v", got, want)
+	}
+}
+
+func TestSettleUp(t *testing.T) {
+	tests := []struct {
+		name     string
+		balances map[uint]float64
+		want     []SplitTransfer
+	}{
+		{"已结清", map[uint]float64{1: 0, 2: 0}, []SplitTransfer{}},
+		{"没有成员", nil, []SplitTransfer{}},
+		{
+			"多人向一人转账",
+			map[uint]float64{1: 30, 2: -10, 3: -20},
+			[]SplitTransfer{{FromMemberID: 3, ToMemberID: 1, Amount: 20}, {FromMemberID: 2, ToMemberID: 1, Amount: 10}},
+		},
+		{
+			"应付最多的成员先向应收最多的成员转账",
+			map[uint]float64{1: 50, 2: 25, 3: -40, 4: -35},
+			[]SplitTransfer{{FromMemberID: 3, ToMemberID: 1, Amount: 40}, {FromMemberID: 4, ToMemberID: 2, Amount: 25}, {FromMemberID: 4, ToMemberID: 1, Amount: 10}},
+		},
+		{
+			"均摊零头，金额相同按成员ID",
+			map[uint]float64{1: 66.66, 2: -33.33, 3: -33.33},
+			[]SplitTransfer{{FromMemberID: 2, ToMemberID: 1, Amount: 33.33}, {FromMemberID: 3, ToMemberID: 1, Amount: 33.33}},
+		},
+		{
+			"浮点误差按分计算",
+			map[uint]float64{1: 0.1 + 0.2, 2: -0.3},
+			[]SplitTransfer{{FromMemberID: 2, ToMemberID: 1, Amount: 0.3}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SettleUp(tt.balances); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SettleUp() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/zxc7563598/fintrack-backend/model"
+)
+
+// 周期识别参数：标准间隔天数及允许的偏差
+var cadenceWindows = []struct {
+	cadence   model.Cadence
+	days      float64
+	tolerance float64
+	minCount  int
+}{
+	{model.CadenceWeekly, 7, 1.5, 4},
+	{model.CadenceBiweekly, 14, 2, 3},
+	{model.CadenceMonthly, 30.44, 4, 3},
+	{model.CadenceQuarterly, 91.3, 10, 3},
+	{model.CadenceYearly, 365.25, 15, 2},
+}
+
+// 同一订阅每期金额允许的相对偏差
+const subscriptionAmountTolerance = 0.2
+
+// 检测到的周期性扣费
+type DetectedSubscription struct {
+	MerchantID    uint
+	Counterparty  string
+	TradeType     string
+	PaymentMethod string
+	Cadence       model.Cadence
+	Amount        float64
+	Occurrences   int
+	FirstTradeAt  int64
+	LastTradeAt   int64
+	NextTradeAt   int64
+}
+
+// DetectSubscriptions 从支出记录中识别同一交易对方按固定周期、相近金额重复扣费的序列
+// 已停止扣费（超过两个周期未再出现）的序列不会返回
+func DetectSubscriptions(records []model.BillRecord, now time.Time) []DetectedSubscription {
+	// 按商户（未规范化时按交易对方）分组
+	groups := map[string][]model.BillRecord{}
+	for _, r := range records {
+		if r.IncomeType != uint8(model.IncomeTypeExpense) || r.Amount <= 0 {
+			continue
+		}
+		key := "c:" + r.Counterparty
+		if r.MerchantID > 0 {
+			key = "m:" + strconv.FormatUint(uint64(r.MerchantID), 10)
+		}
+		groups[key] = append(groups[key], r)
+	}
+	var result []DetectedSubscription
+	for _, group := range groups {
+		// 同一交易对方可能存在多个不同金额的订阅，先按金额聚类
+		for _, cluster := range clusterByAmount(group) {
+			if s, ok := detectSeries(cluster, now); ok {
+				result = append(result, s)
+			}
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].NextTradeAt < result[j].NextTradeAt
+	})
+	return result
+}
+
+// 按金额排序后，相邻金额偏差超过阈值即拆分为新的分组
+func clusterByAmount(records []model.BillRecord) [][]model.BillRecord {
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Amount < records[j].Amount
+	})
+	var clusters [][]model.BillRecord
+	var current []model.BillRecord
+	for _, r := range records {
+		if len(current) > 0 && r.Amount > current[0].Amount*(1+subscriptionAmountTolerance) {
+			clusters = append(clusters, current)
+			current = nil
+		}
+		current = append(current, r)
+	}
+	if len(current) > 0 {
+		clusters = append(clusters, current)
+	}
+	return clusters
+}
+
+// 判断一组记录是否构成固定周期的扣费
+func detectSeries(records []model.BillRecord, now time.Time) (DetectedSubscription, bool) {
+	if len(records) < 2 {
+		return DetectedSubscription{}, false
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].TradeTime < records[j].TradeTime
+	})
+	// 计算相邻两次扣费的间隔天数（同一天的多笔视为一次）
+	var intervals []float64
+	for i := 1; i < len(records); i++ {
+		days := float64(records[i].TradeTime-records[i-1].TradeTime) / 86400
+		if days < 1 {
+			continue
+		}
+		intervals = append(intervals, days)
+	}
+	if len(intervals) == 0 {
+		return DetectedSubscription{}, false
+	}
+	median := medianOf(intervals)
+	for _, w := range cadenceWindows {
+		if math.Abs(median-w.days) > w.tolerance {
+			continue
+		}
+		if len(intervals)+1 < w.minCount {
+			return DetectedSubscription{}, false
+		}
+		// 大部分间隔都需落在周期范围内
+		regular := 0
+		for _, d := range intervals {
+			if math.Abs(d-w.days) <= w.tolerance {
+				regular++
+			}
+		}
+		if float64(regular) < float64(len(intervals))*0.75 {
+			return DetectedSubscription{}, false
+		}
+		first := records[0]
+		last := records[len(records)-1]
+		// 超过两个周期没有扣费，视为已停止
+		if now.Sub(time.Unix(last.TradeTime, 0)).Hours()/24 > w.days*2+w.tolerance {
+			return DetectedSubscription{}, false
+		}
+		return DetectedSubscription{
+			MerchantID:    last.MerchantID,
+			Counterparty:  last.Counterparty,
+			TradeType:     last.TradeType,
+			PaymentMethod: last.PaymentMethod,
+			Cadence:       w.cadence,
+			Amount:        last.Amount,
+			Occurrences:   len(intervals) + 1,
+			FirstTradeAt:  first.TradeTime,
+			LastTradeAt:   last.TradeTime,
+			NextTradeAt:   w.cadence.Next(time.Unix(last.TradeTime, 0)).Unix(),
+		}, true
+	}
+	return DetectedSubscription{}, false
+}
+
+func medianOf(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zxc7563598/fintrack-backend/model"
+)
+
+func TestDetectSubscriptions(t *testing.T) {
+	now := time.Date(2025, 6, 20, 12, 0, 0, 0, time.Local)
+	// 从 start 开始按 step 生成 n 笔扣费
+	series := func(counterparty string, amount float64, start time.Time, n int, step func(time.Time, int) time.Time) []model.BillRecord {
+		records := make([]model.BillRecord, 0, n)
+		for i := 0; i < n; i++ {
+			records = append(records, model.BillRecord{
+				Counterparty: counterparty,
+				IncomeType:   uint8(model.IncomeTypeExpense),
+				Amount:       amount,
+				TradeTime:    step(start, i).Unix(),
+			})
+		}
+		return records
+	}
+	monthly := func(t time.Time, i int) time.Time { return t.AddDate(0, i, 0) }
+	weekly := func(t time.Time, i int) time.Time { return t.AddDate(0, 0, 7*i) }
+	merge := func(groups ...[]model.BillRecord) []model.BillRecord {
+		var all []model.BillRecord
+		for _, g := range groups {
+			all = append(all, g...)
+		}
+		return all
+	}
+	type want struct {
+		counterparty string
+		cadence      model.Cadence
+		amount       float64
+		occurrences  int
+	}
+	tests := []struct {
+		name    string
+		records []model.BillRecord
+		want    []want
+	}{
+		{
+			name:    "按月扣费",
+			records: series("Netflix", 15, time.Date(2025, 1, 5, 9, 0, 0, 0, time.Local), 6, monthly),
+			want:    []want{{"Netflix", model.CadenceMonthly, 15, 6}},
+		},
+		{
+			name:    "按周扣费",
+			records: series("健身房", 50, time.Date(2025, 5, 2, 9, 0, 0, 0, time.Local), 7, weekly),
+			want:    []want{{"健身房", model.CadenceWeekly, 50, 7}},
+		},
+		{
+			name:    "次数不足",
+			records: series("Netflix", 15, time.Date(2025, 5, 5, 9, 0, 0, 0, time.Local), 2, monthly),
+			want:    nil,
+		},
+		{
+			name:    "已停止扣费",
+			records: series("Netflix", 15, time.Date(2024, 6, 5, 9, 0, 0, 0, time.Local), 6, monthly),
+			want:    nil,
+		},
+		{
+			name: "非支出不参与识别",
+			records: func() []model.BillRecord {
+				records := series("工资", 8000, time.Date(2025, 1, 10, 9, 0, 0, 0, time.Local), 6, monthly)
+				for i := range records {
+					records[i].IncomeType = uint8(model.IncomeTypeIncome)
+				}
+				return records
+			}(),
+			want: nil,
+		},
+		{
+			name: "同一交易对方不同金额分别识别",
+			records: merge(
+				series("Apple", 6, time.Date(2025, 1, 3, 9, 0, 0, 0, time.Local), 6, monthly),
+				series("Apple", 68, time.Date(2025, 1, 15, 9, 0, 0, 0, time.Local), 6, monthly),
+			),
+			want: []want{{"Apple", model.CadenceMonthly, 6, 6}, {"Apple", model.CadenceMonthly, 68, 6}},
+		},
+		{
+			name: "间隔不规律",
+			records: func() []model.BillRecord {
+				var records []model.BillRecord
+				for _, day := range []int{1, 3, 20, 22, 60, 61} {
+					records = append(records, model.BillRecord{
+						Counterparty: "便利店",
+						IncomeType:   uint8(model.IncomeTypeExpense),
+						Amount:       20,
+						TradeTime:    time.Date(2025, 4, day, 9, 0, 0, 0, time.Local).Unix(),
+					})
+				}
+				return records
+			}(),
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DetectSubscriptions(tt.records, now)
+			if len(got) != len(tt.want) {
+				t.Fatalf("DetectSubscriptions() returned %d subscriptions, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i, w := range tt.want {
+				g := got[i]
+				if g.Counterparty != w.counterparty || g.Cadence != w.cadence || g.Amount != w.amount || g.Occurrences != w.occurrences {
+					t.Errorf("DetectSubscriptions()[%d] = %+v, want %+v", i, g, w)
+				}
+				if g.NextTradeAt <= g.LastTradeAt {
+					t.Errorf("DetectSubscriptions()[%d] next trade %d not after last trade %d", i, g.NextTradeAt, g.LastTradeAt)
+				}
+			}
+		})
+	}
+}
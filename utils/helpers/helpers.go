@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -161,6 +162,11 @@ func ParseAmount(s string) (float64, error) {
 	return strconv.ParseFloat(s, 64)
 }
 
+// 比较两个金额是否相等（精确到分）
+func AmountEqual(a, b float64) bool {
+	return math.Round(a*100) == math.Round(b*100)
+}
+
 // 阿里云CSV基本信息结构体
 type ExportInfo struct {
 	Name          string